@@ -0,0 +1,156 @@
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client 为 AMLL TTML API 的客户端，可被多个 goroutine 同时使用
+type Client struct {
+	// BaseURL 为服务器地址，如 http://localhost:43594
+	BaseURL string
+	// HTTPClient 为空时使用 http.DefaultClient
+	HTTPClient *http.Client
+	// MaxRetries 为网络错误、429 与 5xx 响应的最大重试次数（不含首次请求）
+	MaxRetries int
+	// RetryBackoff 为首次重试前的等待时间，之后每次翻倍
+	RetryBackoff time.Duration
+}
+
+// New 返回指向 baseURL 的客户端，默认最多重试 2 次
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:      strings.TrimRight(baseURL, "/"),
+		MaxRetries:   2,
+		RetryBackoff: 200 * time.Millisecond,
+	}
+}
+
+// Search 搜索歌词元数据；分页时把上一页的 NextCursor 填入 req.Cursor
+func (c *Client) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	var resp SearchResponse
+	if err := c.doJSON(ctx, http.MethodPost, "/api/search", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Lookup 按平台与歌曲 ID 精确查询索引条目，条目不存在时返回 Code 为 LYRIC_NOT_FOUND 的 *Error
+func (c *Client) Lookup(ctx context.Context, platform, musicID string) (*SearchResult, error) {
+	params := url.Values{"platform": {platform}, "musicId": {musicID}}
+	var result SearchResult
+	if err := c.doJSON(ctx, http.MethodGet, "/api/lookup", params, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Download 下载歌词文件内容，format 为空时为 ttml
+func (c *Client) Download(ctx context.Context, platform, musicID, format string) ([]byte, error) {
+	params := url.Values{"platform": {platform}, "musicId": {musicID}}
+	if format != "" {
+		params.Set("format", format)
+	}
+	return c.do(ctx, http.MethodGet, "/api/download", params, nil)
+}
+
+// Status 返回服务器状态
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var status Status
+	if err := c.doJSON(ctx, http.MethodGet, "/api/status", nil, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path string, params url.Values, body []byte, out interface{}) error {
+	data, err := c.do(ctx, method, path, params, body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// do 发送请求并返回响应体；可重试的失败按指数退避重试，ctx 取消时立即返回
+func (c *Client) do(ctx context.Context, method, path string, params url.Values, body []byte) ([]byte, error) {
+	u := strings.TrimRight(c.BaseURL, "/") + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	backoff := c.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		data, err := c.once(ctx, method, u, body)
+		if err == nil || attempt >= c.MaxRetries || !retryable(err) {
+			return data, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) once(ctx context.Context, method, u string, body []byte) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		json.Unmarshal(data, apiErr)
+		return nil, apiErr
+	}
+	return data, nil
+}
+
+// retryable 判断失败是否值得重试：网络错误、429 与 5xx（ctx 已取消或超时时不重试）
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	return true
+}
+
+// IsNotFound 判断错误是否表示歌词或条目不存在
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Code == CodeLyricNotFound
+}
@@ -0,0 +1,153 @@
+// Package apiclient 是 AMLL TTML API 的 Go 客户端，封装搜索、精确查询、下载与状态接口的请求与响应类型，
+// 供 Go 编写的播放器直接调用，无需手写 HTTP 请求与 JSON 解析。
+package apiclient
+
+import (
+	"fmt"
+	"time"
+)
+
+// MetadataField 为一项元数据，如 musicName、artists
+type MetadataField struct {
+	Key    string
+	Values []string
+}
+
+// Metadata 为原始的 [key, [values...]] 元数据对列表，保持索引中的顺序
+type Metadata [][]interface{}
+
+// Fields 将原始元数据转换为类型化字段，结构不合法的项被跳过
+func (m Metadata) Fields() []MetadataField {
+	fields := make([]MetadataField, 0, len(m))
+	for _, pair := range m {
+		if len(pair) < 2 {
+			continue
+		}
+		key, ok := pair[0].(string)
+		if !ok {
+			continue
+		}
+		field := MetadataField{Key: key}
+		if values, ok := pair[1].([]interface{}); ok {
+			for _, v := range values {
+				if s, ok := v.(string); ok {
+					field.Values = append(field.Values, s)
+				}
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// Get 返回指定键的全部取值，不存在时返回 nil
+func (m Metadata) Get(key string) []string {
+	for _, f := range m.Fields() {
+		if f.Key == key {
+			return f.Values
+		}
+	}
+	return nil
+}
+
+// SearchResult 为一条搜索结果或精确查询得到的索引条目
+type SearchResult struct {
+	ID             string            `json:"id"`
+	RawLyricFile   string            `json:"rawLyricFile"`
+	Metadata       Metadata          `json:"metadata"`
+	Platforms      []string          `json:"platforms"`
+	PlatformIDs    map[string]string `json:"platformIds,omitempty"` // 合并结果中各平台的歌曲 ID
+	Score          float64           `json:"score"`
+	Updated        time.Time         `json:"updated,omitzero"`
+	Lang           string            `json:"lang,omitempty"`
+	HasTranslation bool              `json:"hasTranslation"`
+	HasRoman       bool              `json:"hasRoman"`
+	WordTimed      bool              `json:"wordTimed"`
+	FileSize       *int64            `json:"fileSize,omitempty"` // 原始歌词文件的字节数，未知时为 nil
+	FileMtime      time.Time         `json:"fileMtime,omitzero"`
+	Title          string            `json:"title,omitempty"` // 第一个歌名
+	Artists        []string          `json:"artists,omitempty"`
+	Album          string            `json:"album,omitempty"` // 第一个专辑，元数据没有专辑时可能由服务端从上游平台补全
+	DurationMs     int64             `json:"durationMs,omitempty"`
+	CoverURL       string            `json:"coverUrl,omitempty"`
+	DownloadURL    string            `json:"downloadUrl,omitempty"`  // 原始歌词文件的下载地址
+	DownloadURLs   map[string]string `json:"downloadUrls,omitempty"` // 各格式的下载地址，对应文件不一定存在
+	Source         string            `json:"source,omitempty"`       // 结果来源：main、叠加目录或附加仓库名，来自回退 API 或对等实例时为其名称
+}
+
+// SearchRequest 为 POST /api/search 的请求体；Query 与 Title/Artists/Album（结构化搜索）二选一
+type SearchRequest struct {
+	Query          string   `json:"query,omitempty"`
+	Title          string   `json:"title,omitempty"`
+	Artists        []string `json:"artists,omitempty"`
+	Album          string   `json:"album,omitempty"`
+	Platforms      []string `json:"platforms,omitempty"`
+	Limit          int      `json:"limit,omitempty"`
+	Cursor         string   `json:"cursor,omitempty"`
+	MinScore       float64  `json:"minScore,omitempty"`
+	Sort           string   `json:"sort,omitempty"` // score、updated 或 wordTimed
+	Lang           string   `json:"lang,omitempty"`
+	HasTranslation *bool    `json:"hasTranslation,omitempty"`
+	HasRoman       *bool    `json:"hasRoman,omitempty"`
+	WordTimed      *bool    `json:"wordTimed,omitempty"`
+	MusicID        string   `json:"musicId,omitempty"`
+	Sources        []string `json:"sources,omitempty"`  // 仅返回这些来源的结果
+	Phonetic       bool     `json:"phonetic,omitempty"` // 同时按读音匹配歌名与歌手
+	Dedupe         string   `json:"dedupe,omitempty"`   // 为 content 时内容相同的歌词只返回一个结果
+}
+
+// SearchResponse 为搜索响应（不支持 groupBy=song 的分组格式）
+type SearchResponse struct {
+	Count        int            `json:"count"`
+	Total        int            `json:"total"`
+	Results      []SearchResult `json:"results"`
+	HasMore      bool           `json:"hasMore"`
+	NextCursor   string         `json:"nextCursor,omitempty"`
+	IndexChanged bool           `json:"indexChanged,omitempty"`
+	Cached       bool           `json:"cached,omitempty"`
+	FailedPeers  []string       `json:"failedPeers,omitempty"`
+	SubmitURL    string         `json:"submitUrl,omitempty"`
+}
+
+// Status 为 GET /api/status 的响应
+type Status struct {
+	Status         string         `json:"status"`
+	LastUpdateTime string         `json:"last_update_time"`
+	StartedAt      string         `json:"started_at"`
+	UptimeSeconds  int64          `json:"uptime_seconds"`
+	TotalEntries   int            `json:"total_entries"`
+	PlatformStats  map[string]int `json:"platform_stats"`
+	RepoURL        string         `json:"repo_url"`
+	DataCommit     string         `json:"data_commit"`
+	DataCommitTime string         `json:"data_commit_time"`
+	DataBranch     string         `json:"data_branch"`
+	CacheSize      int            `json:"cache_size"`
+	MetadataIssues int            `json:"metadata_issues"`
+	SlowQueries    int64          `json:"slow_queries"`
+}
+
+// API 错误码，与服务端 README 的错误表一致
+const (
+	CodeInvalidRequest   = "INVALID_REQUEST"
+	CodePlatformInvalid  = "PLATFORM_INVALID"
+	CodeLyricNotFound    = "LYRIC_NOT_FOUND"
+	CodeDownloadDisabled = "DOWNLOAD_DISABLED"
+	CodeSearchTimeout    = "SEARCH_TIMEOUT"
+	CodeDataUnavailable  = "DATA_UNAVAILABLE"
+	CodeInternal         = "INTERNAL_ERROR"
+)
+
+// Error 为服务端返回的错误响应
+type Error struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	RequestID  string `json:"requestId"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("amll api: HTTP %d", e.StatusCode)
+	}
+	return fmt.Sprintf("amll api: %s: %s (request %s)", e.Code, e.Message, e.RequestID)
+}
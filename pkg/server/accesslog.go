@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// --- 访问日志采样 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	accessLogSample = new(int) // 成功且不慢的请求每 N 个记录一个，1 表示全部记录
+
+	accessLogSeq     atomic.Uint64
+	accessLogSkipped atomic.Int64 // 因采样未记录的请求数，见 /api/status
+)
+
+// statusWriter 记录处理器写出的状态码，未显式调用 WriteHeader 时为 200
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush 使 SSE 等流式响应在包装后仍可刷新
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// logAccess 记录一条访问日志：错误（状态码 >= 400）与耗时达到 -slow-query 的请求总是记录，
+// 其余请求按 -access-log-sample 采样
+func logAccess(r *http.Request, status int, elapsed time.Duration) {
+	if *quiet {
+		return
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+	slow := *slowQueryThreshold > 0 && elapsed >= *slowQueryThreshold
+	if status < 400 && !slow && *accessLogSample > 1 && accessLogSeq.Add(1)%uint64(*accessLogSample) != 0 {
+		accessLogSkipped.Add(1)
+		return
+	}
+	logInfof("[%s] %s %s %d %v (%s)", r.Method, r.URL.Path, r.RemoteAddr, status, elapsed, requestID(r))
+}
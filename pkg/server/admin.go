@@ -0,0 +1,36 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// --- 管理接口鉴权 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	adminToken = new(string) // 为空时管理接口不可用
+)
+
+// requireAdmin 要求请求携带 Authorization: Bearer <-admin-token>；未配置令牌时管理接口一律返回 403
+func requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if *adminToken == "" {
+			writeError(w, r, http.StatusForbidden, codeAdminDisabled, "Admin API is disabled (no -admin-token configured)")
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || !validAdminToken(token) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			writeError(w, r, http.StatusUnauthorized, codeUnauthorized, "Missing or invalid admin token")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// validAdminToken 判断令牌是否与 -admin-token 一致（未配置令牌时始终为 false）
+func validAdminToken(token string) bool {
+	return *adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(*adminToken)) == 1
+}
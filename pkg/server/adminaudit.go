@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+	grpcpeer "google.golang.org/grpc/peer"
+)
+
+// --- 管理操作审计日志 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	auditLogFile = new(string) // 非空时管理操作以 JSON Lines 追加写入该文件
+
+	auditLogMu sync.Mutex
+)
+
+// auditRecord 为审计日志中的一行
+type auditRecord struct {
+	Time      time.Time `json:"time"`
+	Action    string    `json:"action"`    // update、update-dry-run、cache-clear
+	Transport string    `json:"transport"` // http 或 grpc
+	RemoteIP  string    `json:"remoteIp"`
+	Identity  string    `json:"identity"` // admin（携带有效的管理令牌）或 anonymous
+	RequestID string    `json:"requestId,omitempty"`
+	Outcome   string    `json:"outcome"` // 如 updated、up-to-date、rejected、failed
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// writeAuditRecord 将一条记录追加到 -audit-log；文件以追加模式打开，不会改写已有内容
+func writeAuditRecord(rec auditRecord) {
+	if *auditLogFile == "" {
+		return
+	}
+	rec.Time = time.Now()
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+	auditLogMu.Lock()
+	defer auditLogMu.Unlock()
+	f, err := os.OpenFile(*auditLogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err == nil {
+		_, err = f.Write(append(line, '\n'))
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		logWarnf("Warning: failed to write audit log %s: %v", *auditLogFile, err)
+	}
+}
+
+func identityFromToken(token string) string {
+	if validAdminToken(token) {
+		return "admin"
+	}
+	return "anonymous"
+}
+
+// auditHTTP 记录一次通过 HTTP 触发的管理操作
+func auditHTTP(r *http.Request, action, outcome, detail string) {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	writeAuditRecord(auditRecord{
+		Action:    action,
+		Transport: "http",
+		RemoteIP:  ip,
+		Identity:  identityFromToken(token),
+		RequestID: requestID(r),
+		Outcome:   outcome,
+		Detail:    detail,
+	})
+}
+
+// auditGRPC 记录一次通过 gRPC 触发的管理操作，身份取自 authorization 元数据
+func auditGRPC(ctx context.Context, action, outcome, detail string) {
+	rec := auditRecord{Action: action, Transport: "grpc", Outcome: outcome, Detail: detail, Identity: "anonymous"}
+	if p, ok := grpcpeer.FromContext(ctx); ok {
+		rec.RemoteIP = p.Addr.String()
+		if ip, _, err := net.SplitHostPort(rec.RemoteIP); err == nil {
+			rec.RemoteIP = ip
+		}
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("authorization"); len(v) > 0 {
+			token, _ := strings.CutPrefix(v[0], "Bearer ")
+			rec.Identity = identityFromToken(token)
+		}
+	}
+	writeAuditRecord(rec)
+}
+
+// updateOutcome 将 runUpdate 的结果转换为审计日志中的 outcome
+func updateOutcome(updated bool) string {
+	if updated {
+		return "updated"
+	}
+	return "up-to-date"
+}
+
+// cacheClearHandler 清空查询缓存（需要管理令牌）
+func cacheClearHandler(w http.ResponseWriter, r *http.Request) {
+	clearCache()
+	auditHTTP(r, "cache-clear", "cleared", "")
+	writeJSON(w, r, map[string]string{"message": "Query cache cleared"})
+}
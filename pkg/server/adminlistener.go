@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// --- 管理端口 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	adminAddr = new(string) // 非空时会修改状态或暴露内部信息的接口只在该地址上提供，公开端口只读
+)
+
+// registerAdminRoutes 注册会修改服务状态（同步、清空缓存、重建索引、清理仓库）或暴露内部统计与诊断信息的路由
+func registerAdminRoutes(mux *http.ServeMux) {
+	const (
+		get  = http.MethodGet
+		head = http.MethodHead
+		post = http.MethodPost
+	)
+	mux.HandleFunc("/api/update", Middleware(allowMethods(updateHandler, get, post)))
+	mux.HandleFunc("/api/analytics", Middleware(allowMethods(requireAdmin(analyticsHandler), get, head)))
+	mux.HandleFunc("/api/cache/clear", Middleware(allowMethods(requireAdmin(cacheClearHandler), post)))
+	mux.HandleFunc("/api/reindex", Middleware(allowMethods(requireAdmin(reindexHandler), post)))
+	mux.HandleFunc("/api/prune", Middleware(allowMethods(requireAdmin(pruneHandler), post)))
+	mux.HandleFunc("/api/keys", Middleware(allowMethods(requireAdmin(apiKeysHandler), get, head)))
+	mux.HandleFunc("/api/download-stats", Middleware(allowMethods(requireAdmin(downloadStatsHandler), get, head)))
+	mux.HandleFunc("/api/validate", Middleware(allowMethods(requireAdmin(validateHandler), get, head)))
+	mux.HandleFunc("/api/audit", Middleware(allowMethods(requireAdmin(auditHandler), get, head)))
+	mux.HandleFunc("/api/snapshot", Middleware(allowMethods(requireAdmin(snapshotHandler), get, head)))
+}
+
+// newAdminMux 返回 -admin-addr 上的路由：管理接口、状态查询与 /debug/pprof/；
+// pprof 只在单独的管理端口上提供，不要求管理令牌
+func newAdminMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	registerAdminRoutes(mux)
+	mux.HandleFunc("/api/status", Middleware(allowMethods(statusHandler, http.MethodGet, http.MethodHead)))
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
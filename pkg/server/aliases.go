@@ -0,0 +1,92 @@
+package server
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// --- 歌手别名 ---
+
+// artistAliases 将小写的歌手名映射到其所在别名组的全部名称，由 mu 保护
+var artistAliases = make(map[string][]string)
+
+// loadAliases 读取别名文件：每行一组等价的名称，以 "|" 分隔，# 开头的行为注释，例如
+//
+//	G.E.M. | 邓紫棋 | 鄧紫棋
+//	米津玄師 | Kenshi Yonezu
+//
+// 同一名称出现在多行时，这些组会被合并
+func loadAliases(path string) (map[string][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	groups := make(map[string]*[]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var names []string
+		for _, name := range strings.Split(line, "|") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		if len(names) < 2 {
+			continue
+		}
+
+		// 与已有的组合并
+		group := &[]string{}
+		for _, name := range names {
+			if existing, ok := groups[strings.ToLower(name)]; ok && existing != group {
+				*group = append(*group, *existing...)
+			}
+		}
+		for _, name := range names {
+			if !containsFold(*group, name) {
+				*group = append(*group, name)
+			}
+		}
+		for _, name := range *group {
+			groups[strings.ToLower(name)] = group
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	aliases := make(map[string][]string, len(groups))
+	for key, group := range groups {
+		aliases[key] = *group
+	}
+	return aliases, nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandArtists 返回 artists 及其全部别名（去重，保持原有顺序在前）
+func expandArtists(aliases map[string][]string, artists []string) []string {
+	expanded := append([]string(nil), artists...)
+	for _, a := range artists {
+		for _, alias := range aliases[strings.ToLower(strings.TrimSpace(a))] {
+			if !containsFold(expanded, alias) {
+				expanded = append(expanded, alias)
+			}
+		}
+	}
+	return expanded
+}
@@ -0,0 +1,277 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- 查询统计 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	analyticsFile = new(string) // 非空时统计数据定期保存到该文件，重启后恢复
+)
+
+const (
+	// maxTrackedQueries 为统计频次的不同查询数上限，超出时淘汰次数最少且最久未出现的一项
+	maxTrackedQueries = 5000
+	// recentZeroResults 为保留的最近无结果查询条数（环形缓冲区）
+	recentZeroResults = 200
+	// analyticsSaveInterval 为统计数据写入 -analytics-file 的间隔
+	analyticsSaveInterval = time.Minute
+)
+
+// queryStat 为单个查询的累计统计
+type queryStat struct {
+	Query    string    `json:"query"`
+	Count    int       `json:"count"`
+	NoHits   int       `json:"noHits"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// zeroResultQuery 为环形缓冲区中的一次无结果查询
+type zeroResultQuery struct {
+	Query string    `json:"query"`
+	Time  time.Time `json:"time"`
+}
+
+// queryAnalytics 为全部统计数据，也是 -analytics-file 的文件格式
+type queryAnalytics struct {
+	Since   time.Time             `json:"since"`
+	Total   int                   `json:"total"`
+	Hits    int                   `json:"hits"`
+	NoHits  int                   `json:"noHits"`
+	Queries map[string]*queryStat `json:"queries"`
+	Recent  []zeroResultQuery     `json:"recentZeroResults"` // 按时间顺序，最多 recentZeroResults 条
+
+	dirty bool
+}
+
+var (
+	analytics   = &queryAnalytics{Since: time.Now(), Queries: make(map[string]*queryStat)}
+	analyticsMu sync.Mutex
+)
+
+// analyticsKey 返回用于统计的查询描述：全文搜索为小写查询词，结构化搜索为各字段的组合
+func analyticsKey(query string, fields fieldQuery) string {
+	if fields.empty() {
+		return strings.ToLower(strings.TrimSpace(query))
+	}
+	var parts []string
+	if fields.Title != "" {
+		parts = append(parts, "title:"+fields.Title)
+	}
+	if len(fields.Artists) > 0 {
+		parts = append(parts, "artists:"+strings.Join(fields.Artists, "/"))
+	}
+	if fields.Album != "" {
+		parts = append(parts, "album:"+fields.Album)
+	}
+	return strings.ToLower(strings.Join(parts, " "))
+}
+
+// recordQuery 记录一次搜索及其是否有结果（分页请求只在第一页记录）
+func recordQuery(key string, hit bool) {
+	if key == "" {
+		return
+	}
+	now := time.Now()
+	analyticsMu.Lock()
+	defer analyticsMu.Unlock()
+
+	a := analytics
+	a.dirty = true
+	a.Total++
+	if hit {
+		a.Hits++
+	} else {
+		a.NoHits++
+		a.Recent = append(a.Recent, zeroResultQuery{Query: key, Time: now})
+		if len(a.Recent) > recentZeroResults {
+			a.Recent = a.Recent[len(a.Recent)-recentZeroResults:]
+		}
+	}
+
+	stat, ok := a.Queries[key]
+	if !ok {
+		if len(a.Queries) >= maxTrackedQueries {
+			var victim *queryStat
+			for _, s := range a.Queries {
+				if victim == nil || s.Count < victim.Count || (s.Count == victim.Count && s.LastSeen.Before(victim.LastSeen)) {
+					victim = s
+				}
+			}
+			delete(a.Queries, victim.Query)
+		}
+		stat = &queryStat{Query: key}
+		a.Queries[key] = stat
+	}
+	stat.Count++
+	stat.LastSeen = now
+	if !hit {
+		stat.NoHits++
+	}
+}
+
+// topQueries 按 value 从大到小返回前 limit 个查询（limit 不大于 0 时返回全部），value 为 0 的查询不计入
+func topQueries(stats []queryStat, limit int, value func(queryStat) int) []queryStat {
+	var list []queryStat
+	for _, s := range stats {
+		if value(s) > 0 {
+			list = append(list, s)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if vi, vj := value(list[i]), value(list[j]); vi != vj {
+			return vi > vj
+		}
+		return list[i].Query < list[j].Query
+	})
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+// loadAnalytics 从 -analytics-file 恢复统计数据，文件不存在时从零开始
+func loadAnalytics() {
+	if *analyticsFile == "" {
+		return
+	}
+	data, err := os.ReadFile(*analyticsFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logWarnf("Warning: failed to read analytics from %s: %v", *analyticsFile, err)
+		}
+		return
+	}
+	loaded := &queryAnalytics{}
+	if err := json.Unmarshal(data, loaded); err != nil {
+		logWarnf("Warning: failed to parse analytics file %s: %v", *analyticsFile, err)
+		return
+	}
+	if loaded.Queries == nil {
+		loaded.Queries = make(map[string]*queryStat)
+	}
+	analyticsMu.Lock()
+	analytics = loaded
+	analyticsMu.Unlock()
+}
+
+// saveAnalytics 在统计数据有变化时写入 -analytics-file（先写临时文件再重命名）
+func saveAnalytics() {
+	analyticsMu.Lock()
+	if !analytics.dirty {
+		analyticsMu.Unlock()
+		return
+	}
+	data, err := json.Marshal(analytics)
+	analytics.dirty = false
+	analyticsMu.Unlock()
+	if err == nil {
+		tmp := *analyticsFile + ".tmp"
+		if err = os.WriteFile(tmp, data, 0o644); err == nil {
+			err = os.Rename(tmp, *analyticsFile)
+		}
+	}
+	if err != nil {
+		logWarnf("Warning: failed to save analytics to %s: %v", *analyticsFile, err)
+	}
+}
+
+// statsSavers 跟踪 saveOnInterval 启动的保存任务
+var statsSavers sync.WaitGroup
+
+// saveOnInterval 每隔 analyticsSaveInterval 调用一次 save，ctx 取消后再保存一次并退出，
+// 停止前最后一段时间的统计不会丢失
+func saveOnInterval(ctx context.Context, save func()) {
+	statsSavers.Add(1)
+	go func() {
+		defer statsSavers.Done()
+		ticker := time.NewTicker(analyticsSaveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				save()
+				return
+			case <-ticker.C:
+				save()
+			}
+		}
+	}()
+}
+
+// waitStatsSaved 等待各保存任务在 ctx 取消后的最后一次保存完成，wait 到期时不再等待
+func waitStatsSaved(wait context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		statsSavers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-wait.Done():
+		return wait.Err()
+	}
+}
+
+// startAnalytics 恢复已保存的统计数据，并定期保存直至 ctx 取消
+func startAnalytics(ctx context.Context) {
+	if *analyticsFile == "" {
+		return
+	}
+	loadAnalytics()
+	saveOnInterval(ctx, saveAnalytics)
+}
+
+// analyticsHandler 返回查询统计（需要管理令牌），limit 控制各排行的长度（默认 20）
+func analyticsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "limit must be a non-negative integer")
+			return
+		}
+		limit = n
+	}
+
+	analyticsMu.Lock()
+	a := analytics
+	stats := make([]queryStat, 0, len(a.Queries))
+	for _, s := range a.Queries {
+		stats = append(stats, *s)
+	}
+	recent := make([]zeroResultQuery, len(a.Recent))
+	// 最近的排在前面
+	for i, q := range a.Recent {
+		recent[len(a.Recent)-1-i] = q
+	}
+	total, hits, noHits, since := a.Total, a.Hits, a.NoHits, a.Since
+	analyticsMu.Unlock()
+
+	hitRate := 0.0
+	if total > 0 {
+		hitRate = float64(hits) / float64(total)
+	}
+	writeJSON(w, r, map[string]interface{}{
+		"status":            "success",
+		"since":             since,
+		"total":             total,
+		"hits":              hits,
+		"noHits":            noHits,
+		"hitRate":           hitRate,
+		"topQueries":        topQueries(stats, limit, func(s queryStat) int { return s.Count }),
+		"topZeroResult":     topQueries(stats, limit, func(s queryStat) int { return s.NoHits }),
+		"recentZeroResults": recent,
+	})
+}
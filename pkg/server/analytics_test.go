@@ -0,0 +1,25 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// ctx 取消后保存任务应立即再保存一次并退出，不等下一个周期，也不再继续运行
+func TestSaveOnIntervalFlushesOnCancel(t *testing.T) {
+	var saves atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	saveOnInterval(ctx, func() { saves.Add(1) })
+	cancel()
+
+	wait, cancelWait := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelWait()
+	if err := waitStatsSaved(wait); err != nil {
+		t.Fatalf("waiting for the final save: %v", err)
+	}
+	if got := saves.Load(); got != 1 {
+		t.Fatalf("saved %d times after cancel, want 1", got)
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime/debug"
+)
+
+// --- 统一错误响应 ---
+
+// API 错误码，客户端应依据 code 而非 message 判断错误类型
+const (
+	codeInvalidRequest       = "INVALID_REQUEST"
+	codeMethodNotAllowed     = "METHOD_NOT_ALLOWED"
+	codePayloadTooLarge      = "PAYLOAD_TOO_LARGE"
+	codeUnauthorized         = "UNAUTHORIZED"
+	codeAdminDisabled        = "ADMIN_DISABLED"
+	codePlatformInvalid      = "PLATFORM_INVALID"
+	codeLyricNotFound        = "LYRIC_NOT_FOUND"
+	codeDownloadDisabled     = "DOWNLOAD_DISABLED"
+	codeSyncDisabled         = "SYNC_DISABLED"
+	codeSyncFailed           = "SYNC_FAILED"
+	codeUpdateCooldown       = "UPDATE_COOLDOWN"
+	codeFallbackFailed       = "FALLBACK_FAILED"
+	codeSearchTimeout        = "SEARCH_TIMEOUT"
+	codeDataUnavailable      = "DATA_UNAVAILABLE"
+	codeStreamingUnsupported = "STREAMING_UNSUPPORTED"
+	codeQuotaExceeded        = "QUOTA_EXCEEDED"
+	codeSignatureInvalid     = "SIGNATURE_INVALID"
+	codeServerBusy           = "SERVER_BUSY"
+	codeInternal             = "INTERNAL_ERROR"
+)
+
+// apiError 为所有接口统一的错误响应格式
+type apiError struct {
+	Status    string `json:"status"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+type requestIDKey struct{}
+
+// newRequestID 优先沿用客户端传入的 X-Request-ID，否则随机生成
+func newRequestID(r *http.Request) string {
+	if id := r.Header.Get("X-Request-ID"); id != "" && len(id) <= 64 {
+		return id
+	}
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// withRequestID 将请求 ID 写入上下文与响应头
+func withRequestID(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := newRequestID(r)
+	w.Header().Set("X-Request-ID", id)
+	return r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id))
+}
+
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// writeError 以统一格式写出错误响应，message 按 Accept-Language 本地化（见 localize.go）
+func writeError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	lang := requestLanguage(r)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Language", lang)
+	w.Header().Add("Vary", "Accept-Language")
+	w.WriteHeader(status)
+	writeJSON(w, r, apiError{
+		Status:    "error",
+		Code:      code,
+		Message:   localizeMessage(lang, message),
+		RequestID: requestID(r),
+	})
+}
+
+// writeJSON 写出 JSON 响应体：不转义 HTML 字符（URL 中的 & 等保持原样），
+// 请求带 pretty=true 时缩进输出，便于用 curl 调试
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if r.URL.Query().Get("pretty") == "true" {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(v)
+}
+
+// decodeJSONBody 解析 JSON 请求体，失败时返回包含出错位置的可读错误
+func decodeJSONBody(r *http.Request, v interface{}) error {
+	dec := json.NewDecoder(r.Body)
+	err := dec.Decode(v)
+	if err == nil && dec.More() {
+		return fmt.Errorf("unexpected data after JSON value at offset %d", dec.InputOffset())
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, io.EOF):
+		return errors.New("request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return errors.New("request body is truncated JSON")
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("malformed JSON at offset %d: %v", syntaxErr.Offset, syntaxErr)
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("field %q must be %s (got %s at offset %d)", typeErr.Field, typeErr.Type, typeErr.Value, typeErr.Offset)
+	default:
+		return err
+	}
+}
+
+// recoverPanic 捕获处理器中的 panic（如异常的元数据），记录带请求 ID 的堆栈并返回 500，
+// 避免连接被直接断开；http.ErrAbortHandler 是主动中止请求的约定，原样抛出
+func recoverPanic(w http.ResponseWriter, r *http.Request) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	if v == http.ErrAbortHandler {
+		panic(v)
+	}
+	logErrorf("panic serving %s %s (%s): %v\n%s", r.Method, r.URL.Path, requestID(r), v, debug.Stack())
+	writeError(w, r, http.StatusInternalServerError, codeInternal, "Internal server error")
+}
@@ -0,0 +1,289 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- API 密钥与配额 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	apiKeysFile      = new(string) // 非空时公开的数据接口要求携带该文件中列出的 API 密钥
+	apiKeyUsageFile  = new(string) // 非空时各密钥的用量定期保存到该文件，重启后恢复，配额跨重启生效
+	apiKeys          []*apiKey     // 启动时从 -api-keys 读取，之后只读
+	apiKeyUsageMu    sync.Mutex
+	apiKeyUsageState = &apiKeyUsageCounters{Since: time.Now(), Keys: make(map[string]*apiKeyUsage)}
+)
+
+// apiKey 为 -api-keys 中的一行：密钥、名称与可选的每日、每月请求配额（0 表示不限）
+type apiKey struct {
+	Key     string
+	Name    string
+	Daily   int
+	Monthly int
+}
+
+// apiKeyUsage 为单个密钥的用量，日、月按 UTC 计算，跨日或跨月后对应计数从零开始
+type apiKeyUsage struct {
+	Total      int       `json:"total"`
+	Day        string    `json:"day"` // 2006-01-02
+	DayCount   int       `json:"dayCount"`
+	Month      string    `json:"month"` // 2006-01
+	MonthCount int       `json:"monthCount"`
+	Rejected   int       `json:"rejected"` // 因超出配额被拒绝的请求数
+	LastUsed   time.Time `json:"lastUsed,omitzero"`
+}
+
+// apiKeyUsageCounters 为全部密钥的用量（键为密钥名称），也是 -api-key-usage-file 的文件格式
+type apiKeyUsageCounters struct {
+	Since time.Time               `json:"since"`
+	Keys  map[string]*apiKeyUsage `json:"keys"`
+
+	dirty bool
+}
+
+// loadAPIKeys 读取 -api-keys，每行为 "密钥 名称 [daily=N] [monthly=N]"，# 开头的行为注释
+func loadAPIKeys() error {
+	if *apiKeysFile == "" {
+		return nil
+	}
+	lines, err := readRuleLines(*apiKeysFile)
+	if err != nil {
+		return err
+	}
+	names := make(map[string]bool)
+	var keys []*apiKey
+	for _, line := range lines {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return fmt.Errorf("invalid API key line %q, expected \"key name [daily=N] [monthly=N]\"", line)
+		}
+		k := &apiKey{Key: fields[0], Name: fields[1]}
+		if names[k.Name] {
+			return fmt.Errorf("duplicate API key name %q", k.Name)
+		}
+		names[k.Name] = true
+		for _, opt := range fields[2:] {
+			name, value, _ := strings.Cut(opt, "=")
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return fmt.Errorf("invalid quota %q for API key %s, expected a non-negative integer", opt, k.Name)
+			}
+			switch name {
+			case "daily":
+				k.Daily = n
+			case "monthly":
+				k.Monthly = n
+			default:
+				return fmt.Errorf("unknown option %q for API key %s, expected daily=N or monthly=N", opt, k.Name)
+			}
+		}
+		keys = append(keys, k)
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("no API keys found in %s", *apiKeysFile)
+	}
+	apiKeys = keys
+	return nil
+}
+
+// findAPIKey 按常量时间比较查找密钥
+func findAPIKey(key string) *apiKey {
+	var found *apiKey
+	for _, k := range apiKeys {
+		if subtle.ConstantTimeCompare([]byte(key), []byte(k.Key)) == 1 {
+			found = k
+		}
+	}
+	return found
+}
+
+// consumeQuota 在配额允许时记录一次请求；超出配额时返回距离配额重置的时间
+func consumeQuota(k *apiKey, now time.Time) (time.Duration, bool) {
+	now = now.UTC()
+	day, month := now.Format(time.DateOnly), now.Format("2006-01")
+
+	apiKeyUsageMu.Lock()
+	defer apiKeyUsageMu.Unlock()
+	u, ok := apiKeyUsageState.Keys[k.Name]
+	if !ok {
+		u = &apiKeyUsage{}
+		apiKeyUsageState.Keys[k.Name] = u
+	}
+	if u.Day != day {
+		u.Day, u.DayCount = day, 0
+	}
+	if u.Month != month {
+		u.Month, u.MonthCount = month, 0
+	}
+	apiKeyUsageState.dirty = true
+	switch {
+	case k.Monthly > 0 && u.MonthCount >= k.Monthly:
+		u.Rejected++
+		return time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC).Sub(now), false
+	case k.Daily > 0 && u.DayCount >= k.Daily:
+		u.Rejected++
+		return time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC).Sub(now), false
+	}
+	u.Total++
+	u.DayCount++
+	u.MonthCount++
+	u.LastUsed = now
+	return 0, true
+}
+
+var (
+	errAPIKeyInvalid = errors.New("Missing or invalid API key")
+	errQuotaExceeded = errors.New("API key quota exceeded")
+)
+
+// checkAPIKey 校验密钥并计入配额，HTTP 与 gRPC 共用；超出配额时同时返回距离配额重置的时间
+func checkAPIKey(key string) (time.Duration, error) {
+	k := findAPIKey(key)
+	if k == nil {
+		return 0, errAPIKeyInvalid
+	}
+	if wait, ok := consumeQuota(k, time.Now()); !ok {
+		return wait, errQuotaExceeded
+	}
+	return 0, nil
+}
+
+// requireAPIKey 在配置了 -api-keys 时要求请求通过 X-API-Key 头或 apiKey 参数携带有效密钥并计入配额；
+// 携带有效管理令牌或经 -client-ca 校验的客户端证书的请求不受限制
+func requireAPIKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(apiKeys) == 0 || verifiedClientCert(r) {
+			next(w, r)
+			return
+		}
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && validAdminToken(token) {
+			next(w, r)
+			return
+		}
+		key := r.Header.Get("X-API-Key")
+		if key == "" {
+			key = r.URL.Query().Get("apiKey")
+		}
+		switch wait, err := checkAPIKey(key); err {
+		case errAPIKeyInvalid:
+			writeError(w, r, http.StatusUnauthorized, codeUnauthorized, err.Error())
+		case errQuotaExceeded:
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfterSeconds(wait)))
+			writeError(w, r, http.StatusTooManyRequests, codeQuotaExceeded, err.Error())
+		default:
+			next(w, r)
+		}
+	}
+}
+
+// loadAPIKeyUsage 从 -api-key-usage-file 恢复用量，文件不存在时从零开始
+func loadAPIKeyUsage() {
+	data, err := os.ReadFile(*apiKeyUsageFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logWarnf("Warning: failed to read API key usage from %s: %v", *apiKeyUsageFile, err)
+		}
+		return
+	}
+	loaded := &apiKeyUsageCounters{}
+	if err := json.Unmarshal(data, loaded); err != nil {
+		logWarnf("Warning: failed to parse API key usage file %s: %v", *apiKeyUsageFile, err)
+		return
+	}
+	if loaded.Keys == nil {
+		loaded.Keys = make(map[string]*apiKeyUsage)
+	}
+	apiKeyUsageMu.Lock()
+	apiKeyUsageState = loaded
+	apiKeyUsageMu.Unlock()
+}
+
+// saveAPIKeyUsage 在用量有变化时写入 -api-key-usage-file（先写临时文件再重命名）
+func saveAPIKeyUsage() {
+	apiKeyUsageMu.Lock()
+	if !apiKeyUsageState.dirty {
+		apiKeyUsageMu.Unlock()
+		return
+	}
+	data, err := json.Marshal(apiKeyUsageState)
+	apiKeyUsageState.dirty = false
+	apiKeyUsageMu.Unlock()
+	if err == nil {
+		tmp := *apiKeyUsageFile + ".tmp"
+		if err = os.WriteFile(tmp, data, 0o600); err == nil {
+			err = os.Rename(tmp, *apiKeyUsageFile)
+		}
+	}
+	if err != nil {
+		logWarnf("Warning: failed to save API key usage to %s: %v", *apiKeyUsageFile, err)
+	}
+}
+
+// startAPIKeyUsage 恢复已保存的用量，并与查询统计相同的间隔定期保存直至 ctx 取消
+func startAPIKeyUsage(ctx context.Context) {
+	if *apiKeyUsageFile == "" || len(apiKeys) == 0 {
+		return
+	}
+	loadAPIKeyUsage()
+	saveOnInterval(ctx, saveAPIKeyUsage)
+}
+
+// apiKeyView 为 /api/keys 响应中的单个密钥，不包含密钥本身
+type apiKeyView struct {
+	Name    string `json:"name"`
+	Daily   int    `json:"dailyQuota"`
+	Monthly int    `json:"monthlyQuota"`
+	apiKeyUsage
+}
+
+// apiKeysHandler 返回各 API 密钥的配额与用量（需要管理令牌），可用 name 只查看一个密钥；
+// 当前日、月之前的计数显示为 0
+func apiKeysHandler(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	now := time.Now().UTC()
+	day, month := now.Format(time.DateOnly), now.Format("2006-01")
+
+	apiKeyUsageMu.Lock()
+	since := apiKeyUsageState.Since
+	var views []apiKeyView
+	for _, k := range apiKeys {
+		if name != "" && k.Name != name {
+			continue
+		}
+		v := apiKeyView{Name: k.Name, Daily: k.Daily, Monthly: k.Monthly}
+		if u, ok := apiKeyUsageState.Keys[k.Name]; ok {
+			v.apiKeyUsage = *u
+		}
+		if v.Day != day {
+			v.Day, v.DayCount = day, 0
+		}
+		if v.Month != month {
+			v.Month, v.MonthCount = month, 0
+		}
+		views = append(views, v)
+	}
+	apiKeyUsageMu.Unlock()
+
+	if name != "" && len(views) == 0 {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "Unknown API key name")
+		return
+	}
+	sort.Slice(views, func(i, j int) bool { return views[i].Name < views[j].Name })
+	writeJSON(w, r, map[string]interface{}{
+		"status": "success",
+		"since":  since,
+		"keys":   views,
+	})
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// --- 孤立与悬空文件报告 ---
+
+type danglingEntry struct {
+	Platform     string `json:"platform"`
+	ID           string `json:"id"`
+	RawLyricFile string `json:"rawLyricFile"`
+}
+
+type orphanFile struct {
+	Platform string `json:"platform"`
+	File     string `json:"file"`
+}
+
+type auditCounts struct {
+	Dangling int `json:"dangling"`
+	Orphans  int `json:"orphans"`
+}
+
+// auditReport 交叉比对索引与歌词目录的结果
+type auditReport struct {
+	Root      string                  `json:"root"`
+	Platforms map[string]*auditCounts `json:"platforms"`
+	Dangling  []danglingEntry         `json:"dangling"`
+	Orphans   []orphanFile            `json:"orphans"`
+}
+
+// auditFiles 基于已加载的索引，找出 rawLyricFile 不存在的条目（悬空）以及未被任何条目引用的歌词文件（孤立）
+func auditFiles() *auditReport {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	report := &auditReport{
+		Root:      actualDataDir,
+		Platforms: make(map[string]*auditCounts),
+		Dangling:  []danglingEntry{},
+		Orphans:   []orphanFile{},
+	}
+	counts := func(platform string) *auditCounts {
+		c, ok := report.Platforms[platform]
+		if !ok {
+			c = &auditCounts{}
+			report.Platforms[platform] = c
+		}
+		return c
+	}
+
+	rawDir := filepath.Join(actualDataDir, "raw-lyrics")
+	referencedRaw := make(map[string]bool)
+
+	names := make([]string, 0, len(dataStore))
+	for name := range dataStore {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, platform := range names {
+		c := counts(platform)
+		ids := make(map[string]bool, len(dataStore[platform]))
+		for _, entry := range dataStore[platform] {
+			ids[entry.ID] = true
+			if entry.RawLyricFile == "" {
+				continue
+			}
+			referencedRaw[entry.RawLyricFile] = true
+			if _, err := os.Stat(filepath.Join(rawDir, entry.RawLyricFile)); err != nil {
+				c.Dangling++
+				report.Dangling = append(report.Dangling, danglingEntry{Platform: platform, ID: entry.ID, RawLyricFile: entry.RawLyricFile})
+			}
+		}
+
+		// 平台目录中以 <id>.<格式> 命名的歌词文件应当能对应到某个条目（raw 平台的文件位于 raw-lyrics）
+		dir, ok := platformPaths[platform]
+		if !ok || platform == "raw" {
+			continue
+		}
+		files, _ := os.ReadDir(dir)
+		for _, f := range files {
+			if f.IsDir() {
+				continue
+			}
+			ext := strings.TrimPrefix(filepath.Ext(f.Name()), ".")
+			if !isLyricFormat(ext) {
+				continue
+			}
+			if !ids[strings.TrimSuffix(f.Name(), "."+ext)] {
+				c.Orphans++
+				report.Orphans = append(report.Orphans, orphanFile{Platform: platform, File: f.Name()})
+			}
+		}
+	}
+
+	// raw-lyrics 中未被任何平台引用的文件
+	files, _ := os.ReadDir(rawDir)
+	for _, f := range files {
+		if !f.IsDir() && !referencedRaw[f.Name()] {
+			counts("raw").Orphans++
+			report.Orphans = append(report.Orphans, orphanFile{Platform: "raw", File: f.Name()})
+		}
+	}
+	return report
+}
+
+func isLyricFormat(ext string) bool {
+	for _, f := range lyricFormats {
+		if f == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func auditHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, auditFiles())
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// --- 下载重定向到 CDN ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	downloadMode = new(string) // serve：由本服务返回文件内容；redirect：302 重定向到 CDN
+	cdnProvider  = new(string) // github 或 jsdelivr
+
+	// dataCommit 为加载索引时数据目录检出的版本，由 mu 保护；CDN 地址固定到该 commit，避免与索引不一致
+	dataCommit commitInfo
+)
+
+var downloadModes = []string{"serve", "redirect"}
+
+// githubRepoPath 从 repoURL 中取出 GitHub 上的 owner/repo，不是 GitHub 仓库时返回空
+func githubRepoPath() string {
+	repo := strings.TrimSuffix(repoURL, ".git")
+	if !strings.HasPrefix(repo, "https://github.com/") {
+		return ""
+	}
+	return strings.TrimPrefix(repo, "https://github.com/")
+}
+
+// cdnURL 返回歌词文件对应的 CDN 地址；文件不在数据仓库的检出目录中（如附加数据源）
+// 或仓库不在 GitHub 上时返回 false，此时仍由本服务返回文件内容
+func cdnURL(filePath string) (string, bool) {
+	repo := githubRepoPath()
+	mu.RLock()
+	root, commit := actualDataDir, dataCommit.Hash
+	mu.RUnlock()
+	if repo == "" || root == "" || isS3URL(root) {
+		return "", false
+	}
+	rel, err := filepath.Rel(root, filePath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return "", false
+	}
+	rel = filepath.ToSlash(rel)
+
+	ref := commit
+	if ref == "" {
+		ref = "main"
+	}
+	switch *cdnProvider {
+	case "github":
+		return fmt.Sprintf("https://raw.githubusercontent.com/%s/%s/%s", repo, ref, rel), true
+	case "jsdelivr":
+		return fmt.Sprintf("https://cdn.jsdelivr.net/gh/%s@%s/%s", repo, ref, rel), true
+	}
+	return "", false
+}
@@ -0,0 +1,458 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// --- 子命令 ---
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string)
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"serve", "Start the HTTP API server (default)", runServe},
+		{"sync", "Clone or pull the lyric data repository once", runSync},
+		{"index", "Load the local index and print a per-platform summary", runIndex},
+		{"search", "Search the local index without starting the server", runSearchCommand},
+		{"convert", "Convert lyric files between formats", runConvertCommand},
+		{"validate", "Validate index files and referenced lyric files", runValidateCommand},
+		{"export", "Export the merged index as CSV, JSON or SQLite", runExportCommand},
+		{"stats", "Print coverage statistics for the lyric database", runStatsCommand},
+		{"audit", "Report dangling index entries and orphaned lyric files", runAuditCommand},
+		{"help", "Show this help", func([]string) { printUsage() }},
+	}
+}
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for command flags.\n", os.Args[0])
+}
+
+// runCommand 分发子命令；未指定子命令（或以参数开头）时按 serve 处理，以兼容旧的启动方式
+func runCommand(args []string) {
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	for _, c := range commands {
+		if c.name == name {
+			c.run(args)
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", name)
+	printUsage()
+	os.Exit(2)
+}
+
+// stringList 是可重复指定、也可用逗号分隔的字符串参数
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			*s = append(*s, item)
+		}
+	}
+	return nil
+}
+
+// parseInterspersed 允许位置参数与选项混排（如 search "晴天" --platform ncm），返回全部位置参数
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// runSync 执行一次 Git 同步后退出
+func runSync(args []string) {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	registerDataFlags(fs)
+	dryRun := fs.Bool("dry-run", false, "Report what a pull would change without modifying the working tree")
+	fs.Parse(args)
+
+	if *dryRun {
+		preview, err := previewSync()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Dry run failed: %v\n", err)
+			os.Exit(1)
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		enc.Encode(preview)
+		return
+	}
+
+	if syncRepo() {
+		fmt.Println("Repository updated")
+	} else {
+		fmt.Println("Already up to date")
+	}
+}
+
+// runIndex 加载本地索引并输出各平台条目数
+func runIndex(args []string) {
+	fs := flag.NewFlagSet("index", flag.ExitOnError)
+	registerDataFlags(fs)
+	fs.Parse(args)
+
+	loadMetadata()
+	if actualDataDir == "" {
+		fmt.Fprintln(os.Stderr, "No valid data directory found")
+		os.Exit(1)
+	}
+
+	counts := platformCounts()
+	names := make([]string, 0, len(counts))
+	total := 0
+	for name, n := range counts {
+		names = append(names, name)
+		total += n
+	}
+	sort.Strings(names)
+
+	fmt.Printf("Data root: %s\n", actualDataDir)
+	for _, name := range names {
+		fmt.Printf("  %-10s %d\n", name, counts[name])
+	}
+	fmt.Printf("Total entries: %d\n", total)
+}
+
+// runSearchCommand 加载本地索引并直接输出搜索结果，不启动 HTTP 服务
+func runSearchCommand(args []string) {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	registerDataFlags(fs)
+	registerSearchFlags(fs)
+	var targetPlatforms stringList
+	fs.Var(&targetPlatforms, "platform", "Restrict to a platform (repeatable or comma-separated)")
+	limit := fs.Int("limit", 0, "Maximum number of results (0 = unlimited)")
+	minScore := fs.Float64("min-score", 0, "Only show results scoring at least this value (0-1)")
+	lang := fs.String("lang", "", "Only show lyrics in this language (zh, ja, en, ko)")
+	asJSON := fs.Bool("json", false, "Print results as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s search <query> [flags]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	query := strings.ToLower(strings.TrimSpace(strings.Join(parseInterspersed(fs, args), " ")))
+	if query == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	if *searchTimeout <= 0 {
+		fmt.Fprintf(os.Stderr, "invalid -search-timeout %v, expected a positive duration\n", *searchTimeout)
+		os.Exit(2)
+	}
+
+	loadMetadata()
+	if actualDataDir == "" {
+		fmt.Fprintln(os.Stderr, "No valid data directory found")
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *searchTimeout)
+	defer cancel()
+	outcome, err := runSearch(ctx, query, searchOptions{Platforms: targetPlatforms, Limit: *limit, MinScore: *minScore, Lang: *lang})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Search failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		enc.Encode(map[string]interface{}{
+			"count":     len(outcome.Results),
+			"results":   withParsedFields(outcome.Results),
+			"truncated": outcome.Truncated,
+		})
+		return
+	}
+
+	for _, r := range outcome.Results {
+		fmt.Printf("%-12s %-20s %.2f  %s - %s\n", r.ID, strings.Join(r.Platforms, ","), r.Score,
+			metadataValue(r.Metadata, "musicName"), metadataValue(r.Metadata, "artists"))
+	}
+	fmt.Printf("%d result(s)\n", len(outcome.Results))
+}
+
+// metadataValue 取出原始元数据中某个键的全部值，以 " / " 连接
+func metadataValue(raw [][]interface{}, key string) string {
+	md, _ := parseMetadata(raw)
+	return strings.Join(md.Values(key), " / ")
+}
+
+// runConvertCommand 转换单个歌词文件，或在输入为目录时批量转换其中所有匹配的文件
+func runConvertCommand(args []string) {
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	to := fs.String("to", "", "Output format ("+strings.Join(convertOutputFormats, ", ")+")")
+	from := fs.String("from", "", "Input format, or auto to detect it from the content (defaults to the file extension)")
+	out := fs.String("out", "", "Output file, or output directory in batch mode (default: stdout / input directory)")
+	var opts convertOptions
+	fs.StringVar(&opts.Layer, "layer", "main", "Lyric layer to export ("+strings.Join(convertLayers, ", ")+")")
+	translation := fs.String("translation", "", "Translation lyric file (usually LRC) to merge line by line by timestamp")
+	fs.Int64Var(&opts.Offset, "offset", 0, "Shift all times by this many milliseconds (positive delays the lyric)")
+	fs.Float64Var(&opts.Scale, "scale", 1, "Multiply all times by this factor (e.g. 0.8 for a 1.25x sped-up version)")
+	fs.BoolVar(&opts.Clamp, "clamp", false, "Clamp times that become negative to 0")
+	fs.Int64Var(&opts.MinGap, "min-gap", 0, "Minimum gap in milliseconds between consecutive lines")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s convert <file|directory> --to <format> [flags]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	inputs := parseInterspersed(fs, args)
+	if len(inputs) != 1 || *to == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	input := inputs[0]
+	if err := opts.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid options: %v\n", err)
+		os.Exit(2)
+	}
+	if *translation != "" {
+		data, err := os.ReadFile(*translation)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Cannot read translation: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Translation = string(data)
+	}
+
+	info, err := os.Stat(input)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot read input: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !info.IsDir() {
+		format := *from
+		if format == "" {
+			format = strings.TrimPrefix(filepath.Ext(input), ".")
+		}
+		data, err := convertFile(input, format, *to, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", input, err)
+			os.Exit(1)
+		}
+		if *out == "" {
+			os.Stdout.Write(data)
+			return
+		}
+		if err := os.WriteFile(*out, data, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Write failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// 批量模式：按 --from（默认 ttml）筛选目录中的文件
+	format := *from
+	if format == "" {
+		format = "ttml"
+	}
+	outDir := *out
+	if outDir == "" {
+		outDir = input
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		fmt.Fprintf(os.Stderr, "Cannot create output directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	var files []string
+	if format == "auto" {
+		// 按内容识别时转换目录中所有常见歌词扩展名的文件
+		for _, ext := range lyricFileFormats {
+			matches, _ := filepath.Glob(filepath.Join(input, "*."+ext))
+			files = append(files, matches...)
+		}
+	} else {
+		files, _ = filepath.Glob(filepath.Join(input, "*."+format))
+	}
+	failed := 0
+	for _, file := range files {
+		data, err := convertFile(file, format, *to, opts)
+		if err == nil {
+			ext := *to
+			if ext == "words" {
+				ext = "words.json"
+			}
+			name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file)) + "." + ext
+			err = os.WriteFile(filepath.Join(outDir, name), data, 0o644)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			failed++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "Converted %d/%d file(s)\n", len(files)-failed, len(files))
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func convertFile(path, from, to string, opts convertOptions) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return convertLyric(data, from, to, opts)
+}
+
+// runValidateCommand 校验本地索引并输出 JSON 报告，发现问题时以非零状态退出
+func runValidateCommand(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	registerDataFlags(fs)
+	fs.Parse(args)
+
+	root := findValidDataDir()
+	if root == "" {
+		fmt.Fprintln(os.Stderr, "No valid data directory found")
+		os.Exit(1)
+	}
+
+	report := validateIndex(root)
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(report)
+	if !report.OK {
+		os.Exit(1)
+	}
+}
+
+// runExportCommand 导出合并去重后的索引
+func runExportCommand(args []string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	registerDataFlags(fs)
+	format := fs.String("format", "csv", "Export format ("+strings.Join(exportFormats, ", ")+")")
+	out := fs.String("out", "", "Output file (default: stdout; required for sqlite)")
+	fs.Parse(args)
+
+	loadMetadata()
+	if actualDataDir == "" {
+		fmt.Fprintln(os.Stderr, "No valid data directory found")
+		os.Exit(1)
+	}
+
+	n, err := exportIndex(*format, *out)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Export failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d song(s)\n", n)
+}
+
+// runStatsCommand 输出数据库统计信息
+func runStatsCommand(args []string) {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	registerDataFlags(fs)
+	top := fs.Int("top", 10, "Number of top artists to list")
+	since := fs.String("since", "", "Report entry growth since this git commit or ref")
+	asJSON := fs.Bool("json", false, "Print statistics as JSON")
+	fs.Parse(args)
+
+	loadMetadata()
+	if actualDataDir == "" {
+		fmt.Fprintln(os.Stderr, "No valid data directory found")
+		os.Exit(1)
+	}
+
+	st, err := collectStats(*top, *since)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Stats failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		enc.Encode(st)
+		return
+	}
+
+	names := make([]string, 0, len(st.Entries))
+	for name := range st.Entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Printf("Data root: %s\n", st.Root)
+	if st.Commit != "" {
+		fmt.Printf("Commit:    %s\n", st.Commit)
+	}
+	fmt.Println("\nEntries per platform:")
+	for _, name := range names {
+		line := fmt.Sprintf("  %-10s %8d", name, st.Entries[name])
+		if st.Growth != nil {
+			line += fmt.Sprintf("  (%+d since %s)", st.Growth.Delta[name], st.Growth.Since)
+		}
+		fmt.Println(line)
+	}
+	fmt.Printf("\nUnique songs: %d (%d on more than one platform)\n", st.UniqueSongs, st.CrossPlatform)
+
+	fmt.Println("\nFormat coverage:")
+	for _, name := range names {
+		coverage, ok := st.FormatCoverage[name]
+		if !ok {
+			continue
+		}
+		parts := make([]string, 0, len(lyricFormats))
+		for _, f := range lyricFormats {
+			parts = append(parts, fmt.Sprintf("%s=%d", f, coverage[f]))
+		}
+		fmt.Printf("  %-10s %s  missing ttml=%d (other formats only=%d)\n", name, strings.Join(parts, " "), st.MissingTTML[name], st.OnlyOtherFormats[name])
+	}
+
+	fmt.Println("\nTop artists:")
+	for i, a := range st.TopArtists {
+		fmt.Printf("  %2d. %s (%d)\n", i+1, a.Artist, a.Count)
+	}
+}
+
+// runAuditCommand 输出悬空条目与孤立文件报告
+func runAuditCommand(args []string) {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	registerDataFlags(fs)
+	fs.Parse(args)
+
+	loadMetadata()
+	if actualDataDir == "" {
+		fmt.Fprintln(os.Stderr, "No valid data directory found")
+		os.Exit(1)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	enc.Encode(auditFiles())
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// --- 并发请求上限 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	maxInFlightSearch   = new(int) // 同时处理的搜索请求上限，0 表示不限
+	maxInFlightDownload = new(int) // 同时处理的下载请求上限，0 表示不限
+
+	searchInFlight   = &inFlightLimiter{limit: maxInFlightSearch}
+	downloadInFlight = &inFlightLimiter{limit: maxInFlightDownload}
+)
+
+// busyRetryAfter 为超出并发上限时 Retry-After 建议的等待秒数；请求通常在毫秒级完成，短暂退避即可
+const busyRetryAfter = "1"
+
+// inFlightLimiter 统计一类请求的并发数，超过上限的请求立即拒绝而不是排队，
+// 使抓取高峰时内存与 CPU 保持有界，而不是让所有请求一起变慢直至超时
+type inFlightLimiter struct {
+	limit    *int
+	current  atomic.Int64
+	rejected atomic.Int64
+}
+
+// acquire 占用一个名额，超过上限时返回 false；成功时须调用 release 归还
+func (l *inFlightLimiter) acquire() bool {
+	n := l.current.Add(1)
+	if *l.limit > 0 && n > int64(*l.limit) {
+		l.current.Add(-1)
+		l.rejected.Add(1)
+		return false
+	}
+	return true
+}
+
+func (l *inFlightLimiter) release() { l.current.Add(-1) }
+
+// limitInFlight 在并发数超过上限时以 429 SERVER_BUSY 拒绝请求
+func limitInFlight(l *inFlightLimiter, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !l.acquire() {
+			w.Header().Set("Retry-After", busyRetryAfter)
+			writeError(w, r, http.StatusTooManyRequests, codeServerBusy, "Too many concurrent requests, please retry shortly")
+			return
+		}
+		defer l.release()
+		next(w, r)
+	}
+}
+
+// inFlightStatus 为 /api/status 中的并发统计
+func inFlightStatus() map[string]interface{} {
+	view := func(l *inFlightLimiter) map[string]int64 {
+		return map[string]int64{"current": l.current.Load(), "limit": int64(*l.limit), "rejected": l.rejected.Load()}
+	}
+	return map[string]interface{}{
+		"search":   view(searchInFlight),
+		"download": view(downloadInFlight),
+	}
+}
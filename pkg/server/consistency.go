@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// --- 索引与磁盘的一致性自检 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	consistencyInterval = new(time.Duration) // 为 0 时不检查
+	consistencySample   = new(int)           // 每次抽查的条目数
+
+	consistencyMu   sync.Mutex
+	lastConsistency consistencyReport
+)
+
+// consistencyExamples 为报告中保留的问题示例数
+const consistencyExamples = 5
+
+// consistencyReport 为最近一次自检的结果，见 /api/status
+type consistencyReport struct {
+	CheckedAt    time.Time `json:"checked_at,omitzero"`
+	Runs         int64     `json:"runs"`
+	Checked      int       `json:"checked"`       // 本次抽查的条目数
+	Missing      int       `json:"missing"`       // 歌词文件已不存在
+	SizeMismatch int       `json:"size_mismatch"` // 文件大小与加载时不同
+	Examples     []string  `json:"examples,omitempty"`
+}
+
+// consistencyTarget 为一个待检查的条目：歌词文件路径及加载时记录的大小
+type consistencyTarget struct {
+	label string
+	path  string
+	size  int64
+}
+
+// sampleConsistencyTargets 从加载时读取过本地歌词文件的条目中随机抽取至多 n 个（蓄水池抽样）
+func sampleConsistencyTargets(n int) []consistencyTarget {
+	mu.RLock()
+	defer mu.RUnlock()
+	var picked []consistencyTarget
+	seen := 0
+	for platform, entries := range dataStore {
+		for i := range entries {
+			e := &entries[i]
+			if e.FileSize == nil {
+				continue
+			}
+			seen++
+			j := len(picked)
+			if j >= n {
+				if j = rand.IntN(seen); j >= n {
+					continue
+				}
+			}
+			root := actualDataDir
+			if repo, ok := findRepo(e.Source); ok {
+				root = repo.Dir()
+			}
+			t := consistencyTarget{
+				label: platform + "/" + e.ID,
+				path:  filepath.Join(root, "raw-lyrics", e.RawLyricFile),
+				size:  *e.FileSize,
+			}
+			if j == len(picked) {
+				picked = append(picked, t)
+			} else {
+				picked[j] = t
+			}
+		}
+	}
+	return picked
+}
+
+// runConsistencyCheck 抽查条目引用的歌词文件是否仍然存在且大小与加载时一致，
+// 用于发现在 Git 之外被修改的工作目录
+func runConsistencyCheck() consistencyReport {
+	targets := sampleConsistencyTargets(*consistencySample)
+	report := consistencyReport{CheckedAt: time.Now().UTC(), Checked: len(targets)}
+	for _, t := range targets {
+		var problem string
+		st, err := os.Stat(t.path)
+		switch {
+		case err != nil:
+			report.Missing++
+			problem = "missing"
+		case st.Size() != t.size:
+			report.SizeMismatch++
+			problem = fmt.Sprintf("size %d -> %d", t.size, st.Size())
+		default:
+			continue
+		}
+		if len(report.Examples) < consistencyExamples {
+			report.Examples = append(report.Examples, fmt.Sprintf("%s %s: %s", t.label, filepath.Base(t.path), problem))
+		}
+	}
+	if report.Missing+report.SizeMismatch > 0 {
+		logWarnf("Warning: consistency check found %d missing and %d resized lyric file(s) among %d sampled entries; the data directory may have been modified outside git",
+			report.Missing, report.SizeMismatch, report.Checked)
+	}
+
+	consistencyMu.Lock()
+	report.Runs = lastConsistency.Runs + 1
+	lastConsistency = report
+	consistencyMu.Unlock()
+	return report
+}
+
+// consistencyStatus 返回最近一次自检的结果
+func consistencyStatus() consistencyReport {
+	consistencyMu.Lock()
+	defer consistencyMu.Unlock()
+	return lastConsistency
+}
+
+// watchConsistency 按 -consistency-interval 定期自检；对象存储与副本模式下没有本地歌词文件，不检查
+func watchConsistency(ctx context.Context) {
+	if *consistencyInterval <= 0 || *consistencySample <= 0 || isS3URL(*inputDataDir) || replicaMode() {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(*consistencyInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				runConsistencyCheck()
+			}
+		}
+	}()
+}
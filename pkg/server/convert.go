@@ -0,0 +1,204 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// --- 格式转换 ---
+
+// 可作为转换输入与输出的格式
+var (
+	convertInputFormats  = []string{"ttml", "lrc", "yrc", "qrc"}
+	convertOutputFormats = []string{"ttml", "lrc", "json", "yrc", "qrc", "words"}
+)
+
+// convertLayers 为可导出的歌词层：main 为原文，translation 与 roman 只保留翻译或音译，
+// 供双语播放器作为副歌词层叠加显示；reading 为以注音替换汉字后的读法
+var convertLayers = []string{"main", "translation", "roman", "reading"}
+
+// convertOptions 为转换时的附加处理
+type convertOptions struct {
+	timingTransform
+	Layer       string `json:"layer,omitempty"`       // 导出的歌词层，空值同 main
+	Translation string `json:"translation,omitempty"` // 按时间逐行并入的外部翻译歌词（通常为 LRC）
+}
+
+// isZero 报告是否没有任何附加处理
+func (o convertOptions) isZero() bool {
+	return o.timingTransform.isZero() && (o.Layer == "" || o.Layer == "main") && o.Translation == ""
+}
+
+// validate 检查附加处理的参数
+func (o convertOptions) validate() error {
+	if o.Layer != "" && !slices.Contains(convertLayers, o.Layer) {
+		return fmt.Errorf("layer must be one of %s", strings.Join(convertLayers, ", "))
+	}
+	return o.timingTransform.validate()
+}
+
+// parseLyric 按格式名解析歌词内容，format 为 auto 时先根据内容识别格式
+func parseLyric(data []byte, format string) (*Lyric, error) {
+	switch format {
+	case "auto":
+		detected := detectLyricFormat(data)
+		if detected == "" {
+			return nil, errUnknownLyricFormat
+		}
+		return parseLyric(data, detected)
+	case "ttml":
+		return parseTTML(data)
+	case "lrc":
+		return parseLRC(data)
+	case "yrc":
+		return parseYRC(data)
+	case "qrc":
+		return parseQRC(data)
+	}
+	return nil, fmt.Errorf("unsupported input format %q", format)
+}
+
+// renderLyric 将歌词模型输出为指定格式
+func renderLyric(lyric *Lyric, format string) ([]byte, error) {
+	switch format {
+	case "ttml":
+		return []byte(renderTTML(lyric)), nil
+	case "lrc":
+		return []byte(renderLRC(lyric)), nil
+	case "json":
+		return json.Marshal(lyric)
+	case "yrc":
+		return []byte(renderYRC(lyric)), nil
+	case "qrc":
+		return []byte(renderQRC(lyric)), nil
+	case "words":
+		return json.Marshal(karaokeWords(lyric))
+	}
+	return nil, fmt.Errorf("unsupported output format %q", format)
+}
+
+// karaokeWord 是扁平化的逐字事件，供自行排版、只需要时间信息的渲染器使用
+type karaokeWord struct {
+	Start        int64  `json:"start"`
+	End          int64  `json:"end"`
+	Text         string `json:"text"`
+	LineIndex    int    `json:"lineIndex"`
+	IsBackground bool   `json:"isBackground"`
+}
+
+// karaokeWords 按行顺序展开所有词，没有逐字信息的行作为一个整词输出
+func karaokeWords(lyric *Lyric) []karaokeWord {
+	words := make([]karaokeWord, 0, len(lyric.Lines))
+	for i, line := range lyric.Lines {
+		for _, w := range lineWords(line) {
+			words = append(words, karaokeWord{Start: w.Start, End: w.End, Text: w.Text, LineIndex: i, IsBackground: line.IsBackground})
+		}
+	}
+	return words
+}
+
+// convertLyric 将 from 格式的歌词内容转换为 to 格式，输出前按 opts 依次并入外部翻译、选取歌词层并调整时间轴
+func convertLyric(data []byte, from, to string, opts convertOptions) ([]byte, error) {
+	lyric, err := parseLyric(data, from)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Translation != "" {
+		if _, err := mergeTranslation(lyric, []byte(opts.Translation)); err != nil {
+			return nil, err
+		}
+	}
+	if err := selectLayer(lyric, opts.Layer); err != nil {
+		return nil, err
+	}
+	opts.apply(lyric)
+	return renderLyric(lyric, to)
+}
+
+// selectLayer 将翻译或音译提升为行文本：逐字信息随之丢弃（翻译与音译只有逐行时间），
+// 没有对应内容的行被去掉，全部没有时返回错误
+func selectLayer(lyric *Lyric, layer string) error {
+	switch layer {
+	case "", "main":
+		return nil
+	case "reading":
+		return selectReading(lyric)
+	}
+	kept := lyric.Lines[:0]
+	for _, l := range lyric.Lines {
+		text := l.Translation
+		if layer == "roman" {
+			text = l.Roman
+		}
+		if text == "" {
+			continue
+		}
+		l.Text, l.Words, l.Translation, l.Roman, l.Reading = text, nil, "", "", ""
+		kept = append(kept, l)
+	}
+	if len(kept) == 0 {
+		return fmt.Errorf("lyric has no %s lines", layer)
+	}
+	lyric.Lines = kept
+	return nil
+}
+
+// formatLRCTime 将毫秒格式化为 LRC 时间标签 mm:ss.xx
+func formatLRCTime(ms int64) string {
+	if ms < 0 {
+		ms = 0
+	}
+	return fmt.Sprintf("%02d:%02d.%02d", ms/60000, ms/1000%60, ms%1000/10)
+}
+
+// renderLRC 输出逐行 LRC，背景人声行不单独输出；翻译作为相同时间标签的下一行输出
+func renderLRC(lyric *Lyric) string {
+	var sb strings.Builder
+	for _, line := range lyric.Lines {
+		if line.IsBackground {
+			continue
+		}
+		fmt.Fprintf(&sb, "[%s]%s\n", formatLRCTime(line.Start), line.Text)
+		if line.Translation != "" {
+			fmt.Fprintf(&sb, "[%s]%s\n", formatLRCTime(line.Start), line.Translation)
+		}
+	}
+	return sb.String()
+}
+
+// serveConvertedLyric 解析本地歌词文件、按 opts 处理后以原格式重新输出；
+// format 为识别出的格式，为空时取扩展名。只支持既能解析又能输出的格式
+func serveConvertedLyric(w http.ResponseWriter, r *http.Request, platform, musicID, filePath, format string, opts convertOptions) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(filePath), ".")
+	}
+	if !slices.Contains(convertInputFormats, format) || !slices.Contains(convertOutputFormats, format) {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "Conversion options are not supported for "+format+" lyrics")
+		return
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		writeError(w, r, http.StatusNotFound, codeLyricNotFound, "Lyric file not found")
+		return
+	}
+	out, err := convertLyric(data, format, format, opts)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "Cannot convert lyric: "+err.Error())
+		return
+	}
+	filename := strings.TrimSuffix(filepath.Base(filePath), filepath.Ext(filePath)) + "." + format
+	setDownloadHeaders(w, platform, musicID, filePath)
+	// 内容已经改变，不再使用原文件的 ETag
+	w.Header().Del("ETag")
+	w.Header().Set(headerFormat, format)
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	http.ServeContent(w, r, filename, time.Time{}, bytes.NewReader(out))
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// --- 分页游标 ---
+
+// searchCursor 记录上一页最后一项的排序键。
+// 下一页从该位置之后继续，因此即使期间索引重新加载，未变化的条目也不会重复或遗漏
+type searchCursor struct {
+	Version      string  `json:"v"` // 生成游标时的索引版本
+	Sort         string  `json:"o"`
+	Score        float64 `json:"s"`
+	Updated      int64   `json:"u,omitempty"` // UnixNano
+	WordTimed    bool    `json:"w,omitempty"`
+	RawLyricFile string  `json:"f"`
+	Source       string  `json:"r,omitempty"` // 不同仓库中的同名歌词文件是不同的结果，以来源区分
+}
+
+// resultLess 定义结果的排序，a 应排在 b 之前时返回 true；须为全序以保证翻页稳定
+type resultLess func(a, b *SearchResult) bool
+
+// byScore 按评分降序，评分相同时按文件名、再按来源升序（默认排序）；
+// 结果按来源与文件名合并（见 runSearch），两者合起来才唯一
+func byScore(a, b *SearchResult) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if a.RawLyricFile != b.RawLyricFile {
+		return a.RawLyricFile < b.RawLyricFile
+	}
+	return a.Source < b.Source
+}
+
+// byUpdated 按歌词文件的最后修改时间降序，使最近修正的歌词排在前面
+func byUpdated(a, b *SearchResult) bool {
+	if !a.Updated.Equal(b.Updated) {
+		return a.Updated.After(b.Updated)
+	}
+	return byScore(a, b)
+}
+
+// byWordTimed 将含逐字时间的歌词排在前面，其余按评分排序
+func byWordTimed(a, b *SearchResult) bool {
+	if a.WordTimed != b.WordTimed {
+		return a.WordTimed
+	}
+	return byScore(a, b)
+}
+
+// searchSorts 为 sort 参数支持的排序方式
+var searchSorts = map[string]resultLess{
+	"score":     byScore,
+	"updated":   byUpdated,
+	"wordTimed": byWordTimed,
+}
+
+// sortedResults 返回按 less 排序的结果副本（原切片可能来自缓存，不能原地排序）
+func sortedResults(results []SearchResult, less resultLess) []SearchResult {
+	sorted := append([]SearchResult(nil), results...)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(&sorted[i], &sorted[j]) })
+	return sorted
+}
+
+var errInvalidCursor = errors.New("invalid cursor")
+
+// indexVersion 返回当前索引的版本标识，每次重新加载后改变
+func indexVersion() string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return strconv.FormatInt(lastUpdateTime.UnixNano(), 36)
+}
+
+func (c searchCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(s string) (*searchCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var c searchCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.RawLyricFile == "" {
+		return nil, errInvalidCursor
+	}
+	return &c, nil
+}
+
+// searchPage 为完整排序结果中的一页
+type searchPage struct {
+	Results    []SearchResult
+	Total      int
+	HasMore    bool
+	NextCursor string
+}
+
+// paginate 从按 sortBy 排序的完整结果中取出 after 之后的至多 limit 项（limit 不大于 0 时取全部）
+func paginate(results []SearchResult, sortBy string, after *searchCursor, limit int) searchPage {
+	less := searchSorts[sortBy]
+	start := 0
+	if after != nil {
+		pivot := SearchResult{Score: after.Score, RawLyricFile: after.RawLyricFile, Source: after.Source, WordTimed: after.WordTimed}
+		if after.Updated != 0 {
+			pivot.Updated = time.Unix(0, after.Updated).UTC()
+		}
+		start = sort.Search(len(results), func(i int) bool {
+			return less(&pivot, &results[i])
+		})
+	}
+	end := len(results)
+	if limit > 0 && start+limit < end {
+		end = start + limit
+	}
+
+	page := searchPage{Results: results[start:end], Total: len(results), HasMore: end < len(results)}
+	if page.HasMore {
+		last := results[end-1]
+		c := searchCursor{
+			Version:      indexVersion(),
+			Sort:         sortBy,
+			Score:        last.Score,
+			RawLyricFile: last.RawLyricFile,
+			Source:       last.Source,
+			WordTimed:    last.WordTimed,
+		}
+		if !last.Updated.IsZero() {
+			c.Updated = last.Updated.UnixNano()
+		}
+		page.NextCursor = c.encode()
+	}
+	return page
+}
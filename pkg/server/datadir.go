@@ -0,0 +1,56 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// --- 数据目录失效后的重新探测 ---
+
+// redetectInterval 为两次重新探测之间的最短间隔，避免大量 404 请求反复触发加载
+const redetectInterval = 10 * time.Second
+
+var (
+	redetectMu   sync.Mutex
+	lastRedetect time.Time
+)
+
+// dataDirStale 判断已加载的本地数据目录是否已不存在或不再是有效的数据目录（如被移动或删除）
+func dataDirStale() bool {
+	mu.RLock()
+	root := actualDataDir
+	mu.RUnlock()
+	if root == "" || isS3URL(root) || replicaMode() {
+		return false
+	}
+	return !isDataDir(root)
+}
+
+// redetectDataDir 在数据目录失效时重新执行 findValidDataDir 并加载索引，
+// 返回调用方是否值得重试（目录仍然有效，或重新加载后已找到有效目录）
+func redetectDataDir() bool {
+	if !dataDirStale() {
+		return false
+	}
+	redetectMu.Lock()
+	defer redetectMu.Unlock()
+	// 等待锁期间可能已由其他请求完成重新加载
+	if !dataDirStale() {
+		return true
+	}
+	if time.Since(lastRedetect) < redetectInterval {
+		return false
+	}
+	lastRedetect = time.Now()
+
+	mu.RLock()
+	old := actualDataDir
+	mu.RUnlock()
+	logInfof("Data directory %s is no longer valid, re-detecting", old)
+	loadMetadata()
+	if dataDirStale() {
+		logWarnf("Warning: no valid data directory found after %s became invalid", old)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,42 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- 客户端截止时间 ---
+
+var (
+	// 命令行参数（见 registerSearchFlags）
+	searchTimeout = new(time.Duration) // 服务端允许的最长搜索时间，客户端请求的截止时间不得超过此值
+)
+
+// clientTimeout 解析客户端声明的等待时间：
+// X-Request-Deadline-Ms 为毫秒数，Request-Timeout 为秒数（可带小数）。
+// 未提供或无法解析时返回 0。
+func clientTimeout(r *http.Request) time.Duration {
+	if v := strings.TrimSpace(r.Header.Get("X-Request-Deadline-Ms")); v != "" {
+		if ms, err := strconv.ParseInt(v, 10, 64); err == nil && ms > 0 {
+			return time.Duration(ms) * time.Millisecond
+		}
+	}
+	if v := strings.TrimSpace(r.Header.Get("Request-Timeout")); v != "" {
+		if sec, err := strconv.ParseFloat(v, 64); err == nil && sec > 0 {
+			return time.Duration(sec * float64(time.Second))
+		}
+	}
+	return 0
+}
+
+// withRequestDeadline 依据客户端声明的等待时间派生上下文，并以服务端上限封顶
+func withRequestDeadline(r *http.Request) (context.Context, context.CancelFunc) {
+	timeout := *searchTimeout
+	if t := clientTimeout(r); t > 0 && t < timeout {
+		timeout = t
+	}
+	return context.WithTimeout(r.Context(), timeout)
+}
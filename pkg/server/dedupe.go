@@ -0,0 +1,60 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"slices"
+)
+
+// --- 按歌词内容去重 ---
+
+// dedupeModes 为 dedupe 参数支持的取值
+var dedupeModes = []string{"content"}
+
+// contentHash 返回歌词文件内容的 SHA-256 前缀，与下载接口的 ETag（去掉引号）相同
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// dedupeByContent 对内容哈希相同的结果只保留排在最前的一个，其余结果的平台、ID 与条目并入该结果；
+// 没有内容哈希的结果（如回退 API 与对等实例的结果）不参与去重。返回新切片，不修改缓存中的结果
+func dedupeByContent(results []SearchResult) []SearchResult {
+	out := make([]SearchResult, 0, len(results))
+	kept := make(map[string]int, len(results))
+	copied := make(map[int]bool)
+	for _, r := range results {
+		i, ok := kept[r.ContentHash]
+		if r.ContentHash == "" || !ok {
+			if r.ContentHash != "" {
+				kept[r.ContentHash] = len(out)
+			}
+			out = append(out, r)
+			continue
+		}
+		k := &out[i]
+		if !copied[i] {
+			// 首次并入时复制，避免修改缓存中结果共用的切片与 map
+			copied[i] = true
+			k.Platforms = slices.Clone(k.Platforms)
+			k.Sources = slices.Clone(k.Sources)
+			k.PlatformIDs = maps.Clone(k.PlatformIDs)
+			if k.PlatformIDs == nil {
+				k.PlatformIDs = make(map[string]string)
+			}
+		}
+		for _, p := range r.Platforms {
+			if !slices.Contains(k.Platforms, p) {
+				k.Platforms = append(k.Platforms, p)
+			}
+		}
+		for p, id := range r.PlatformIDs {
+			if _, exists := k.PlatformIDs[p]; !exists {
+				k.PlatformIDs[p] = id
+			}
+		}
+		k.Sources = append(k.Sources, r.Sources...)
+	}
+	return out
+}
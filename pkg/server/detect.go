@@ -0,0 +1,97 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// --- 歌词格式识别 ---
+
+// lyricFileFormats 为数据目录中可能存在的歌词文件格式，format=auto 时按此顺序查找
+var lyricFileFormats = []string{"ttml", "lrc", "yrc", "qrc", "lys"}
+
+// validDownloadFormat 报告下载请求的 format 是否可接受：为空（默认 ttml）、auto 或 lyricFileFormats 之一。
+// format 会拼接进文件路径，其余取值一律拒绝
+func validDownloadFormat(format string) bool {
+	return format == "" || format == "auto" || slices.Contains(lyricFileFormats, format)
+}
+
+var (
+	yrcLinePattern = regexp.MustCompile(`^\[\d+,\d+\]\(\d+,\d+,-?\d+\)`)
+	qrcLinePattern = regexp.MustCompile(`^\[\d+,\d+\].*\(\d+,\d+\)`)
+	lysLinePattern = regexp.MustCompile(`^\[\d{1,2}\].*\(\d+,\d+\)`)
+	lrcLinePattern = regexp.MustCompile(`^\[\d+:\d+`)
+)
+
+// errUnknownLyricFormat 表示无法从内容判断歌词格式
+var errUnknownLyricFormat = errors.New("cannot detect lyric format")
+
+// detectLyricFormat 根据内容而非扩展名判断歌词格式（ttml、lrc、yrc、qrc 或 lys），无法判断时返回空字符串；
+// XML 内容按根元素区分 TTML 与包装过的 QRC，其余按前 200 行中各格式特征行的数量取最多者
+func detectLyricFormat(data []byte) string {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if bytes.HasPrefix(data, []byte("<")) {
+		head := data[:min(len(data), 4096)]
+		switch {
+		case bytes.Contains(head, []byte("<tt")):
+			return "ttml"
+		case bytes.Contains(head, []byte("QrcInfos")) || bytes.Contains(head, []byte("LyricContent")):
+			return "qrc"
+		}
+		return ""
+	}
+
+	counts := make(map[string]int)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for n := 0; n < 200 && scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case yrcLinePattern.MatchString(line):
+			counts["yrc"]++
+		case qrcLinePattern.MatchString(line):
+			counts["qrc"]++
+		case lysLinePattern.MatchString(line):
+			counts["lys"]++
+		case lrcLinePattern.MatchString(line):
+			counts["lrc"]++
+		}
+	}
+	best := ""
+	for _, format := range []string{"yrc", "qrc", "lys", "lrc"} {
+		if counts[format] > counts[best] {
+			best = format
+		}
+	}
+	return best
+}
+
+// resolveAutoLyricFile 实现 format=auto：按 lyricFileFormats 的顺序找到第一个存在的歌词文件，
+// 返回其路径与根据内容识别出的格式（无法识别时取扩展名）
+func resolveAutoLyricFile(resolve func(format string) (string, error)) (string, string, error) {
+	var lastErr error = errLyricNotFound
+	for _, format := range lyricFileFormats {
+		path, err := resolve(format)
+		if err != nil {
+			if errors.Is(err, errInvalidPlatform) {
+				return "", "", err
+			}
+			lastErr = err
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", err
+		}
+		if detected := detectLyricFormat(data); detected != "" {
+			return path, detected, nil
+		}
+		return path, format, nil
+	}
+	return "", "", lastErr
+}
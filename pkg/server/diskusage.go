@@ -0,0 +1,182 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- 磁盘占用与 Git 对象清理 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	gitPruneThresholdMB = new(int64) // .git 超过该大小（MB）时在定时同步后自动清理，为 0 时不清理
+
+	diskUsageMu     sync.Mutex
+	diskUsageCached diskUsage
+)
+
+// diskUsageTTL 为磁盘占用统计的缓存时间，遍历大型数据目录代价较高
+const diskUsageTTL = 5 * time.Minute
+
+// pruneModes 为 /api/prune 支持的清理方式：gc 清理不可达的 Git 对象，reclone 重新浅克隆数据仓库
+var pruneModes = []string{"gc", "reclone"}
+
+// diskUsage 为数据目录的磁盘占用，见 /api/status
+type diskUsage struct {
+	DataBytes  int64     `json:"data_bytes"` // 数据目录总大小（含 .git）
+	GitBytes   int64     `json:"git_bytes"`  // 其中 .git 的大小
+	MeasuredAt time.Time `json:"measured_at,omitzero"`
+}
+
+// dirSize 统计目录下所有普通文件的大小之和，无法读取的文件被忽略
+func dirSize(dir string) int64 {
+	var total int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				total += info.Size()
+			}
+		}
+		return nil
+	})
+	return total
+}
+
+// measureDiskUsage 统计本地数据目录的磁盘占用并刷新缓存；对象存储与副本模式下没有本地数据目录，返回零值
+func measureDiskUsage() diskUsage {
+	mu.RLock()
+	root := actualDataDir
+	mu.RUnlock()
+	var u diskUsage
+	if root != "" && !isS3URL(root) && !replicaMode() {
+		u = diskUsage{DataBytes: dirSize(root), GitBytes: dirSize(filepath.Join(root, ".git")), MeasuredAt: time.Now().UTC()}
+	}
+	diskUsageMu.Lock()
+	diskUsageCached = u
+	diskUsageMu.Unlock()
+	return u
+}
+
+// currentDiskUsage 返回缓存的磁盘占用，超过 diskUsageTTL 时重新统计
+func currentDiskUsage() diskUsage {
+	diskUsageMu.Lock()
+	u := diskUsageCached
+	diskUsageMu.Unlock()
+	if !u.MeasuredAt.IsZero() && time.Since(u.MeasuredAt) < diskUsageTTL {
+		return u
+	}
+	return measureDiskUsage()
+}
+
+// pruneDataRepo 清理 -data-dir 中的数据仓库：gc 使过期的 reflog 失效并清理不可达对象；
+// reclone 在旁边重新浅克隆后替换原目录，随后重新加载索引
+func pruneDataRepo(mode string) error {
+	if isS3URL(*inputDataDir) || replicaMode() {
+		return errors.New("no local data repository")
+	}
+	root, _ := filepath.Abs(*inputDataDir)
+	if _, err := os.Stat(filepath.Join(root, ".git")); err != nil {
+		return errors.New("data directory is not a git repository")
+	}
+	if mode == "reclone" && *noSync {
+		return errors.New("reclone is not available with -no-sync")
+	}
+	if mode == "reclone" && *fullHistory {
+		return errors.New("reclone is not available with -full-history")
+	}
+
+	gitMu.Lock()
+	err := func() error {
+		defer gitMu.Unlock()
+		if mode == "gc" {
+			for _, args := range [][]string{{"reflog", "expire", "--expire=now", "--all"}, {"gc", "--prune=now", "--quiet"}} {
+				if out, err := exec.Command("git", append([]string{"-C", root}, args...)...).CombinedOutput(); err != nil {
+					return fmt.Errorf("git %s failed: %v: %s", args[0], err, strings.TrimSpace(string(out)))
+				}
+			}
+			return nil
+		}
+		fresh, old := root+".reclone", root+".old"
+		os.RemoveAll(fresh)
+		if out, err := exec.Command("git", "clone", "--depth", "1", repoURL, fresh).CombinedOutput(); err != nil {
+			os.RemoveAll(fresh)
+			return fmt.Errorf("git clone failed: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		os.RemoveAll(old)
+		if err := os.Rename(root, old); err != nil {
+			os.RemoveAll(fresh)
+			return err
+		}
+		if err := os.Rename(fresh, root); err != nil {
+			os.Rename(old, root)
+			return err
+		}
+		os.RemoveAll(old)
+		return nil
+	}()
+	if err != nil {
+		return err
+	}
+	if mode == "reclone" {
+		loadMetadata()
+	}
+	measureDiskUsage()
+	return nil
+}
+
+// maybePruneDataRepo 在 .git 超过 -git-prune-threshold-mb 时先执行 gc，仍然超过时重新浅克隆
+func maybePruneDataRepo() {
+	if *gitPruneThresholdMB <= 0 {
+		return
+	}
+	limit := *gitPruneThresholdMB << 20
+	for _, mode := range pruneModes {
+		u := measureDiskUsage()
+		if u.GitBytes <= limit || (mode == "reclone" && *fullHistory) {
+			return
+		}
+		logInfof("Git objects use %d MB (threshold %d MB), pruning with %s", u.GitBytes>>20, *gitPruneThresholdMB, mode)
+		if err := pruneDataRepo(mode); err != nil {
+			logWarnf("Warning: prune (%s) failed: %v", mode, err)
+			return
+		}
+	}
+}
+
+// pruneHandler 处理 POST /api/prune?mode=gc|reclone（需要管理令牌），返回清理前后的磁盘占用
+func pruneHandler(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = "gc"
+	}
+	if mode != "gc" && mode != "reclone" {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "mode must be gc or reclone")
+		return
+	}
+	before := measureDiskUsage()
+	if err := pruneDataRepo(mode); err != nil {
+		logErrorf("Prune (%s) failed: %v", mode, err)
+		auditHTTP(r, "prune", "failed", mode+": "+err.Error())
+		writeError(w, r, http.StatusInternalServerError, codeInternal, "Prune failed: "+err.Error())
+		return
+	}
+	after := currentDiskUsage()
+	auditHTTP(r, "prune", "pruned", fmt.Sprintf("%s: %d -> %d bytes", mode, before.DataBytes, after.DataBytes))
+	writeJSON(w, r, map[string]interface{}{
+		"message": "Data repository pruned",
+		"mode":    mode,
+		"before":  before,
+		"after":   after,
+	})
+}
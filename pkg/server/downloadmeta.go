@@ -0,0 +1,76 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// --- 下载响应的 ETag 与元数据头 ---
+
+// 下载响应附带的元数据头；含非 ASCII 字符的值经百分号编码
+const (
+	headerRawLyricFile   = "X-AMLL-Raw-Lyric-File"
+	headerMusicName      = "X-AMLL-Music-Name"
+	headerArtists        = "X-AMLL-Artists" // 多个歌手以逗号分隔，各自单独编码
+	headerAlbum          = "X-AMLL-Album"
+	headerHasTranslation = "X-AMLL-Has-Translation"
+	headerHasRoman       = "X-AMLL-Has-Roman"
+	headerWordTimed      = "X-AMLL-Word-Timed"
+	headerFormat         = "X-AMLL-Format" // format=auto 时根据内容识别出的格式
+)
+
+// downloadExposedHeaders 为允许浏览器跨域读取的下载响应头
+var downloadExposedHeaders = []string{
+	"ETag", "Content-Length", "Content-Disposition", "Last-Modified",
+	headerRawLyricFile, headerMusicName, headerArtists, headerAlbum,
+	headerHasTranslation, headerHasRoman, headerWordTimed, headerFormat,
+}
+
+// fileETag 以文件内容的 SHA-256 前缀作为强 ETag，与文件的修改时间无关（Git 检出会改变 mtime）
+func fileETag(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`, nil
+}
+
+// setDownloadHeaders 为本地歌词文件设置 ETag 与索引中的元数据头；HEAD 请求据此即可判断歌词是否存在及其大小
+func setDownloadHeaders(w http.ResponseWriter, platform, musicID, filePath string) {
+	if etag, err := fileETag(filePath); err == nil {
+		w.Header().Set("ETag", etag)
+	}
+	entry, err := lookupEntry(platform, musicID)
+	if err != nil {
+		return
+	}
+	h := w.Header()
+	h.Set(headerRawLyricFile, url.PathEscape(entry.RawLyricFile))
+	if len(entry.Metadata.MusicName) > 0 {
+		h.Set(headerMusicName, url.PathEscape(entry.Metadata.MusicName[0]))
+	}
+	if len(entry.Metadata.Artists) > 0 {
+		artists := make([]string, len(entry.Metadata.Artists))
+		for i, a := range entry.Metadata.Artists {
+			artists[i] = url.PathEscape(a)
+		}
+		h.Set(headerArtists, strings.Join(artists, ","))
+	}
+	if len(entry.Metadata.Album) > 0 {
+		h.Set(headerAlbum, url.PathEscape(entry.Metadata.Album[0]))
+	}
+	h.Set(headerHasTranslation, strconv.FormatBool(entry.HasTranslation))
+	h.Set(headerHasRoman, strconv.FormatBool(entry.HasRoman))
+	h.Set(headerWordTimed, strconv.FormatBool(entry.WordTimed))
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// --- 下载统计 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	downloadStatsFile = new(string) // 非空时下载统计定期保存到该文件，重启后恢复
+)
+
+// downloadStat 为单首歌词的累计下载次数
+type downloadStat struct {
+	Platform     string    `json:"platform"`
+	MusicID      string    `json:"musicId"`
+	Count        int       `json:"count"`
+	LastDownload time.Time `json:"lastDownload"`
+}
+
+// downloadCounters 为全部下载统计，也是 -download-stats-file 的文件格式
+type downloadCounters struct {
+	Since  time.Time                `json:"since"`
+	Total  int                      `json:"total"`
+	Tracks map[string]*downloadStat `json:"tracks"` // 键为 平台/歌曲 ID
+
+	dirty bool
+}
+
+var (
+	downloads   = &downloadCounters{Since: time.Now(), Tracks: make(map[string]*downloadStat)}
+	downloadsMu sync.Mutex
+)
+
+func downloadKey(platform, musicID string) string {
+	return platform + "/" + musicID
+}
+
+// recordDownload 记录一次成功的下载（platform 为规范平台名）；歌曲 ID 已由索引校验，统计条目数不超过索引规模
+func recordDownload(platform, musicID string) {
+	now := time.Now()
+	downloadsMu.Lock()
+	defer downloadsMu.Unlock()
+
+	d := downloads
+	d.dirty = true
+	d.Total++
+	key := downloadKey(platform, musicID)
+	stat, ok := d.Tracks[key]
+	if !ok {
+		stat = &downloadStat{Platform: platform, MusicID: musicID}
+		d.Tracks[key] = stat
+	}
+	stat.Count++
+	stat.LastDownload = now
+}
+
+// loadDownloadStats 从 -download-stats-file 恢复下载统计，文件不存在时从零开始
+func loadDownloadStats() {
+	data, err := os.ReadFile(*downloadStatsFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logWarnf("Warning: failed to read download stats from %s: %v", *downloadStatsFile, err)
+		}
+		return
+	}
+	loaded := &downloadCounters{}
+	if err := json.Unmarshal(data, loaded); err != nil {
+		logWarnf("Warning: failed to parse download stats file %s: %v", *downloadStatsFile, err)
+		return
+	}
+	if loaded.Tracks == nil {
+		loaded.Tracks = make(map[string]*downloadStat)
+	}
+	downloadsMu.Lock()
+	downloads = loaded
+	downloadsMu.Unlock()
+}
+
+// saveDownloadStats 在统计有变化时写入 -download-stats-file（先写临时文件再重命名）
+func saveDownloadStats() {
+	downloadsMu.Lock()
+	if !downloads.dirty {
+		downloadsMu.Unlock()
+		return
+	}
+	data, err := json.Marshal(downloads)
+	downloads.dirty = false
+	downloadsMu.Unlock()
+	if err == nil {
+		tmp := *downloadStatsFile + ".tmp"
+		if err = os.WriteFile(tmp, data, 0o644); err == nil {
+			err = os.Rename(tmp, *downloadStatsFile)
+		}
+	}
+	if err != nil {
+		logWarnf("Warning: failed to save download stats to %s: %v", *downloadStatsFile, err)
+	}
+}
+
+// startDownloadStats 恢复已保存的下载统计，并与查询统计相同的间隔定期保存直至 ctx 取消
+func startDownloadStats(ctx context.Context) {
+	if *downloadStatsFile == "" {
+		return
+	}
+	loadDownloadStats()
+	saveOnInterval(ctx, saveDownloadStats)
+}
+
+// downloadStatView 为响应中的单条统计，附带当前索引中的歌词文件与元数据（条目已不在索引中时为空）
+type downloadStatView struct {
+	downloadStat
+	RawLyricFile string          `json:"rawLyricFile,omitempty"`
+	Metadata     [][]interface{} `json:"metadata,omitempty"`
+}
+
+func newDownloadStatView(s downloadStat) downloadStatView {
+	v := downloadStatView{downloadStat: s}
+	if entry, err := lookupEntry(s.Platform, s.MusicID); err == nil {
+		v.RawLyricFile, v.Metadata = entry.RawLyricFile, entry.MetadataRaw
+	}
+	return v
+}
+
+// downloadStatsHandler 返回下载统计（需要管理令牌）：指定 platform 与 musicId 时返回单首的下载次数，
+// 否则返回下载最多的前 limit 首（默认 20，为 0 时返回全部），可用 platform 限定平台
+func downloadStatsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 20
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "limit must be a non-negative integer")
+			return
+		}
+		limit = n
+	}
+	platform := q.Get("platform")
+	if platform != "" {
+		platform = resolvePlatform(platform)
+	}
+	musicID := q.Get("musicId")
+	if musicID != "" && platform == "" {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "musicId requires platform")
+		return
+	}
+
+	downloadsMu.Lock()
+	d := downloads
+	total, since := d.Total, d.Since
+	var stats []downloadStat
+	if musicID != "" {
+		stat := downloadStat{Platform: platform, MusicID: musicID}
+		if s, ok := d.Tracks[downloadKey(platform, musicID)]; ok {
+			stat = *s
+		}
+		stats = append(stats, stat)
+	} else {
+		for _, s := range d.Tracks {
+			if platform == "" || s.Platform == platform {
+				stats = append(stats, *s)
+			}
+		}
+	}
+	downloadsMu.Unlock()
+
+	if musicID != "" {
+		writeJSON(w, r, map[string]interface{}{
+			"status": "success",
+			"since":  since,
+			"track":  newDownloadStatView(stats[0]),
+		})
+		return
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		return downloadKey(stats[i].Platform, stats[i].MusicID) < downloadKey(stats[j].Platform, stats[j].MusicID)
+	})
+	tracks := len(stats)
+	if limit > 0 && len(stats) > limit {
+		stats = stats[:limit]
+	}
+	top := make([]downloadStatView, len(stats))
+	for i, s := range stats {
+		top[i] = newDownloadStatView(s)
+	}
+	writeJSON(w, r, map[string]interface{}{
+		"status":        "success",
+		"since":         since,
+		"total":         total,
+		"tracks":        tracks,
+		"topDownloaded": top,
+	})
+}
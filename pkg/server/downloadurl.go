@@ -0,0 +1,64 @@
+package server
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// --- 搜索结果中的下载地址 ---
+
+var publicURL = new(string) // 下载地址的公开前缀（见 registerServeFlags），为空时使用以 / 开头的相对地址
+
+// validatePublicURL 检查 -public-url 是否为 http(s) 绝对地址，并去除末尾的 /
+func validatePublicURL() error {
+	if *publicURL == "" {
+		return nil
+	}
+	u, err := url.Parse(*publicURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("expected an absolute http(s) URL")
+	}
+	*publicURL = strings.TrimRight(*publicURL, "/")
+	return nil
+}
+
+// downloadURL 构造结果在指定格式下的 /api/download 地址，平台取结果的第一个平台；启用签名时附带有效期为 -signed-url-ttl 的签名
+func downloadURL(r *SearchResult, format string) string {
+	q := url.Values{}
+	q.Set("platform", r.Platforms[0])
+	q.Set("musicId", r.ID)
+	q.Set("format", format)
+	if r.Source != "" {
+		q.Set("source", r.Source)
+	}
+	signDownloadQuery(q)
+	return *publicURL + "/api/download?" + q.Encode()
+}
+
+// withDownloadURLs 为结果附加下载地址：downloadUrl 指向原始歌词文件的格式（无法判断时为 auto），
+// downloadUrls 列出各数据目录格式的地址（对应文件不一定存在）；返回副本，不修改缓存中的结果
+func withDownloadURLs(results []SearchResult) []SearchResult {
+	if *noDownload {
+		return results
+	}
+	out := append([]SearchResult(nil), results...)
+	for i := range out {
+		r := &out[i]
+		if len(r.Platforms) == 0 {
+			continue
+		}
+		format := strings.ToLower(strings.TrimPrefix(filepath.Ext(r.RawLyricFile), "."))
+		if !slices.Contains(lyricFileFormats, format) {
+			format = "auto"
+		}
+		r.DownloadURL = downloadURL(r, format)
+		r.DownloadURLs = make(map[string]string, len(lyricFileFormats))
+		for _, f := range lyricFileFormats {
+			r.DownloadURLs[f] = downloadURL(r, f)
+		}
+	}
+	return out
+}
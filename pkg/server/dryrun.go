@@ -0,0 +1,80 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// --- 同步预演 ---
+
+// syncPreview 描述一次 git pull 将带来的变化
+type syncPreview struct {
+	WouldClone      bool           `json:"would_clone,omitempty"`
+	CommitsBehind   int            `json:"commits_behind"`
+	ChangedFiles    []string       `json:"changed_files"`
+	EntriesAffected map[string]int `json:"entries_affected"`
+}
+
+// previewSync 仅执行 git fetch 并比较 HEAD 与上游分支，不修改工作区，也不重新加载索引
+func previewSync() (*syncPreview, error) {
+	gitMu.Lock()
+	defer gitMu.Unlock()
+
+	absTarget, _ := filepath.Abs(*inputDataDir)
+	preview := &syncPreview{ChangedFiles: []string{}, EntriesAffected: make(map[string]int)}
+	if _, err := os.Stat(filepath.Join(absTarget, ".git")); os.IsNotExist(err) {
+		preview.WouldClone = true
+		return preview, nil
+	}
+
+	git := func(args ...string) (string, error) {
+		out, err := exec.Command("git", append([]string{"-C", absTarget}, args...)...).Output()
+		if err != nil {
+			return "", fmt.Errorf("git %s: %v", args[0], err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	if _, err := git("fetch", "--quiet"); err != nil {
+		return nil, err
+	}
+	behind, err := git("rev-list", "--count", "HEAD..@{u}")
+	if err != nil {
+		return nil, err
+	}
+	preview.CommitsBehind, _ = strconv.Atoi(behind)
+	if preview.CommitsBehind == 0 {
+		return preview, nil
+	}
+
+	names, err := git("diff", "--name-status", "HEAD", "@{u}")
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range strings.Split(names, "\n") {
+		if line != "" {
+			preview.ChangedFiles = append(preview.ChangedFiles, line)
+		}
+	}
+
+	// 以 index 文件的增删行数估算受影响的条目数
+	for platform, path := range indexFiles(absTarget) {
+		rel, _ := filepath.Rel(absTarget, path)
+		numstat, err := git("diff", "--numstat", "HEAD", "@{u}", "--", filepath.ToSlash(rel))
+		if err != nil || numstat == "" {
+			continue
+		}
+		fields := strings.Fields(numstat)
+		if len(fields) < 2 {
+			continue
+		}
+		added, _ := strconv.Atoi(fields[0])
+		deleted, _ := strconv.Atoi(fields[1])
+		preview.EntriesAffected[platform] = added + deleted
+	}
+	return preview, nil
+}
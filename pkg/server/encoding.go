@@ -0,0 +1,164 @@
+package server
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	searchpb "amlldb-search/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// --- 搜索响应的二进制编码 ---
+
+const (
+	mimeMsgpack  = "application/msgpack"
+	mimeProtobuf = "application/x-protobuf"
+)
+
+// responseEncoding 依据 Accept 头选择搜索响应的编码：msgpack、protobuf 或默认的 json
+func responseEncoding(r *http.Request) string {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mime, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		switch strings.ToLower(strings.TrimSpace(mime)) {
+		case mimeMsgpack, "application/x-msgpack", "application/vnd.msgpack":
+			return "msgpack"
+		case mimeProtobuf, "application/protobuf", "application/vnd.google.protobuf":
+			return "protobuf"
+		}
+	}
+	return "json"
+}
+
+// writeSearchResponse 按协商的编码写出搜索响应：msgpack 与 JSON 的结构和字段名完全一致；
+// protobuf 使用 gRPC 接口的 SearchResponse，只包含 results 中的单条结果（不支持 groupBy=song）
+func writeSearchResponse(w http.ResponseWriter, r *http.Request, resp map[string]interface{}, page searchPage, cached bool) {
+	w.Header().Add("Vary", "Accept")
+	switch responseEncoding(r) {
+	case "msgpack":
+		data, err := encodeMsgpack(resp)
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, codeInternal, "Failed to encode response")
+			return
+		}
+		w.Header().Set("Content-Type", mimeMsgpack)
+		w.Write(data)
+	case "protobuf":
+		pb := &searchpb.SearchResponse{
+			Results:    make([]*searchpb.SearchResult, 0, len(page.Results)),
+			Truncated:  page.HasMore,
+			Cached:     cached,
+			Total:      int32(page.Total),
+			NextCursor: page.NextCursor,
+		}
+		for _, res := range page.Results {
+			pb.Results = append(pb.Results, toPBResult(res))
+		}
+		data, err := proto.Marshal(pb)
+		if err != nil {
+			writeError(w, r, http.StatusInternalServerError, codeInternal, "Failed to encode response")
+			return
+		}
+		w.Header().Set("Content-Type", mimeProtobuf)
+		w.Write(data)
+	default:
+		writeJSON(w, r, resp)
+	}
+}
+
+// encodeMsgpack 先按 JSON 规则序列化（沿用 json 标签与 omitempty），再转换为 MessagePack
+func encodeMsgpack(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var generic interface{}
+	if err := dec.Decode(&generic); err != nil {
+		return nil, err
+	}
+	return appendMsgpack(nil, generic), nil
+}
+
+// appendMsgpack 编码 JSON 解码得到的通用值；整数使用最短的整数类型，其余数字为 float64
+func appendMsgpack(b []byte, v interface{}) []byte {
+	switch v := v.(type) {
+	case nil:
+		return append(b, 0xc0)
+	case bool:
+		if v {
+			return append(b, 0xc3)
+		}
+		return append(b, 0xc2)
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return appendMsgpackInt(b, n)
+		}
+		f, _ := v.Float64()
+		b = append(b, 0xcb)
+		return binary.BigEndian.AppendUint64(b, math.Float64bits(f))
+	case string:
+		n := len(v)
+		switch {
+		case n < 32:
+			b = append(b, 0xa0|byte(n))
+		case n <= math.MaxUint8:
+			b = append(b, 0xd9, byte(n))
+		case n <= math.MaxUint16:
+			b = binary.BigEndian.AppendUint16(append(b, 0xda), uint16(n))
+		default:
+			b = binary.BigEndian.AppendUint32(append(b, 0xdb), uint32(n))
+		}
+		return append(b, v...)
+	case []interface{}:
+		b = appendMsgpackHeader(b, len(v), 0x90, 0xdc, 0xdd)
+		for _, e := range v {
+			b = appendMsgpack(b, e)
+		}
+		return b
+	case map[string]interface{}:
+		b = appendMsgpackHeader(b, len(v), 0x80, 0xde, 0xdf)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			b = appendMsgpack(b, k)
+			b = appendMsgpack(b, v[k])
+		}
+		return b
+	}
+	return append(b, 0xc0)
+}
+
+func appendMsgpackInt(b []byte, n int64) []byte {
+	switch {
+	case n >= 0 && n < 128:
+		return append(b, byte(n))
+	case n < 0 && n >= -32:
+		return append(b, byte(n))
+	case n >= math.MinInt32 && n <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(append(b, 0xd2), uint32(int32(n)))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, 0xd3), uint64(n))
+	}
+}
+
+// appendMsgpackHeader 写出数组或映射的长度头（fix 类型、16 位或 32 位）
+func appendMsgpackHeader(b []byte, n int, fix, c16, c32 byte) []byte {
+	switch {
+	case n < 16:
+		return append(b, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(b, c16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(b, c32), uint32(n))
+	}
+}
@@ -0,0 +1,233 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// --- 上游元数据补全 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	enrichEnabled = new(bool)
+	enrichRate    = new(float64) // 每秒最多请求上游的次数
+
+	// 上游接口地址
+	ncmSongDetailURL = "https://music.163.com/api/song/detail/"
+	qqSongDetailURL  = "https://c.y.qq.com/v8/fcg-bin/fcg_play_single_song.fcg"
+
+	enrichClient = &http.Client{Timeout: 5 * time.Second}
+)
+
+// enrichTTL 为补全结果的缓存时间（上游查无此歌时同样缓存，避免反复请求）
+const enrichTTL = 24 * time.Hour
+
+// songDetails 为从上游平台补全的字段
+type songDetails struct {
+	Album      string
+	DurationMs int64
+	CoverURL   string
+}
+
+type enrichCacheItem struct {
+	details songDetails
+	fetched time.Time
+}
+
+var (
+	enrichCache   = make(map[string]enrichCacheItem)
+	enrichCacheMu sync.Mutex
+
+	// 限流：相邻两次上游请求之间至少间隔 1/enrichRate 秒
+	enrichLimiterMu sync.Mutex
+	enrichNext      time.Time
+)
+
+// waitEnrichSlot 等待下一个可用的上游请求时机，ctx 结束时返回错误
+func waitEnrichSlot(ctx context.Context) error {
+	interval := time.Second
+	if *enrichRate > 0 {
+		interval = time.Duration(float64(time.Second) / *enrichRate)
+	}
+	enrichLimiterMu.Lock()
+	now := time.Now()
+	if enrichNext.Before(now) {
+		enrichNext = now
+	}
+	wait := enrichNext.Sub(now)
+	enrichNext = enrichNext.Add(interval)
+	enrichLimiterMu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// fetchUpstreamJSON 请求上游接口并解码 JSON 响应
+func fetchUpstreamJSON(ctx context.Context, u string, v interface{}) error {
+	if err := waitEnrichSlot(ctx); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0")
+	resp, err := enrichClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("upstream returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// fetchNCMDetails 通过网易云音乐的歌曲详情接口查询
+func fetchNCMDetails(ctx context.Context, id string) (songDetails, error) {
+	var body struct {
+		Songs []struct {
+			Duration int64 `json:"duration"`
+			Album    struct {
+				Name   string `json:"name"`
+				PicURL string `json:"picUrl"`
+			} `json:"album"`
+		} `json:"songs"`
+	}
+	params := url.Values{"ids": {"[" + id + "]"}}
+	if err := fetchUpstreamJSON(ctx, ncmSongDetailURL+"?"+params.Encode(), &body); err != nil {
+		return songDetails{}, err
+	}
+	if len(body.Songs) == 0 {
+		return songDetails{}, nil
+	}
+	s := body.Songs[0]
+	return songDetails{Album: s.Album.Name, DurationMs: s.Duration, CoverURL: s.Album.PicURL}, nil
+}
+
+// fetchQQDetails 通过 QQ 音乐的单曲接口查询，ID 为纯数字时按 songid 查询，否则按 songmid 查询
+func fetchQQDetails(ctx context.Context, id string) (songDetails, error) {
+	var body struct {
+		Data []struct {
+			Interval int64 `json:"interval"`
+			Album    struct {
+				Mid  string `json:"mid"`
+				Name string `json:"name"`
+			} `json:"album"`
+		} `json:"data"`
+	}
+	params := url.Values{"format": {"json"}, "platform": {"yqq"}}
+	if _, err := strconv.ParseInt(id, 10, 64); err == nil {
+		params.Set("songid", id)
+	} else {
+		params.Set("songmid", id)
+	}
+	if err := fetchUpstreamJSON(ctx, qqSongDetailURL+"?"+params.Encode(), &body); err != nil {
+		return songDetails{}, err
+	}
+	if len(body.Data) == 0 {
+		return songDetails{}, nil
+	}
+	s := body.Data[0]
+	details := songDetails{Album: s.Album.Name, DurationMs: s.Interval * 1000}
+	if s.Album.Mid != "" {
+		details.CoverURL = "https://y.gtimg.cn/music/photo_new/T002R300x300M000" + s.Album.Mid + ".jpg"
+	}
+	return details, nil
+}
+
+var enrichFetchers = map[string]func(context.Context, string) (songDetails, error){
+	"ncm": fetchNCMDetails,
+	"qq":  fetchQQDetails,
+}
+
+// songDetailsFor 查询单个平台条目的补全字段（优先使用缓存）；上游出错时不缓存，以便稍后重试
+func songDetailsFor(ctx context.Context, platform, id string) (songDetails, bool) {
+	fetch, ok := enrichFetchers[platform]
+	if !ok {
+		return songDetails{}, false
+	}
+	key := platform + ":" + id
+	enrichCacheMu.Lock()
+	item, ok := enrichCache[key]
+	enrichCacheMu.Unlock()
+	if ok && time.Since(item.fetched) < enrichTTL {
+		return item.details, true
+	}
+
+	details, err := fetch(ctx, id)
+	if err != nil {
+		return songDetails{}, false
+	}
+	enrichCacheMu.Lock()
+	enrichCache[key] = enrichCacheItem{details: details, fetched: time.Now()}
+	enrichCacheMu.Unlock()
+	return details, true
+}
+
+// enrichCandidates 返回可用于查询上游的 (平台, ID)：先是按优先级排列的 ncm、qq 条目，
+// 再是元数据中记录的 ncmMusicId、qqMusicId（raw 平台的条目只能通过后者补全）
+func enrichCandidates(r *SearchResult) [][2]string {
+	var list [][2]string
+	add := func(platform, id string) {
+		c := [2]string{platform, id}
+		if id != "" && !slices.Contains(list, c) {
+			list = append(list, c)
+		}
+	}
+	for _, src := range r.Sources {
+		if _, ok := enrichFetchers[src.Platform]; ok {
+			add(src.Platform, src.ID)
+		}
+	}
+	md, _ := parseMetadata(r.Metadata)
+	for _, id := range md.NCMMusicID {
+		add("ncm", id)
+	}
+	for _, id := range md.QQMusicID {
+		add("qq", id)
+	}
+	return list
+}
+
+// enrichResults 为结果补全专辑、时长与封面，返回新的切片（results 可能来自查询缓存，不能原地修改）。
+// 依次尝试各候选条目直到上游返回数据，ctx 结束后剩余结果保持原样
+func enrichResults(ctx context.Context, results []SearchResult) []SearchResult {
+	enriched := append([]SearchResult(nil), results...)
+	for i := range enriched {
+		r := &enriched[i]
+		for _, c := range enrichCandidates(r) {
+			if ctx.Err() != nil {
+				return enriched
+			}
+			details, ok := songDetailsFor(ctx, c[0], c[1])
+			if !ok || details == (songDetails{}) {
+				continue
+			}
+			// 仅补全元数据中缺失的专辑
+			if md, _ := parseMetadata(r.Metadata); len(md.Album) == 0 {
+				r.Album = details.Album
+			}
+			r.DurationMs = details.DurationMs
+			r.CoverURL = details.CoverURL
+			break
+		}
+	}
+	return enriched
+}
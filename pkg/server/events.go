@@ -0,0 +1,49 @@
+package server
+
+import (
+	"sync"
+	"time"
+)
+
+// --- 服务器事件 ---
+
+// serverEvent 是推送给长连接客户端的服务器事件
+type serverEvent struct {
+	Type string                 `json:"type"`
+	Time time.Time              `json:"time"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+var (
+	eventSubsMu sync.Mutex
+	eventSubs   = make(map[chan serverEvent]struct{})
+)
+
+// subscribeEvents 注册一个事件订阅者，使用完毕后需调用 unsubscribeEvents
+func subscribeEvents() chan serverEvent {
+	ch := make(chan serverEvent, 16)
+	eventSubsMu.Lock()
+	eventSubs[ch] = struct{}{}
+	eventSubsMu.Unlock()
+	return ch
+}
+
+func unsubscribeEvents(ch chan serverEvent) {
+	eventSubsMu.Lock()
+	delete(eventSubs, ch)
+	eventSubsMu.Unlock()
+}
+
+// publishEvent 向所有订阅者广播事件；订阅者缓冲区已满时丢弃该事件，避免慢客户端阻塞同步流程
+func publishEvent(eventType string, data map[string]interface{}) {
+	ev := serverEvent{Type: eventType, Time: time.Now(), Data: data}
+
+	eventSubsMu.Lock()
+	defer eventSubsMu.Unlock()
+	for ch := range eventSubs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
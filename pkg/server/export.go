@@ -0,0 +1,188 @@
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// --- 索引导出 ---
+
+// exportRow 为合并去重后的一首歌曲，元数据已解析为独立的列
+type exportRow struct {
+	RawLyricFile     string            `json:"rawLyricFile"`
+	Source           string            `json:"source,omitempty"` // 条目来自 -repo 仓库时为仓库名称
+	Platforms        []string          `json:"platforms"`
+	PlatformIDs      map[string]string `json:"platformIds"`
+	MusicName        []string          `json:"musicName"`
+	Artists          []string          `json:"artists"`
+	Album            []string          `json:"album"`
+	ISRC             []string          `json:"isrc"`
+	TTMLAuthorGithub []string          `json:"ttmlAuthorGithubLogin"`
+}
+
+var exportFormats = []string{"csv", "json", "sqlite"}
+
+// collectExportRows 按 rawLyricFile 合并各平台条目（与搜索结果一样，不同仓库中的同名歌词文件不合并），
+// 平台按合并优先级处理，优先级最高的平台提供元数据
+func collectExportRows() []*exportRow {
+	ranks := platformRanks()
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	ordered := slices.Clone(platforms)
+	slices.SortStableFunc(ordered, func(a, b string) int { return rankOf(ranks, a) - rankOf(ranks, b) })
+
+	merged := make(map[string]*exportRow)
+	var order []string
+	for _, p := range ordered {
+		for _, entry := range dataStore[p] {
+			key := entry.RawLyricFile
+			if entry.Source != "" {
+				key = entry.Source + "\x00" + key
+			}
+			row, ok := merged[key]
+			if !ok {
+				row = &exportRow{
+					RawLyricFile:     entry.RawLyricFile,
+					Source:           entry.Source,
+					PlatformIDs:      make(map[string]string),
+					MusicName:        entry.Metadata.MusicName,
+					Artists:          entry.Metadata.Artists,
+					Album:            entry.Metadata.Album,
+					ISRC:             entry.Metadata.ISRC,
+					TTMLAuthorGithub: entry.Metadata.TTMLAuthorGithubLogin,
+				}
+				merged[key] = row
+				order = append(order, key)
+			}
+			row.Platforms = append(row.Platforms, p)
+			row.PlatformIDs[p] = entry.ID
+		}
+	}
+
+	sort.Strings(order)
+	rows := make([]*exportRow, 0, len(order))
+	for _, key := range order {
+		rows = append(rows, merged[key])
+	}
+	return rows
+}
+
+// idPlatforms 返回需要单独导出 ID 列的平台（raw 平台的 ID 即文件名，不单独成列）
+func idPlatforms() []string {
+	var list []string
+	for _, p := range platformList() {
+		if p != "raw" {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+// exportColumns 返回导出的列名，每个平台一列 ID
+func exportColumns(ids []string) []string {
+	cols := []string{"raw_lyric_file", "source", "platforms"}
+	for _, p := range ids {
+		cols = append(cols, p+"_id")
+	}
+	return append(cols, "music_name", "artists", "album", "isrc", "ttml_author")
+}
+
+// 多值字段以 " / " 连接
+func (r *exportRow) columns(ids []string) []string {
+	join := func(v []string) string { return strings.Join(v, " / ") }
+	cols := []string{r.RawLyricFile, r.Source, strings.Join(r.Platforms, ",")}
+	for _, p := range ids {
+		cols = append(cols, r.PlatformIDs[p])
+	}
+	return append(cols, join(r.MusicName), join(r.Artists), join(r.Album), join(r.ISRC), join(r.TTMLAuthorGithub))
+}
+
+func writeExportCSV(w io.Writer, rows []*exportRow) error {
+	cw := csv.NewWriter(w)
+	ids := idPlatforms()
+	cw.Write(exportColumns(ids))
+	for _, r := range rows {
+		cw.Write(r.columns(ids))
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeExportJSON(w io.Writer, rows []*exportRow) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(rows)
+}
+
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// writeExportSQLite 通过 sqlite3 命令行工具写入数据库文件（与 Git 同步一样依赖外部可执行文件）
+func writeExportSQLite(path string, rows []*exportRow) error {
+	var sql bytes.Buffer
+	ids := idPlatforms()
+	sql.WriteString("BEGIN;\nDROP TABLE IF EXISTS lyrics;\nCREATE TABLE lyrics (")
+	for i, c := range exportColumns(ids) {
+		if i > 0 {
+			sql.WriteString(", ")
+		}
+		sql.WriteString(`"` + strings.ReplaceAll(c, `"`, `""`) + `" TEXT`)
+	}
+	sql.WriteString(");\n")
+	for _, r := range rows {
+		cols := r.columns(ids)
+		for i := range cols {
+			cols[i] = sqlQuote(cols[i])
+		}
+		fmt.Fprintf(&sql, "INSERT INTO lyrics VALUES (%s);\n", strings.Join(cols, ", "))
+	}
+	sql.WriteString("CREATE INDEX idx_lyrics_music_name ON lyrics(music_name);\nCREATE INDEX idx_lyrics_artists ON lyrics(artists);\nCOMMIT;\n")
+
+	cmd := exec.Command("sqlite3", path)
+	cmd.Stdin = &sql
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("sqlite3 failed: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// exportIndex 按指定格式将合并后的索引写入 out（sqlite 格式必须指定输出文件）
+func exportIndex(format, out string) (int, error) {
+	rows := collectExportRows()
+
+	if format == "sqlite" {
+		if out == "" {
+			return 0, fmt.Errorf("sqlite export requires an output file")
+		}
+		return len(rows), writeExportSQLite(out, rows)
+	}
+
+	var w io.Writer = os.Stdout
+	if out != "" {
+		file, err := os.Create(out)
+		if err != nil {
+			return 0, err
+		}
+		defer file.Close()
+		w = file
+	}
+
+	switch format {
+	case "csv":
+		return len(rows), writeExportCSV(w, rows)
+	case "json":
+		return len(rows), writeExportJSON(w, rows)
+	}
+	return 0, fmt.Errorf("unsupported export format %q", format)
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- LRCLIB 回退 ---
+
+// fallbackPlatform 为回退结果在 platforms 与下载接口中使用的平台名
+const fallbackPlatform = "lrclib"
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	fallbackURL = new(string) // 兼容 LRCLIB 的 API 地址，为空时不回退
+
+	fallbackClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// lrclibTrack 对应 LRCLIB /api/search 与 /api/get 返回的单首歌曲
+type lrclibTrack struct {
+	ID           int64   `json:"id"`
+	TrackName    string  `json:"trackName"`
+	ArtistName   string  `json:"artistName"`
+	AlbumName    string  `json:"albumName"`
+	Duration     float64 `json:"duration"`
+	PlainLyrics  string  `json:"plainLyrics"`
+	SyncedLyrics string  `json:"syncedLyrics"`
+}
+
+// fallbackEnabled 判断是否配置了回退 API
+func fallbackEnabled() bool {
+	return strings.TrimSpace(*fallbackURL) != ""
+}
+
+// fallbackGet 请求回退 API 并将 JSON 响应解码到 v，404 时返回 errLyricNotFound
+func fallbackGet(ctx context.Context, path string, params url.Values, v interface{}) error {
+	u := strings.TrimRight(*fallbackURL, "/") + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "amlldb-search (https://github.com/jwbb903/amll-search)")
+	resp, err := fallbackClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errLyricNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fallback API returned %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// toSearchResult 将 LRCLIB 的歌曲转换为搜索结果，评分与本地结果使用相同的评分函数
+func (t lrclibTrack) toSearchResult(scorer entryScorer) SearchResult {
+	entry := IndexEntry{
+		ID: strconv.FormatInt(t.ID, 10),
+		MetadataRaw: [][]interface{}{
+			{"musicName", []interface{}{t.TrackName}},
+			{"artists", []interface{}{t.ArtistName}},
+			{"album", []interface{}{t.AlbumName}},
+		},
+	}
+	entry.Metadata, _ = parseMetadata(entry.MetadataRaw)
+	score, ok := scorer(&entry)
+	if !ok {
+		score = crossFieldScore
+	}
+	return SearchResult{
+		ID:          entry.ID,
+		Metadata:    entry.MetadataRaw,
+		Platforms:   []string{fallbackPlatform},
+		PlatformIDs: map[string]string{fallbackPlatform: entry.ID},
+		Score:       score,
+		Source:      fallbackPlatform,
+	}
+}
+
+// fallbackSearch 在本地没有结果时查询回退 API：全文搜索使用 q 参数，按字段搜索使用 track_name 等参数
+func fallbackSearch(ctx context.Context, query string, fields fieldQuery, opts searchOptions) ([]SearchResult, error) {
+	params := url.Values{}
+	var scorer entryScorer
+	if fields.empty() {
+		query = rewriteQuery(currentRewriteRules(), query)
+		params.Set("q", query)
+		query = currentTextRules().apply(query)
+		scorer = func(entry *IndexEntry) (float64, bool) { return textScore(entry, query), true }
+	} else {
+		if fields.Title != "" {
+			params.Set("track_name", rewriteQuery(currentRewriteRules(), fields.Title))
+		}
+		if len(fields.Artists) > 0 {
+			params.Set("artist_name", strings.Join(fields.Artists, " "))
+		}
+		if fields.Album != "" {
+			params.Set("album_name", fields.Album)
+		}
+		scorer, _ = fields.compile()
+	}
+
+	var tracks []lrclibTrack
+	if err := fallbackGet(ctx, "/api/search", params, &tracks); err != nil {
+		return nil, err
+	}
+
+	results := make([]SearchResult, 0, len(tracks))
+	for _, t := range tracks {
+		// 仅返回带歌词的条目；语言与逐字等筛选条件在回退结果上无法判断，指定时不回退（见 searchHandler）
+		if t.SyncedLyrics == "" && t.PlainLyrics == "" {
+			continue
+		}
+		if r := t.toSearchResult(scorer); r.Score >= opts.MinScore {
+			results = append(results, r)
+		}
+	}
+	return sortedResults(results, byScore), nil
+}
+
+// fallbackLyric 下载回退 API 中的歌词，优先返回带时间轴的 LRC，否则返回纯文本
+func fallbackLyric(ctx context.Context, id string) (content, ext string, err error) {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return "", "", errLyricNotFound
+	}
+	var t lrclibTrack
+	if err := fallbackGet(ctx, "/api/get/"+id, nil, &t); err != nil {
+		return "", "", err
+	}
+	switch {
+	case t.SyncedLyrics != "":
+		return t.SyncedLyrics, "lrc", nil
+	case t.PlainLyrics != "":
+		return t.PlainLyrics, "txt", nil
+	}
+	return "", "", errLyricNotFound
+}
+
+// serveFallbackLyric 处理 platform=lrclib 的下载请求
+func serveFallbackLyric(w http.ResponseWriter, r *http.Request, id, format string, opts convertOptions) {
+	if format != "" && format != "lrc" {
+		writeError(w, r, http.StatusNotFound, codeLyricNotFound, "Only lrc is available from the fallback source")
+		return
+	}
+	content, ext, err := fallbackLyric(r.Context(), id)
+	switch {
+	case errors.Is(err, errLyricNotFound):
+		writeError(w, r, http.StatusNotFound, codeLyricNotFound, "Lyric file not found")
+		return
+	case err != nil:
+		logErrorf("Fallback download failed: %v", err)
+		writeError(w, r, http.StatusBadGateway, codeFallbackFailed, "Fallback source unavailable")
+		return
+	}
+	if !opts.isZero() {
+		out, err := convertLyric([]byte(content), ext, ext, opts)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "Cannot convert lyric: "+err.Error())
+			return
+		}
+		content = string(out)
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s-%s.%s", fallbackPlatform, id, ext))
+	w.Write([]byte(content))
+}
@@ -0,0 +1,188 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- 联邦搜索 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	peers       peerList
+	peerTimeout = new(time.Duration)
+	peerLimit   = new(int)
+)
+
+// federatedHeader 标记来自其他实例的联邦请求，收到时不再向自己的对等实例转发，避免互相配置时循环请求
+const federatedHeader = "X-AMLL-Federated"
+
+// peer 为一个对等实例，name 用于结果中的 source 字段；key 非空时作为 X-API-Key 随搜索请求发送
+type peer struct {
+	name string
+	url  string
+	key  string
+}
+
+// peerList 为 -peer 的取值，形如 name=http://host:port，对等实例配置了 -api-keys 时追加 ,key=<API 密钥>
+type peerList []peer
+
+func (l *peerList) String() string {
+	names := make([]string, 0, len(*l))
+	for _, p := range *l {
+		names = append(names, p.name)
+	}
+	return strings.Join(names, ",")
+}
+
+func (l *peerList) Set(v string) error {
+	name, rest, ok := strings.Cut(v, "=")
+	u, options, _ := strings.Cut(rest, ",")
+	name, u = strings.TrimSpace(name), strings.TrimRight(strings.TrimSpace(u), "/")
+	if !ok || name == "" || u == "" {
+		return fmt.Errorf("invalid peer %q, expected name=url[,key=api-key]", v)
+	}
+	if _, err := url.ParseRequestURI(u); err != nil {
+		return fmt.Errorf("invalid peer url %q: %v", u, err)
+	}
+	p := peer{name: name, url: u}
+	if options != "" {
+		key, ok := strings.CutPrefix(strings.TrimSpace(options), "key=")
+		if !ok || key == "" {
+			return fmt.Errorf("invalid peer option %q, expected key=api-key", options)
+		}
+		p.key = key
+	}
+	*l = append(*l, p)
+	return nil
+}
+
+// findPeer 按名称查找对等实例
+func findPeer(name string) (peer, bool) {
+	for _, p := range peers {
+		if p.name == name {
+			return p, true
+		}
+	}
+	return peer{}, false
+}
+
+// peerSearchRequest 为发往对等实例的搜索请求体，与 POST /api/search 的参数一致
+type peerSearchRequest struct {
+	Query          string   `json:"query,omitempty"`
+	Platforms      []string `json:"platforms,omitempty"`
+	Limit          int      `json:"limit"`
+	MinScore       float64  `json:"minScore,omitempty"`
+	Lang           string   `json:"lang,omitempty"`
+	HasTranslation *bool    `json:"hasTranslation,omitempty"`
+	HasRoman       *bool    `json:"hasRoman,omitempty"`
+	WordTimed      *bool    `json:"wordTimed,omitempty"`
+	fieldQuery
+}
+
+// searchPeer 向单个对等实例发送搜索请求，结果的 source 设为该实例的名称
+func searchPeer(ctx context.Context, p peer, body []byte) ([]SearchResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url+"/api/search", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(federatedHeader, "1")
+	if p.key != "" {
+		req.Header.Set("X-API-Key", p.key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer returned %s", resp.Status)
+	}
+	var out struct {
+		Results []SearchResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	for i := range out.Results {
+		out.Results[i].Source = p.name
+	}
+	return out.Results, nil
+}
+
+// federatedSearch 并行查询全部对等实例（每个实例最多 -peer-limit 条，超时 -peer-timeout），
+// 将结果并入本地结果：同一歌词文件以本地结果为准，其余按评分重新排序。返回合并后的结果与失败的实例名
+func federatedSearch(ctx context.Context, local []SearchResult, query string, fields fieldQuery, opts searchOptions) ([]SearchResult, []string) {
+	body, _ := json.Marshal(peerSearchRequest{
+		Query:          query,
+		Platforms:      opts.Platforms,
+		Limit:          *peerLimit,
+		MinScore:       opts.MinScore,
+		Lang:           opts.Lang,
+		HasTranslation: opts.HasTranslation,
+		HasRoman:       opts.HasRoman,
+		WordTimed:      opts.WordTimed,
+		fieldQuery:     fields,
+	})
+	ctx, cancel := context.WithTimeout(ctx, *peerTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var resMu sync.Mutex
+	remote := make(map[string][]SearchResult, len(peers))
+	var failed []string
+	for _, p := range peers {
+		wg.Add(1)
+		go func(p peer) {
+			defer wg.Done()
+			results, err := searchPeer(ctx, p, body)
+			resMu.Lock()
+			defer resMu.Unlock()
+			if err != nil {
+				logErrorf("Federated search on peer %s failed: %v", p.name, err)
+				failed = append(failed, p.name)
+				return
+			}
+			remote[p.name] = results
+		}(p)
+	}
+	wg.Wait()
+
+	merged := append([]SearchResult(nil), local...)
+	seen := make(map[string]bool, len(local))
+	for _, r := range local {
+		seen[r.RawLyricFile] = true
+	}
+	// 按 -peer 的顺序合并，先配置的实例优先
+	for _, p := range peers {
+		for _, r := range remote[p.name] {
+			if r.RawLyricFile != "" && seen[r.RawLyricFile] {
+				continue
+			}
+			seen[r.RawLyricFile] = true
+			merged = append(merged, r)
+		}
+	}
+	return sortedResults(merged, byScore), failed
+}
+
+// redirectToPeer 将 source 指向对等实例的下载请求重定向到该实例
+func redirectToPeer(w http.ResponseWriter, r *http.Request, p peer, platform, musicID, format string, opts convertOptions) {
+	params := url.Values{"platform": {platform}, "musicId": {musicID}}
+	if format != "" {
+		params.Set("format", format)
+	}
+	opts.encode(params)
+	if opts.Layer != "" {
+		params.Set("layer", opts.Layer)
+	}
+	http.Redirect(w, r, p.url+"/api/download?"+params.Encode(), http.StatusFound)
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// --- 结构化字段搜索 ---
+
+// fieldQuery 按字段分别匹配解析后的元数据，用于歌单匹配等需要精确区分标题与歌手的场景
+type fieldQuery struct {
+	Title   string   `json:"title"`
+	Artists []string `json:"artists"`
+	Album   string   `json:"album"`
+}
+
+// empty 判断是否未指定任何字段
+func (q fieldQuery) empty() bool {
+	if normalizeField(q.Title) != "" || normalizeField(q.Album) != "" {
+		return false
+	}
+	for _, a := range q.Artists {
+		if normalizeField(a) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeField 统一字段的比较形式：转小写、全角转半角，并去除空白与标点，
+// 使 "Hello, World!" 与 "hello world" 视为相同
+func normalizeField(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		// 全角 ASCII（！到～）映射到半角
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// compile 生成条目评分函数及对应的缓存键；
+// 每个指定的字段都须命中，多个歌手须全部出现在条目的歌手列表中（可通过别名命中），
+// 评分为各字段加权覆盖率的平均值，歌名或全部歌手完全相同时再加上对应的加成
+func (q fieldQuery) compile() (entryScorer, string) {
+	// 歌名与专辑先经过查询改写规则去除噪声
+	mu.RLock()
+	aliases, rewrites := artistAliases, activeRewriteRules
+	mu.RUnlock()
+	title := normalizeField(rewriteQuery(rewrites, q.Title))
+	album := normalizeField(rewriteQuery(rewrites, q.Album))
+	// 每个查询歌手展开为其全部别名，命中任一别名即可
+	var artists [][]string
+	var keys []string
+	for _, a := range q.Artists {
+		if normalizeField(a) == "" {
+			continue
+		}
+		var alts []string
+		for _, alias := range expandArtists(aliases, []string{a}) {
+			if n := normalizeField(alias); n != "" {
+				alts = append(alts, n)
+			}
+		}
+		artists = append(artists, alts)
+		keys = append(keys, strings.Join(alts, "|"))
+	}
+
+	key := "fields:title=" + title + ";album=" + album + ";artists=" + strings.Join(keys, ",")
+	scorer := func(entry *IndexEntry) (float64, bool) {
+		md := &entry.Metadata
+		var total, last float64
+		var n int
+		check := func(values []string, field string, wants ...string) bool {
+			if len(wants) == 0 || wants[0] == "" {
+				return true
+			}
+			best := 0.0
+			for _, want := range wants {
+				if c := bestCoverage(values, want); c > best {
+					best = c
+				}
+			}
+			total += best * fieldWeights.weight(field)
+			last = best
+			n++
+			return best > 0
+		}
+		if !check(md.MusicName, "title", title) {
+			return 0, false
+		}
+		titleExact := title != "" && last == 1
+		if !check(md.Album, "album", album) {
+			return 0, false
+		}
+		artistExact := len(artists) > 0
+		for _, alts := range artists {
+			if !check(md.Artists, "artist", alts...) {
+				return 0, false
+			}
+			artistExact = artistExact && last == 1
+		}
+		return total/float64(n) + exactBoost(titleExact, artistExact), true
+	}
+	return scorer, key
+}
+
+// runFieldSearch 在所有目标平台中按字段执行搜索，结果格式与全文搜索一致
+func runFieldSearch(ctx context.Context, q fieldQuery, opts searchOptions) (searchOutcome, error) {
+	scorer, key := q.compile()
+	return executeSearch(ctx, key, scorer, opts)
+}
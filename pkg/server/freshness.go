@@ -0,0 +1,116 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// --- 条目更新时间 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	fullHistory = new(bool) // 以完整历史克隆数据仓库（已有的浅克隆在下次同步时补全），使更新时间可用
+)
+
+// lyricTimes 在一次加载过程中解析并缓存原始歌词文件的最后修改时间
+type lyricTimes struct {
+	root  string
+	times map[string]time.Time
+}
+
+// newLyricTimes 优先从 Git 历史读取 raw-lyrics 下各文件最近一次提交的时间（一次 git log 遍历），
+// 非 Git 目录或未出现在历史中的文件在查询时回退到文件的 mtime。
+// 浅克隆（默认的 --depth 1）的边界提交包含当时的全部文件，其提交时间并不是这些文件的修改时间，
+// 只出现在边界提交中的文件视为时间未知，而不是全部得到同一个时间
+func newLyricTimes(root string) *lyricTimes {
+	lt := &lyricTimes{root: root, times: make(map[string]time.Time)}
+	if root == "" {
+		return lt
+	}
+	if _, err := os.Stat(filepath.Join(root, ".git")); err != nil {
+		return lt
+	}
+
+	cmd := exec.Command("git", "log", "--format=%x1e%H %ct", "--name-only", "--", "raw-lyrics")
+	cmd.Dir = root
+	out, err := cmd.Output()
+	if err != nil {
+		return lt
+	}
+	boundary := shallowBoundary(root)
+	if len(boundary) > 0 {
+		logInfof("Data repository %s is a shallow clone: update times are only known for lyrics changed after its oldest fetched commit (use -full-history for complete times)", root)
+	}
+
+	// git log 从新到旧输出，每个文件只记录第一次出现（即最近一次修改）的时间
+	var commitTime time.Time
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if rest, ok := strings.CutPrefix(line, "\x1e"); ok {
+			hash, ts, _ := strings.Cut(rest, " ")
+			commitTime = time.Time{}
+			if sec, err := strconv.ParseInt(ts, 10, 64); err == nil && !boundary[hash] {
+				commitTime = time.Unix(sec, 0).UTC()
+			}
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		name := strings.TrimPrefix(line, "raw-lyrics/")
+		if _, ok := lt.times[name]; !ok {
+			lt.times[name] = commitTime
+		}
+	}
+	return lt
+}
+
+// shallowBoundary 返回浅克隆的边界提交（.git/shallow 中列出的提交），完整克隆时为空
+func shallowBoundary(root string) map[string]bool {
+	out, err := exec.Command("git", "-C", root, "rev-parse", "--git-path", "shallow").Output()
+	if err != nil {
+		return nil
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	boundary := make(map[string]bool)
+	for _, hash := range strings.Fields(string(data)) {
+		boundary[hash] = true
+	}
+	return boundary
+}
+
+// isShallowRepo 报告 dir 是否为浅克隆
+func isShallowRepo(dir string) bool {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "--is-shallow-repository").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// get 返回原始歌词文件的最后修改时间，无法确定时返回零值
+func (lt *lyricTimes) get(rawLyricFile string) time.Time {
+	if t, ok := lt.times[rawLyricFile]; ok {
+		return t
+	}
+	var t time.Time
+	if lt.root == "" {
+		return t
+	}
+	if info, err := os.Stat(filepath.Join(lt.root, "raw-lyrics", rawLyricFile)); err == nil {
+		t = info.ModTime().UTC()
+	}
+	lt.times[rawLyricFile] = t
+	return t
+}
@@ -0,0 +1,39 @@
+package server
+
+// --- 按歌曲分组 ---
+
+// songSource 为某首歌在单个平台索引中的条目
+type songSource struct {
+	Platform string          `json:"platform"`
+	ID       string          `json:"id"`
+	Metadata [][]interface{} `json:"metadata"`
+	Score    float64         `json:"score"`
+}
+
+// songGroup 为 groupBy=song 时的结果格式：每个歌词文件一项，各平台的条目完整保留
+type songGroup struct {
+	RawLyricFile string       `json:"rawLyricFile"`
+	Score        float64      `json:"score"`
+	Entries      []songSource `json:"entries"`
+}
+
+// sourcePlatformIDs 汇总各平台条目的歌曲 ID；同一平台有多个条目时取排在前面的
+func sourcePlatformIDs(sources []songSource) map[string]string {
+	ids := make(map[string]string, len(sources))
+	for _, src := range sources {
+		if _, ok := ids[src.Platform]; !ok {
+			ids[src.Platform] = src.ID
+		}
+	}
+	return ids
+}
+
+// groupBySong 将合并后的结果展开为按歌词文件分组的形式，组内条目已按平台优先级排列
+func groupBySong(results []SearchResult) []songGroup {
+	groups := make([]songGroup, 0, len(results))
+	for _, r := range results {
+		entries := append([]songSource(nil), r.Sources...)
+		groups = append(groups, songGroup{RawLyricFile: r.RawLyricFile, Score: r.Score, Entries: entries})
+	}
+	return groups
+}
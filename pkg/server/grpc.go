@@ -0,0 +1,286 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	searchpb "amlldb-search/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// --- gRPC 接口 ---
+
+// downloadChunkSize 为 Download 流式返回时每个分块的大小
+const downloadChunkSize = 32 * 1024
+
+// grpcServer 实现 proto/search.proto 中定义的 LyricSearch 服务，复用 HTTP 接口的底层逻辑
+type grpcServer struct {
+	searchpb.UnimplementedLyricSearchServer
+}
+
+// newGRPCServer 创建 gRPC 服务，施加与 HTTP 相同的访问控制（见 grpcauth.go），启用 TLS 时使用同一证书
+func newGRPCServer() *grpc.Server {
+	opts := []grpc.ServerOption{grpc.UnaryInterceptor(grpcUnaryAuth), grpc.StreamInterceptor(grpcStreamAuth)}
+	if cfg := serverTLSConfig("grpc"); cfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(cfg)))
+	}
+	s := grpc.NewServer(opts...)
+	searchpb.RegisterLyricSearchServer(s, &grpcServer{})
+	return s
+}
+
+func toPBResult(r SearchResult) *searchpb.SearchResult {
+	res := &searchpb.SearchResult{
+		Id:             r.ID,
+		RawLyricFile:   r.RawLyricFile,
+		Metadata:       toPBMetadata(r.Metadata),
+		Platforms:      r.Platforms,
+		PlatformIds:    r.PlatformIDs,
+		Score:          r.Score,
+		Lang:           r.Lang,
+		HasTranslation: r.HasTranslation,
+		HasRoman:       r.HasRoman,
+		WordTimed:      r.WordTimed,
+		Source:         r.Source,
+	}
+	if !r.Updated.IsZero() {
+		res.Updated = r.Updated.UTC().Format(time.RFC3339)
+	}
+	res.FileSize = r.FileSize
+	if !r.FileMtime.IsZero() {
+		res.FileMtime = r.FileMtime.UTC().Format(time.RFC3339)
+	}
+	return res
+}
+
+// toPBMetadata 将原始 [key, [values...]] 元数据对转换为类型化字段
+func toPBMetadata(raw [][]interface{}) []*searchpb.MetadataField {
+	fields := make([]*searchpb.MetadataField, 0, len(raw))
+	for _, pair := range raw {
+		if len(pair) < 2 {
+			continue
+		}
+		key, ok := pair[0].(string)
+		if !ok {
+			continue
+		}
+		field := &searchpb.MetadataField{Key: key}
+		if values, ok := pair[1].([]interface{}); ok {
+			for _, v := range values {
+				if s, ok := v.(string); ok {
+					field.Values = append(field.Values, s)
+				}
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func (g *grpcServer) search(ctx context.Context, req *searchpb.SearchRequest) (searchOutcome, error) {
+	query := strings.ToLower(strings.TrimSpace(req.GetQuery()))
+	if query == "" {
+		return searchOutcome{}, nil
+	}
+	if err := validateQuery(query, fieldQuery{}); err != nil {
+		return searchOutcome{}, status.Error(codes.InvalidArgument, err.Error())
+	}
+	sources, unknown := parseSources(req.GetSources())
+	if unknown != "" {
+		return searchOutcome{}, status.Error(codes.InvalidArgument, "unknown source: "+unknown)
+	}
+	if !searchInFlight.acquire() {
+		return searchOutcome{}, status.Error(codes.ResourceExhausted, "too many concurrent searches, please retry shortly")
+	}
+	defer searchInFlight.release()
+	// 客户端的 gRPC 截止时间已随 ctx 传入，此处仅施加服务端上限
+	ctx, cancel := context.WithTimeout(ctx, *searchTimeout)
+	defer cancel()
+	outcome, err := runSearch(ctx, query, searchOptions{
+		Platforms: req.GetPlatforms(),
+		Limit:     int(req.GetLimit()),
+		MinScore:  req.GetMinScore(),
+		Sources:   sources,
+		Phonetic:  req.GetPhonetic(),
+	})
+	if errors.Is(err, errSearchTimeout) {
+		return outcome, status.Error(codes.DeadlineExceeded, "search timeout")
+	}
+	return outcome, err
+}
+
+func (g *grpcServer) Search(ctx context.Context, req *searchpb.SearchRequest) (*searchpb.SearchResponse, error) {
+	outcome, err := g.search(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	resp := &searchpb.SearchResponse{
+		Results:   make([]*searchpb.SearchResult, 0, len(outcome.Results)),
+		Truncated: outcome.Truncated,
+		Cached:    outcome.Cached,
+	}
+	for _, r := range outcome.Results {
+		resp.Results = append(resp.Results, toPBResult(r))
+	}
+	return resp, nil
+}
+
+func (g *grpcServer) SearchStream(req *searchpb.SearchRequest, stream grpc.ServerStreamingServer[searchpb.SearchResult]) error {
+	outcome, err := g.search(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+	for _, r := range outcome.Results {
+		if err := stream.Send(toPBResult(r)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *grpcServer) Lookup(ctx context.Context, req *searchpb.LookupRequest) (*searchpb.SearchResult, error) {
+	entry, err := lookupEntry(req.GetPlatform(), req.GetMusicId())
+	switch {
+	case errors.Is(err, errInvalidPlatform):
+		return nil, status.Error(codes.InvalidArgument, "invalid platform")
+	case err != nil:
+		return nil, status.Error(codes.NotFound, "entry not found")
+	}
+	// 与 HTTP 一样返回规范的平台名称，而不是请求中的别名
+	platform := resolvePlatform(req.GetPlatform())
+	return toPBResult(SearchResult{
+		ID:             entry.ID,
+		RawLyricFile:   entry.RawLyricFile,
+		Metadata:       entry.MetadataRaw,
+		Platforms:      []string{platform},
+		PlatformIDs:    map[string]string{platform: entry.ID},
+		Updated:        entry.Updated,
+		Lang:           entry.Lang,
+		HasTranslation: entry.HasTranslation,
+		HasRoman:       entry.HasRoman,
+		WordTimed:      entry.WordTimed,
+		FileSize:       entry.FileSize,
+		FileMtime:      entry.FileMtime,
+		Source:         entrySource(&entry),
+	}), nil
+}
+
+func (g *grpcServer) Download(req *searchpb.DownloadRequest, stream grpc.ServerStreamingServer[searchpb.DownloadChunk]) error {
+	if *noDownload {
+		return status.Error(codes.PermissionDenied, "download API is disabled by server configuration")
+	}
+	if !validDownloadFormat(req.GetFormat()) {
+		return status.Errorf(codes.InvalidArgument, "format must be one of auto, %s", strings.Join(lyricFileFormats, ", "))
+	}
+	// gRPC 请求没有地址，签名通过 expires 与 signature 元数据携带，签名方式与 HTTP 下载地址相同
+	sig := url.Values{}
+	sig.Set("expires", grpcMetadata(stream.Context(), "expires"))
+	sig.Set("signature", grpcMetadata(stream.Context(), "signature"))
+	if err := verifyDownloadSignature(sig, req.GetPlatform(), req.GetMusicId(), req.GetFormat(), req.GetSource()); err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	if !downloadInFlight.acquire() {
+		return status.Error(codes.ResourceExhausted, "too many concurrent downloads, please retry shortly")
+	}
+	defer downloadInFlight.release()
+	source := req.GetSource()
+	resolve := func(format string) (string, error) {
+		return resolveLyricFile(req.GetPlatform(), req.GetMusicId(), format)
+	}
+	switch _, isRepo := findRepo(source); {
+	case isRepo:
+		resolve = func(format string) (string, error) {
+			return resolveRepoLyricFile(source, req.GetPlatform(), req.GetMusicId(), format)
+		}
+	case isLocalOrigin(source):
+		resolve = func(format string) (string, error) {
+			return resolveOriginLyricFile(source, req.GetPlatform(), req.GetMusicId(), format)
+		}
+	}
+	var filePath string
+	var err error
+	if req.GetFormat() == "auto" {
+		filePath, _, err = resolveAutoLyricFile(resolve)
+	} else {
+		filePath, err = resolve(req.GetFormat())
+	}
+	switch {
+	case errors.Is(err, errInvalidPlatform):
+		return status.Error(codes.InvalidArgument, "invalid platform")
+	case err != nil:
+		return status.Error(codes.NotFound, "lyric file not found")
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	defer file.Close()
+
+	buf := make([]byte, downloadChunkSize)
+	for {
+		n, err := file.Read(buf)
+		if n > 0 {
+			if sendErr := stream.Send(&searchpb.DownloadChunk{Data: buf[:n]}); sendErr != nil {
+				return sendErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+	}
+}
+
+func (g *grpcServer) Status(ctx context.Context, req *searchpb.StatusRequest) (*searchpb.StatusResponse, error) {
+	snap := statusSnapshot()
+	resp := &searchpb.StatusResponse{
+		Status:         snap["status"].(string),
+		LastUpdateTime: snap["last_update_time"].(string),
+		TotalEntries:   int64(snap["total_entries"].(int)),
+		PlatformStats:  make(map[string]int64),
+		RepoUrl:        snap["repo_url"].(string),
+		CacheSize:      int64(snap["cache_size"].(int)),
+		DataCommit:     snap["data_commit"].(string),
+		DataCommitTime: snap["data_commit_time"].(string),
+		DataBranch:     snap["data_branch"].(string),
+		StartedAt:      snap["started_at"].(string),
+		UptimeSeconds:  snap["uptime_seconds"].(int64),
+	}
+	for k, v := range snap["platform_stats"].(map[string]int) {
+		resp.PlatformStats[k] = int64(v)
+	}
+	return resp, nil
+}
+
+func (g *grpcServer) Update(ctx context.Context, req *searchpb.UpdateRequest) (*searchpb.UpdateResponse, error) {
+	if *noSync {
+		auditGRPC(ctx, "update", "rejected", "git sync disabled")
+		return nil, status.Error(codes.PermissionDenied, "git sync is disabled by server configuration")
+	}
+	res := triggerManualUpdate()
+	if res.RetryAfter > 0 {
+		auditGRPC(ctx, "update", "rejected", "cooldown")
+		return nil, status.Errorf(codes.ResourceExhausted, "update was triggered recently, retry in %d seconds", retryAfterSeconds(res.RetryAfter))
+	}
+	detail := ""
+	if res.Coalesced {
+		detail = "coalesced with a running sync"
+	}
+	auditGRPC(ctx, "update", updateOutcome(res.Updated), detail)
+	if res.Updated {
+		return &searchpb.UpdateResponse{Updated: true, Message: "Update successful and metadata reloaded"}, nil
+	}
+	return &searchpb.UpdateResponse{Message: "Already up to date"}, nil
+}
@@ -0,0 +1,22 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	searchpb "amlldb-search/proto"
+)
+
+func TestGRPCLookupReturnsCanonicalPlatform(t *testing.T) {
+	setupReloadFixture(t, 10)
+	res, err := (&grpcServer{}).Lookup(context.Background(), &searchpb.LookupRequest{Platform: "netease", MusicId: "1000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := res.GetPlatforms(); len(got) != 1 || got[0] != "ncm" {
+		t.Fatalf("platforms = %v, want [ncm]", got)
+	}
+	if _, ok := res.GetPlatformIds()["ncm"]; !ok {
+		t.Fatalf("platformIds = %v, want an ncm key", res.GetPlatformIds())
+	}
+}
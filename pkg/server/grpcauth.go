@@ -0,0 +1,91 @@
+package server
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	searchpb "amlldb-search/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	grpcpeer "google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// --- gRPC 访问控制 ---
+
+// grpcAdminMethods 为会修改服务状态的 RPC；gRPC 只有一个端口，指定 -admin-addr 时这些方法要求管理令牌，
+// 使公开端口保持只读
+var grpcAdminMethods = []string{searchpb.LyricSearch_Update_FullMethodName}
+
+// grpcAPIKeyMethods 为与 HTTP 公开数据接口对应、配置了 -api-keys 时要求 API 密钥的 RPC
+var grpcAPIKeyMethods = []string{
+	searchpb.LyricSearch_Search_FullMethodName,
+	searchpb.LyricSearch_SearchStream_FullMethodName,
+	searchpb.LyricSearch_Lookup_FullMethodName,
+	searchpb.LyricSearch_Download_FullMethodName,
+}
+
+// grpcMetadata 返回请求元数据中 key 的第一个值
+func grpcMetadata(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// grpcAdminToken 报告请求是否通过 authorization 元数据携带了有效的管理令牌
+func grpcAdminToken(ctx context.Context) bool {
+	token, ok := strings.CutPrefix(grpcMetadata(ctx, "authorization"), "Bearer ")
+	return ok && validAdminToken(token)
+}
+
+// grpcVerifiedClientCert 报告请求是否出示了经 -client-ca 校验的客户端证书
+func grpcVerifiedClientCert(ctx context.Context) bool {
+	p, ok := grpcpeer.FromContext(ctx)
+	if !ok {
+		return false
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	return ok && len(info.State.VerifiedChains) > 0
+}
+
+// authorizeGRPC 对 method 施加与 HTTP 路由相同的访问控制；API 密钥通过 x-api-key 元数据携带，
+// 携带有效管理令牌或经校验的客户端证书的请求不受密钥与配额限制
+func authorizeGRPC(ctx context.Context, method string) error {
+	if slices.Contains(grpcAdminMethods, method) && *adminAddr != "" {
+		if *adminToken == "" {
+			return status.Error(codes.PermissionDenied, "admin RPCs are disabled on the public gRPC port (no -admin-token configured)")
+		}
+		if !grpcAdminToken(ctx) {
+			return status.Error(codes.Unauthenticated, "missing or invalid admin token")
+		}
+	}
+	if slices.Contains(grpcAPIKeyMethods, method) && len(apiKeys) > 0 && !grpcAdminToken(ctx) && !grpcVerifiedClientCert(ctx) {
+		switch wait, err := checkAPIKey(grpcMetadata(ctx, "x-api-key")); err {
+		case errAPIKeyInvalid:
+			return status.Error(codes.Unauthenticated, "missing or invalid API key")
+		case errQuotaExceeded:
+			return status.Errorf(codes.ResourceExhausted, "API key quota exceeded, retry in %d seconds", retryAfterSeconds(wait))
+		}
+	}
+	return nil
+}
+
+func grpcUnaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := authorizeGRPC(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func grpcStreamAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := authorizeGRPC(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
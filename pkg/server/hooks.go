@@ -0,0 +1,76 @@
+package server
+
+import (
+	"net/http"
+	"sync"
+)
+
+// --- 嵌入时的事件回调 ---
+
+// ReloadEvent 为索引重新加载完成时传给 OnReload 回调的信息
+type ReloadEvent struct {
+	Root     string // 数据目录（副本模式下为主实例地址）
+	Platform string // 通过 /api/reindex 重建单个平台时为该平台，完整加载时为空
+	Entries  int    // 完整加载时为条目总数，单平台重建时为该平台的条目数
+}
+
+// SearchEvent 为一次 HTTP 搜索成功返回时传给 OnSearch 回调的信息
+type SearchEvent struct {
+	Request *http.Request
+	Query   string // 全文搜索的查询，结构化搜索为其字段组合（如 "title:晴天 artists:周杰伦"），均为小写
+	Results int    // 分页前的结果总数
+	Cached  bool   // 结果是否来自查询缓存
+}
+
+// DownloadEvent 为一次歌词下载（不含 HEAD 请求）传给 OnDownload 回调的信息
+type DownloadEvent struct {
+	Request  *http.Request
+	Platform string // 规范平台名
+	MusicID  string
+	Format   string // 请求的 format 参数，如 ttml、lrc 或 auto，未指定时为空
+}
+
+var (
+	hooksMu       sync.RWMutex
+	reloadHooks   []func(ReloadEvent)
+	searchHooks   []func(SearchEvent)
+	downloadHooks []func(DownloadEvent)
+)
+
+// OnReload 注册索引重新加载（含单平台重建）完成后的回调
+func OnReload(fn func(ReloadEvent)) Option {
+	return func(*Server) { addHook(&reloadHooks, fn) }
+}
+
+// OnSearch 注册 HTTP 搜索成功返回前的回调，可用于计费或审计
+func OnSearch(fn func(SearchEvent)) Option {
+	return func(*Server) { addHook(&searchHooks, fn) }
+}
+
+// OnDownload 注册 HTTP 歌词下载找到文件后、写出响应前的回调
+func OnDownload(fn func(DownloadEvent)) Option {
+	return func(*Server) { addHook(&downloadHooks, fn) }
+}
+
+func addHook[T any](hooks *[]func(T), fn func(T)) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	*hooks = append(*hooks, fn)
+}
+
+// clearHooks 撤销全部回调，New 失败时调用
+func clearHooks() {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	reloadHooks, searchHooks, downloadHooks = nil, nil, nil
+}
+
+// runHooks 按注册顺序同步调用回调；回调在请求或加载的 goroutine 中执行，应尽快返回并自行保证并发安全
+func runHooks[T any](hooks *[]func(T), ev T) {
+	hooksMu.RLock()
+	list := *hooks
+	hooksMu.RUnlock()
+	for _, fn := range list {
+		fn(ev)
+	}
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --- 错误信息本地化 ---
+
+// 错误响应 message 支持的语言，Accept-Language 未指定或不受支持时使用英文；code 不随语言变化
+const (
+	langEN = "en"
+	langZH = "zh"
+)
+
+// errorMessagesZH 为固定错误信息的中文翻译，键为英文原文
+var errorMessagesZH = map[string]string{
+	"API key quota exceeded":                                 "API 密钥的配额已用完",
+	"Admin API is disabled (no -admin-token configured)":     "管理接口未启用（未配置 -admin-token）",
+	"Download API is disabled by server configuration":       "服务器配置已禁用下载接口",
+	"Entry not found":                                        "未找到该条目",
+	"Failed to encode response":                              "响应编码失败",
+	"Fallback source unavailable":                            "后备数据源不可用",
+	"Git sync is disabled by server configuration":           "服务器配置已禁用 Git 同步",
+	"Internal server error":                                  "服务器内部错误",
+	"Invalid cursor":                                         "无效的分页游标",
+	"Invalid platform":                                       "无效的平台",
+	"Lyric body exceeds the 5 MB limit":                      "歌词内容超过 5 MB 上限",
+	"Lyric file not found":                                   "未找到歌词文件",
+	"Missing or invalid API key":                             "缺少 API 密钥或密钥无效",
+	"Missing or invalid admin token":                         "缺少管理令牌或令牌无效",
+	"No valid data directory found":                          "未找到有效的数据目录",
+	"Only lrc is available from the fallback source":         "后备数据源只提供 lrc 格式",
+	"POST a lyric body or specify platform and musicId":      "请以 POST 提交歌词内容，或指定 platform 与 musicId",
+	"Search timeout":                                         "搜索超时",
+	"Streaming unsupported":                                  "不支持流式响应",
+	"Too many concurrent requests, please retry shortly":     "同时处理的请求过多，请稍后重试",
+	"Translation merge is not supported for peer sources":    "来自对等实例的结果不支持合并翻译",
+	"Unknown API key name":                                   "未知的 API 密钥名称",
+	"Unknown source":                                         "未知的数据源",
+	"hasRoman must be true or false":                         "hasRoman 必须为 true 或 false",
+	"hasTranslation must be true or false":                   "hasTranslation 必须为 true 或 false",
+	"limit must be a non-negative integer":                   "limit 必须为非负整数",
+	"minScore must be a number":                              "minScore 必须为数字",
+	"minScore must be between 0 and 1":                       "minScore 必须介于 0 与 1 之间",
+	"mode must be gc or reclone":                             "mode 必须为 gc 或 reclone",
+	"musicId requires platform":                              "指定 musicId 时必须同时指定 platform",
+	"phonetic must be true or false":                         "phonetic 必须为 true 或 false",
+	"platform is required":                                   "缺少 platform 参数",
+	"query cannot be combined with title/artists/album":      "query 不能与 title、artists、album 同时使用",
+	"sort must be one of score, updated, wordTimed":          "sort 必须为 score、updated、wordTimed 之一",
+	"wordTimed must be true or false":                        "wordTimed 必须为 true 或 false",
+	"groupBy must be \"song\"":                               "groupBy 只能为 \"song\"",
+	"dedupe must be \"content\"":                             "dedupe 只能为 \"content\"",
+	"Download requires a signed URL (expires and signature)": "下载需要带签名的地址（expires 与 signature 参数）",
+	"Invalid download signature":                             "下载签名无效",
+	"Download URL has expired":                               "下载地址已过期",
+	"scale must be a positive number":                        "scale 必须为正数",
+	"minGap must not be negative":                            "minGap 不能为负数",
+	"offset must be an integer number of milliseconds":       "offset 必须为整数毫秒",
+	"clamp must be true or false":                            "clamp 必须为 true 或 false",
+	"minGap must be an integer number of milliseconds":       "minGap 必须为整数毫秒",
+	"request body is empty":                                  "请求体为空",
+	"request body is truncated JSON":                         "请求体中的 JSON 不完整",
+}
+
+// errorPatternsZH 翻译带有动态内容的错误信息，译文中的 $1 等为原文中的对应部分（如参数值、原始错误）
+var errorPatternsZH = []struct {
+	re *regexp.Regexp
+	zh string
+}{
+	{regexp.MustCompile(`^Invalid request body: (.*)$`), "请求体无效：$1"},
+	{regexp.MustCompile(`^Cannot convert lyric: (.*)$`), "无法转换歌词：$1"},
+	{regexp.MustCompile(`^Cannot parse lyric: (.*)$`), "无法解析歌词：$1"},
+	{regexp.MustCompile(`^Prune failed: (.*)$`), "清理失败：$1"},
+	{regexp.MustCompile(`^Failed to reload index: (.*)$`), "重新加载索引失败：$1"},
+	{regexp.MustCompile(`^Unknown source: (.*)$`), "未知的数据源：$1"},
+	{regexp.MustCompile(`^Method (\S+) not allowed, use (.*)$`), "不支持 $1 方法，请使用 $2"},
+	{regexp.MustCompile(`^Update was triggered recently, retry in (\d+) seconds$`), "最近已触发过更新，请在 $1 秒后重试"},
+	{regexp.MustCompile(`^Conversion options are not supported for (\S+) lyrics$`), "$1 格式的歌词不支持转换选项"},
+	{regexp.MustCompile(`^format must be one of auto, (.*)$`), "format 必须为 auto 或 $1 之一"},
+	{regexp.MustCompile(`^format must be auto or one of (.*)$`), "format 必须为 auto 或 $1 之一"},
+	{regexp.MustCompile(`^lang must be one of (.*)$`), "lang 必须为 $1 之一"},
+	{regexp.MustCompile(`^layer must be one of (.*)$`), "layer 必须为 $1 之一"},
+	{regexp.MustCompile(`^query is too long \((\d+) characters, maximum (\d+)\)$`), "查询词过长（$1 个字符，最多 $2 个）"},
+	{regexp.MustCompile(`^query is too short \((\d+) characters, minimum (\d+)\)$`), "查询词过短（$1 个字符，至少 $2 个）"},
+	{regexp.MustCompile(`^too many artists \((\d+), maximum (\d+)\)$`), "艺术家过多（$1 个，最多 $2 个）"},
+	{regexp.MustCompile(`^title, artist or album is too long \((\d+) characters, maximum (\d+)\)$`), "标题、艺术家或专辑过长（$1 个字符，最多 $2 个）"},
+	{regexp.MustCompile(`^title, artists and album are all too short \(minimum (\d+) characters\)$`), "标题、艺术家与专辑均过短（至少 $1 个字符）"},
+}
+
+// requestLanguage 按 Accept-Language（含 q 权重）选出错误信息的语言，zh、zh-CN、zh-TW 等均视为中文
+func requestLanguage(r *http.Request) string {
+	best, bestQ := langEN, 0.0
+	for _, part := range strings.Split(r.Header.Get("Accept-Language"), ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				q = f
+			}
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if (primary == langZH || primary == langEN) && q > bestQ {
+			best, bestQ = primary, q
+		}
+	}
+	return best
+}
+
+// localizeMessage 将错误信息翻译为 lang 对应的语言，没有译文时保持英文原文
+func localizeMessage(lang, message string) string {
+	if lang != langZH {
+		return message
+	}
+	if zh, ok := errorMessagesZH[message]; ok {
+		return zh
+	}
+	for _, p := range errorPatternsZH {
+		if p.re.MatchString(message) {
+			return p.re.ReplaceAllString(message, p.zh)
+		}
+	}
+	return message
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// --- 日志级别 ---
+
+// logLevel 为日志级别，数值越大越重要
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var logLevelNames = []string{"debug", "info", "warn", "error"}
+
+var (
+	// 命令行参数（-log-level 见 registerDataFlags，-quiet 见 registerServeFlags）
+	minLogLevel = levelInfo // 低于该级别的日志被丢弃
+	quiet       = new(bool) // 不记录每个 HTTP 请求的访问日志
+)
+
+func (l *logLevel) String() string {
+	if *l < levelDebug || int(*l) >= len(logLevelNames) {
+		return ""
+	}
+	return logLevelNames[*l]
+}
+
+func (l *logLevel) Set(v string) error {
+	i := slices.Index(logLevelNames, strings.ToLower(strings.TrimSpace(v)))
+	if i < 0 {
+		return fmt.Errorf("invalid log level %q, expected one of %s", v, strings.Join(logLevelNames, ", "))
+	}
+	*l = logLevel(i)
+	return nil
+}
+
+// logf 在级别不低于 -log-level 时写入一行日志
+func logf(level logLevel, format string, args ...interface{}) {
+	if level < minLogLevel {
+		return
+	}
+	log.Output(3, fmt.Sprintf(format, args...))
+}
+
+// debugEnabled 报告是否记录调试日志，用于跳过只为调试日志准备数据的代价
+func debugEnabled() bool { return minLogLevel <= levelDebug }
+
+func logDebugf(format string, args ...interface{}) { logf(levelDebug, format, args...) }
+func logInfof(format string, args ...interface{})  { logf(levelInfo, format, args...) }
+func logWarnf(format string, args ...interface{})  { logf(levelWarn, format, args...) }
+func logErrorf(format string, args ...interface{}) { logf(levelError, format, args...) }
+
+// logCommandOutput 在调试级别下逐行记录外部命令（如 git）的输出
+func logCommandOutput(name string, out []byte) {
+	if !debugEnabled() {
+		return
+	}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			logDebugf("%s: %s", name, line)
+		}
+	}
+}
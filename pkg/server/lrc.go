@@ -0,0 +1,207 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// --- LRC 解析 ---
+
+var (
+	// lrcTagPattern 匹配行首的 [xx] 标签
+	lrcTagPattern = regexp.MustCompile(`^\[([^\[\]]*)\]`)
+	// lrcWordTagPattern 匹配行内的逐字时间标签：A2 扩展的 <mm:ss.xx> 或 ESLyric 的 [mm:ss.xx]
+	lrcWordTagPattern = regexp.MustCompile(`<(\d+:\d+(?:[.:]\d+)?)>|\[(\d+:\d+(?:[.:]\d+)?)\]`)
+)
+
+// lrcMetadataKeys 将 LRC 的 ID 标签映射为与 TTML 一致的元数据键
+var lrcMetadataKeys = map[string]string{
+	"ti": "musicName",
+	"ar": "artists",
+	"al": "album",
+}
+
+// parseLRCTime 解析 LRC 时间标签（mm:ss、mm:ss.xx、mm:ss.xxx 或 mm:ss:xx），返回毫秒
+func parseLRCTime(s string) (int64, error) {
+	mins, rest, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid LRC time %q", s)
+	}
+	m, err := strconv.ParseInt(mins, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LRC time %q", s)
+	}
+	sec, frac, _ := strings.Cut(strings.Replace(rest, ":", ".", 1), ".")
+	sv, err := strconv.ParseInt(sec, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid LRC time %q", s)
+	}
+	ms := m*60000 + sv*1000
+	if frac != "" {
+		f, err := strconv.ParseInt(frac, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid LRC time %q", s)
+		}
+		// 按位数换算：.5 为 500ms，.05 为 50ms，.005 为 5ms
+		for i := len(frac); i < 3; i++ {
+			f *= 10
+		}
+		for i := len(frac); i > 3; i-- {
+			f /= 10
+		}
+		ms += f
+	}
+	return ms, nil
+}
+
+// parseLRCWords 解析带逐字时间标签的行文本，lineStart 为行首时间；
+// 没有逐字标签时返回 nil。最后一个标签之后没有文本时作为最后一个词的结束时间
+func parseLRCWords(text string, lineStart int64) []LyricWord {
+	locs := lrcWordTagPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(locs) == 0 {
+		return nil
+	}
+	var words []LyricWord
+	start := lineStart
+	prev := 0
+	for _, loc := range locs {
+		var tag string
+		if loc[2] >= 0 {
+			tag = text[loc[2]:loc[3]]
+		} else {
+			tag = text[loc[4]:loc[5]]
+		}
+		t, err := parseLRCTime(tag)
+		if err != nil {
+			continue
+		}
+		if seg := text[prev:loc[0]]; seg != "" {
+			words = append(words, LyricWord{Start: start, End: t, Text: seg})
+		} else if n := len(words); n > 0 && words[n-1].End == words[n-1].Start {
+			words[n-1].End = t
+		}
+		start = t
+		prev = loc[1]
+	}
+	if seg := text[prev:]; seg != "" {
+		// 末尾没有结束标签的词暂以起始时间为结束，稍后以下一行的开始时间补全
+		words = append(words, LyricWord{Start: start, End: start, Text: seg})
+	}
+	return words
+}
+
+// parseLRC 解析 LRC 歌词，支持多时间标签行、[offset:] 偏移、A2 扩展与 ESLyric 的逐字时间标签；
+// 与上一行时间相同的行视为其翻译（网易云等平台导出的双语 LRC 即为此格式）
+func parseLRC(data []byte) (*Lyric, error) {
+	lyric := &Lyric{Metadata: make(map[string][]string)}
+	var offset int64
+
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		var times []int64
+		for {
+			m := lrcTagPattern.FindStringSubmatch(line)
+			if m == nil {
+				break
+			}
+			line = line[len(m[0]):]
+			if t, err := parseLRCTime(m[1]); err == nil {
+				times = append(times, t)
+				continue
+			}
+			// ID 标签，如 [ti:标题]、[offset:+500]
+			key, value, ok := strings.Cut(m[1], ":")
+			if !ok {
+				continue
+			}
+			key, value = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(value)
+			if key == "offset" {
+				offset, _ = strconv.ParseInt(strings.TrimPrefix(value, "+"), 10, 64)
+			} else if value != "" {
+				if k, ok := lrcMetadataKeys[key]; ok {
+					key = k
+				}
+				lyric.Metadata[key] = append(lyric.Metadata[key], value)
+			}
+		}
+		for _, t := range times {
+			l := LyricLine{Start: t, Words: parseLRCWords(line, t)}
+			if l.Words == nil {
+				l.Text = line
+			}
+			lyric.Lines = append(lyric.Lines, l)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid LRC: %w", err)
+	}
+
+	sort.SliceStable(lyric.Lines, func(i, j int) bool { return lyric.Lines[i].Start < lyric.Lines[j].Start })
+	lyric.Lines = mergeLRCTranslations(lyric.Lines)
+	finishLRCLines(lyric.Lines, offset)
+	finishLines(lyric)
+	lyric.Lines = dropEmptyLines(lyric.Lines)
+	if len(lyric.Lines) == 0 {
+		return nil, errEmptyLyric
+	}
+	return lyric, nil
+}
+
+// mergeLRCTranslations 将与前一行开始时间相同的逐行文本并入前一行作为翻译
+func mergeLRCTranslations(lines []LyricLine) []LyricLine {
+	merged := lines[:0]
+	for _, l := range lines {
+		if n := len(merged); n > 0 && merged[n-1].Start == l.Start && l.Words == nil && merged[n-1].Translation == "" &&
+			strings.TrimSpace(l.Text) != "" && (merged[n-1].Words != nil || strings.TrimSpace(merged[n-1].Text) != "") {
+			merged[n-1].Translation = l.Text
+			continue
+		}
+		merged = append(merged, l)
+	}
+	return merged
+}
+
+// finishLRCLines 应用 [offset:]（正值表示歌词提前显示），并以下一行的开始时间补全行与末尾词的结束时间
+func finishLRCLines(lines []LyricLine, offset int64) {
+	for i := range lines {
+		l := &lines[i]
+		l.Start -= offset
+		for j := range l.Words {
+			l.Words[j].Start -= offset
+			l.Words[j].End -= offset
+		}
+	}
+	for i := range lines {
+		l := &lines[i]
+		next := l.Start
+		if i+1 < len(lines) {
+			next = lines[i+1].Start
+		}
+		if n := len(l.Words); n > 0 {
+			if l.Words[n-1].End == l.Words[n-1].Start {
+				l.Words[n-1].End = max(next, l.Words[n-1].Start)
+			}
+			l.End = l.Words[n-1].End
+		} else {
+			l.End = max(next, l.Start)
+		}
+	}
+}
+
+// dropEmptyLines 去掉没有文本的行（LRC 中常用空行标记上一句的结束）
+func dropEmptyLines(lines []LyricLine) []LyricLine {
+	kept := lines[:0]
+	for _, l := range lines {
+		if l.Text != "" {
+			kept = append(kept, l)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,345 @@
+package server
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// --- 歌词模型与 TTML 解析、输出 ---
+
+// LyricWord 是带时间信息的单个词，时间单位均为毫秒
+type LyricWord struct {
+	Start int64       `json:"start"`
+	End   int64       `json:"end"`
+	Text  string      `json:"text"`
+	Ruby  []LyricRuby `json:"ruby,omitempty"` // 词中各注音（振假名）片段，按出现顺序
+}
+
+// LyricLine 是一行歌词，可附带逐字信息、翻译与音译
+type LyricLine struct {
+	Start        int64       `json:"start"`
+	End          int64       `json:"end"`
+	Text         string      `json:"text"`
+	Words        []LyricWord `json:"words,omitempty"`
+	Translation  string      `json:"translation,omitempty"`
+	Roman        string      `json:"roman,omitempty"`
+	Reading      string      `json:"reading,omitempty"` // 以注音替换被注音文字后的读法，仅含注音的行有值
+	Agent        string      `json:"agent,omitempty"`
+	IsBackground bool        `json:"isBackground,omitempty"`
+}
+
+// Lyric 是各格式歌词解析后的统一表示
+type Lyric struct {
+	Metadata map[string][]string `json:"metadata,omitempty"`
+	Lines    []LyricLine         `json:"lines"`
+}
+
+const (
+	ttmlMetadataNS = "http://www.w3.org/ns/ttml#metadata"
+	ttmlStylingNS  = "http://www.w3.org/ns/ttml#styling"
+	ttmlXMLNS      = "http://www.w3.org/XML/1998/namespace"
+)
+
+var errEmptyLyric = errors.New("lyric contains no lines")
+
+// parseTTMLTime 解析 TTML 时间表达式（如 "01:02.345"、"1:02:03.4"、"12.5s"、"300ms"），返回毫秒
+func parseTTMLTime(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("empty time expression")
+	}
+
+	switch {
+	case strings.HasSuffix(s, "ms"):
+		v, err := strconv.ParseFloat(strings.TrimSuffix(s, "ms"), 64)
+		return int64(v + 0.5), err
+	case strings.HasSuffix(s, "s"):
+		v, err := strconv.ParseFloat(strings.TrimSuffix(s, "s"), 64)
+		return int64(v*1000 + 0.5), err
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid time expression %q", s)
+	}
+	var total float64
+	for _, p := range parts {
+		v, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time expression %q", s)
+		}
+		total = total*60 + v
+	}
+	return int64(total*1000 + 0.5), nil
+}
+
+func xmlAttr(el xml.StartElement, space, local string) string {
+	for _, a := range el.Attr {
+		if a.Name.Local == local && (space == "" || a.Name.Space == space) {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// parseTTML 将 AMLL TTML 文档解析为统一的歌词模型，
+// 识别逐字 span、翻译（x-translation）、音译（x-roman）、背景人声（x-bg）与 TTML2 注音（tts:ruby）
+func parseTTML(data []byte) (*Lyric, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	lyric := &Lyric{Metadata: make(map[string][]string)}
+
+	// 每层元素对应的文本去向
+	type frame struct {
+		kind string // p, word, ruby-base, ruby-text, translation, roman, bg, other
+		line int    // 所属行在 lyric.Lines 中的下标
+	}
+	var stack []frame
+	current := func() frame {
+		if len(stack) == 0 {
+			return frame{kind: "other", line: -1}
+		}
+		return stack[len(stack)-1]
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid TTML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			parent := current()
+			switch {
+			case t.Name.Local == "meta":
+				if key := xmlAttr(t, "", "key"); key != "" {
+					lyric.Metadata[key] = append(lyric.Metadata[key], xmlAttr(t, "", "value"))
+				}
+				stack = append(stack, frame{kind: "other", line: parent.line})
+
+			case t.Name.Local == "p":
+				line := LyricLine{Agent: xmlAttr(t, ttmlMetadataNS, "agent")}
+				line.Start, _ = parseTTMLTime(xmlAttr(t, "", "begin"))
+				line.End, _ = parseTTMLTime(xmlAttr(t, "", "end"))
+				lyric.Lines = append(lyric.Lines, line)
+				stack = append(stack, frame{kind: "p", line: len(lyric.Lines) - 1})
+
+			case t.Name.Local == "span" && (parent.kind == "word" || parent.kind == "ruby-base" || parent.kind == "ruby-text") && xmlAttr(t, ttmlStylingNS, "ruby") != "":
+				// 词内的注音结构：container、baseContainer、textContainer 透明，base 与 text 分别记录被注音文字与读法；
+				// 不在逐字 span 内的 tts:ruby（如翻译 span 中或没有逐字的行）没有可挂靠的词，按普通 span 处理
+				kind := "word"
+				switch xmlAttr(t, ttmlStylingNS, "ruby") {
+				case "base":
+					kind = "ruby-base"
+					word := &lyric.Lines[parent.line].Words[len(lyric.Lines[parent.line].Words)-1]
+					word.Ruby = append(word.Ruby, LyricRuby{})
+				case "text":
+					kind = "ruby-text"
+				case "delimiter":
+					kind = "other"
+				}
+				stack = append(stack, frame{kind: kind, line: parent.line})
+
+			case t.Name.Local == "span" && parent.line >= 0:
+				switch xmlAttr(t, ttmlMetadataNS, "role") {
+				case "x-translation":
+					stack = append(stack, frame{kind: "translation", line: parent.line})
+				case "x-roman":
+					stack = append(stack, frame{kind: "roman", line: parent.line})
+				case "x-bg":
+					bg := LyricLine{Agent: lyric.Lines[parent.line].Agent, IsBackground: true}
+					lyric.Lines = append(lyric.Lines, bg)
+					stack = append(stack, frame{kind: "bg", line: len(lyric.Lines) - 1})
+				default:
+					word := LyricWord{}
+					word.Start, _ = parseTTMLTime(xmlAttr(t, "", "begin"))
+					word.End, _ = parseTTMLTime(xmlAttr(t, "", "end"))
+					line := &lyric.Lines[parent.line]
+					line.Words = append(line.Words, word)
+					stack = append(stack, frame{kind: "word", line: parent.line})
+				}
+
+			default:
+				stack = append(stack, frame{kind: "other", line: parent.line})
+			}
+
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+
+		case xml.CharData:
+			f := current()
+			if f.line < 0 {
+				continue
+			}
+			line := &lyric.Lines[f.line]
+			text := string(t)
+			switch f.kind {
+			case "translation":
+				line.Translation += text
+			case "roman":
+				line.Roman += text
+			case "word":
+				line.Words[len(line.Words)-1].Text += text
+			case "ruby-base":
+				word := &line.Words[len(line.Words)-1]
+				word.Text += text
+				if len(word.Ruby) > 0 {
+					word.Ruby[len(word.Ruby)-1].Base += text
+				}
+			case "ruby-text":
+				if word := &line.Words[len(line.Words)-1]; len(word.Ruby) > 0 {
+					word.Ruby[len(word.Ruby)-1].Text += text
+				}
+			case "p", "bg":
+				// 逐字行中 span 之间的空白归入前一个词；逐行歌词的文本直接写入行
+				if len(line.Words) > 0 {
+					if strings.TrimSpace(text) == "" {
+						line.Words[len(line.Words)-1].Text += text
+					} else {
+						line.Words = append(line.Words, LyricWord{Start: line.Start, End: line.End, Text: text})
+					}
+				} else {
+					line.Text += text
+				}
+			}
+		}
+	}
+
+	finishLines(lyric)
+	if len(lyric.Lines) == 0 {
+		return nil, errEmptyLyric
+	}
+	return lyric, nil
+}
+
+// finishLines 根据逐字信息补全行文本与时间，并清理首尾空白
+func finishLines(lyric *Lyric) {
+	for i := range lyric.Lines {
+		line := &lyric.Lines[i]
+		if len(line.Words) > 0 {
+			var sb strings.Builder
+			for _, w := range line.Words {
+				sb.WriteString(w.Text)
+			}
+			line.Text = sb.String()
+			if line.Start == 0 && line.End == 0 {
+				line.Start = line.Words[0].Start
+				line.End = line.Words[len(line.Words)-1].End
+			}
+		}
+		line.Text = strings.TrimSpace(line.Text)
+		line.Translation = strings.TrimSpace(line.Translation)
+		line.Roman = strings.TrimSpace(line.Roman)
+		line.Reading = strings.TrimSpace(lineReading(*line))
+	}
+}
+
+// formatTTMLTime 将毫秒格式化为 TTML 时间表达式 mm:ss.mmm，超过一小时时为 h:mm:ss.mmm
+func formatTTMLTime(ms int64) string {
+	if ms < 0 {
+		ms = 0
+	}
+	if ms >= 3600000 {
+		return fmt.Sprintf("%d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+	}
+	return fmt.Sprintf("%02d:%02d.%03d", ms/60000, ms/1000%60, ms%1000)
+}
+
+// renderTTML 将歌词模型输出为 AMLL TTML；背景人声行作为 x-bg span 并入前一个主歌词行，
+// 翻译与音译不带 xml:lang（模型中不保留语言）
+func renderTTML(lyric *Lyric) string {
+	var sb strings.Builder
+	text := func(s string) {
+		xml.EscapeText(&sb, []byte(s))
+	}
+	attr := func(name, value string) {
+		sb.WriteString(" " + name + `="`)
+		text(value)
+		sb.WriteByte('"')
+	}
+	content := func(line LyricLine) {
+		if len(line.Words) == 0 {
+			text(line.Text)
+		}
+		for _, w := range line.Words {
+			fmt.Fprintf(&sb, `<span begin="%s" end="%s">`, formatTTMLTime(w.Start), formatTTMLTime(w.End))
+			for _, seg := range rubySegments(w) {
+				if seg.Ruby == "" {
+					text(seg.Text)
+					continue
+				}
+				sb.WriteString(`<span tts:ruby="container"><span tts:ruby="base">`)
+				text(seg.Text)
+				sb.WriteString(`</span><span tts:ruby="text">`)
+				text(seg.Ruby)
+				sb.WriteString("</span></span>")
+			}
+			sb.WriteString("</span>")
+		}
+		if line.Translation != "" {
+			sb.WriteString(`<span ttm:role="x-translation">`)
+			text(line.Translation)
+			sb.WriteString("</span>")
+		}
+		if line.Roman != "" {
+			sb.WriteString(`<span ttm:role="x-roman">`)
+			text(line.Roman)
+			sb.WriteString("</span>")
+		}
+	}
+
+	var agents []string
+	var end int64
+	for _, l := range lyric.Lines {
+		if l.Agent != "" && !slices.Contains(agents, l.Agent) {
+			agents = append(agents, l.Agent)
+		}
+		end = max(end, l.End)
+	}
+	sb.WriteString(`<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttm="` + ttmlMetadataNS + `" xmlns:tts="` + ttmlStylingNS + `" xmlns:amll="http://www.example.com/ns/amll"><head><metadata>`)
+	for _, a := range agents {
+		sb.WriteString(`<ttm:agent type="person"`)
+		attr("xml:id", a)
+		sb.WriteString("/>")
+	}
+	for _, key := range slices.Sorted(maps.Keys(lyric.Metadata)) {
+		for _, v := range lyric.Metadata[key] {
+			sb.WriteString("<amll:meta")
+			attr("key", key)
+			attr("value", v)
+			sb.WriteString("/>")
+		}
+	}
+	fmt.Fprintf(&sb, `</metadata></head><body dur="%s"><div>`, formatTTMLTime(end))
+	for i := 0; i < len(lyric.Lines); i++ {
+		line := lyric.Lines[i]
+		fmt.Fprintf(&sb, `<p begin="%s" end="%s"`, formatTTMLTime(line.Start), formatTTMLTime(line.End))
+		if line.Agent != "" {
+			attr("ttm:agent", line.Agent)
+		}
+		sb.WriteByte('>')
+		content(line)
+		for i+1 < len(lyric.Lines) && lyric.Lines[i+1].IsBackground {
+			i++
+			bg := lyric.Lines[i]
+			fmt.Fprintf(&sb, `<span ttm:role="x-bg" begin="%s" end="%s">`, formatTTMLTime(bg.Start), formatTTMLTime(bg.End))
+			content(bg)
+			sb.WriteString("</span>")
+		}
+		sb.WriteString("</p>")
+	}
+	sb.WriteString("</div></body></tt>")
+	return sb.String()
+}
@@ -0,0 +1,32 @@
+package server
+
+import "testing"
+
+const testTTMLHead = `<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttm="http://www.w3.org/ns/ttml#metadata" xmlns:tts="http://www.w3.org/ns/ttml#styling"><body><div>`
+
+// 不在逐字 span 内的 tts:ruby 没有可挂靠的词，应按普通 span 处理而不是越界 panic
+func TestParseTTMLRubyOutsideWord(t *testing.T) {
+	for name, p := range map[string]string{
+		"ruby base in translation": `<p begin="00:01.000" end="00:02.000">abc<span ttm:role="x-translation"><span tts:ruby="base">x</span></span></p>`,
+		"ruby text in roman":       `<p begin="00:01.000" end="00:02.000">abc<span ttm:role="x-roman"><span tts:ruby="text">x</span></span></p>`,
+		"container in translation": `<p begin="00:01.000" end="00:02.000">abc<span ttm:role="x-translation"><span tts:ruby="container"><span tts:ruby="base">漢</span><span tts:ruby="text">かん</span></span></span></p>`,
+		"ruby in wrapper element":  `<p begin="00:01.000" end="00:02.000"><metadata><span tts:ruby="base">x</span></metadata></p>`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseTTML([]byte(testTTMLHead + p + `</div></body></tt>`)); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestParseTTMLRubyInWord(t *testing.T) {
+	lyric, err := parseTTML([]byte(testTTMLHead + `<p begin="00:01.000" end="00:02.000"><span begin="00:01.000" end="00:02.000"><span tts:ruby="container"><span tts:ruby="base">漢</span><span tts:ruby="text">かん</span></span></span></p></div></body></tt>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	words := lyric.Lines[0].Words
+	if len(words) != 1 || words[0].Text != "漢" || len(words[0].Ruby) != 1 || words[0].Ruby[0].Base != "漢" || words[0].Ruby[0].Text != "かん" {
+		t.Fatalf("words = %+v, want 漢 with ruby かん", words)
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// --- 歌词正文索引与 BM25 排序 ---
+
+var indexLyrics = new(bool) // 加载时为歌词正文建立词频索引，全文搜索同时匹配歌词内容
+
+// BM25 参数及歌词正文命中的评分映射：仅正文命中的条目评分为 contentScoreMax*s/(s+contentScoreHalf)，
+// s 为 BM25 值，因此默认权重下始终低于 contentScoreMax，排在歌名、歌手等元数据命中的高覆盖率结果之后
+const (
+	bm25K1           = 1.2
+	bm25B            = 0.75
+	contentScoreMax  = 0.5
+	contentScoreHalf = 5.0
+)
+
+// contentDoc 为单个歌词文件正文（含翻译）的词频
+type contentDoc struct {
+	Terms  map[string]int32
+	Length int // 词元总数
+}
+
+// contentStats 为一次加载中所有已索引歌词文件的语料统计，用于计算 IDF 与长度归一化
+type contentStats struct {
+	Docs      int
+	AvgLength float64
+	DF        map[string]int // 包含各词元的文档数
+}
+
+// lyricContentStats 为当前索引的语料统计，未启用 -index-lyrics 时为 nil（受 mu 保护）
+var lyricContentStats *contentStats
+
+// contentTokens 将文本切分为词元：拉丁字母与数字按单词切分并转为小写，
+// 汉字、假名与谚文按相邻两字切分（单独一个字时取该字），不依赖词典即可匹配中日韩短语
+func contentTokens(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	var cjk []rune
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+		if len(cjk) == 1 {
+			tokens = append(tokens, string(cjk))
+		}
+		for i := 0; i+1 < len(cjk); i++ {
+			tokens = append(tokens, string(cjk[i:i+2]))
+		}
+		cjk = cjk[:0]
+	}
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul):
+			if word.Len() > 0 {
+				flush()
+			}
+			cjk = append(cjk, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if len(cjk) > 0 {
+				flush()
+			}
+			word.WriteRune(unicode.ToLower(r))
+		default:
+			flush()
+		}
+	}
+	flush()
+	return tokens
+}
+
+// newContentDoc 统计歌词正文与翻译的词频
+func newContentDoc(lyric *Lyric) *contentDoc {
+	doc := &contentDoc{Terms: make(map[string]int32)}
+	for _, line := range lyric.Lines {
+		for _, text := range []string{line.Text, line.Translation} {
+			for _, t := range contentTokens(text) {
+				doc.Terms[t]++
+				doc.Length++
+			}
+		}
+	}
+	return doc
+}
+
+// newContentStats 汇总各歌词文件的词频得到语料统计；同一文件被多个平台引用时只计一次
+func newContentStats(infos *lyricInfos) *contentStats {
+	docs := make([]*contentDoc, 0, len(infos.infos))
+	for _, info := range infos.infos {
+		docs = append(docs, info.Content)
+	}
+	return summarizeContent(docs)
+}
+
+// storeContentStats 由已加载的条目重新汇总语料统计，统计范围与 newContentStats 相同（只含主数据仓库的歌词文件，
+// 同一文件只计一次），供只重建单个平台时与条目一并替换；同一文件优先采用刚重建的 platform 中的词频
+func storeContentStats(store map[string][]IndexEntry, platform string) *contentStats {
+	seen := make(map[string]bool)
+	var docs []*contentDoc
+	for _, p := range append([]string{platform}, slices.Collect(maps.Keys(store))...) {
+		for _, e := range store[p] {
+			if e.Source != "" || e.Content == nil || seen[e.RawLyricFile] {
+				continue
+			}
+			seen[e.RawLyricFile] = true
+			docs = append(docs, e.Content)
+		}
+	}
+	return summarizeContent(docs)
+}
+
+func summarizeContent(docs []*contentDoc) *contentStats {
+	stats := &contentStats{DF: make(map[string]int)}
+	total := 0
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		stats.Docs++
+		total += doc.Length
+		for t := range doc.Terms {
+			stats.DF[t]++
+		}
+	}
+	if stats.Docs > 0 {
+		stats.AvgLength = float64(total) / float64(stats.Docs)
+	}
+	return stats
+}
+
+// bm25 计算查询词元对文档的 BM25 值；要求文档包含全部查询词元，否则返回 false
+func (s *contentStats) bm25(doc *contentDoc, terms []string) (float64, bool) {
+	if doc == nil || len(terms) == 0 || s.Docs == 0 {
+		return 0, false
+	}
+	var score float64
+	norm := bm25K1 * (1 - bm25B + bm25B*float64(doc.Length)/s.AvgLength)
+	for _, t := range terms {
+		tf := float64(doc.Terms[t])
+		if tf == 0 {
+			return 0, false
+		}
+		df := float64(s.DF[t])
+		idf := math.Log(1 + (float64(s.Docs)-df+0.5)/(df+0.5))
+		score += idf * tf * (bm25K1 + 1) / (tf + norm)
+	}
+	return score, true
+}
+
+// contentScore 将 BM25 值映射为 (0, contentScoreMax) 内的评分，再乘以 content 字段的权重
+func contentScore(bm25 float64) float64 {
+	return contentScoreMax * bm25 / (bm25 + contentScoreHalf) * fieldWeights.weight("content")
+}
+
+// currentContentStats 返回当前索引的语料统计
+func currentContentStats() *contentStats {
+	mu.RLock()
+	defer mu.RUnlock()
+	return lyricContentStats
+}
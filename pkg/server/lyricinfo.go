@@ -0,0 +1,131 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// --- 索引时的歌词特征 ---
+
+// searchLanguages 为 lang 过滤参数支持的语言
+var searchLanguages = []string{"zh", "ja", "en", "ko"}
+
+// lyricInfo 为加载索引时从原始歌词文件中提取的特征
+type lyricInfo struct {
+	Lang           string      // 歌词正文的主要语言，无法判断时为空
+	HasTranslation bool        // 含 x-translation 翻译
+	HasRoman       bool        // 含 x-roman 音译
+	WordTimed      bool        // 含逐字（音节）时间，而非仅有逐行时间
+	Content        *contentDoc // 正文词频，仅在启用 -index-lyrics 时统计
+	FileSize       *int64      // 文件字节数，无法读取文件时为 nil
+	FileMtime      time.Time   // 文件的修改时间（mtime）
+	ContentHash    string      // 文件内容的哈希（见 contentHash），无法读取文件时为空
+}
+
+// lyricInfos 在一次加载过程中解析并缓存各原始歌词文件的特征（多个平台常引用同一文件）
+type lyricInfos struct {
+	root  string
+	infos map[string]lyricInfo
+}
+
+func newLyricInfos(root string) *lyricInfos {
+	return &lyricInfos{root: root, infos: make(map[string]lyricInfo)}
+}
+
+// get 返回原始歌词文件的特征；文件格式根据内容识别（上游有扩展名与内容不符的文件），
+// 只解析可转换输入格式（TTML、LRC、YRC、QRC），其他格式或解析失败时返回零值
+func (li *lyricInfos) get(rawLyricFile string) lyricInfo {
+	if info, ok := li.infos[rawLyricFile]; ok {
+		return info
+	}
+	var info lyricInfo
+	var data []byte
+	if li.root != "" {
+		path := filepath.Join(li.root, "raw-lyrics", rawLyricFile)
+		if st, err := os.Stat(path); err == nil && st.Mode().IsRegular() {
+			size := st.Size()
+			info.FileSize, info.FileMtime = &size, st.ModTime().UTC()
+			if data, err = os.ReadFile(path); err == nil {
+				info.ContentHash = contentHash(data)
+			}
+		}
+	}
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(rawLyricFile), "."))
+	if data != nil && slices.Contains(lyricFileFormats, ext) {
+		if lyric, err := parseLyric(data, "auto"); err == nil {
+			info.Lang = detectLanguage(lyricText(lyric))
+			for _, line := range lyric.Lines {
+				info.HasTranslation = info.HasTranslation || line.Translation != ""
+				info.HasRoman = info.HasRoman || line.Roman != ""
+				info.WordTimed = info.WordTimed || hasWordTiming(line)
+			}
+			if *indexLyrics {
+				info.Content = newContentDoc(lyric)
+			}
+		}
+	}
+	li.infos[rawLyricFile] = info
+	return info
+}
+
+// hasWordTiming 判断一行是否带有逐字时间：至少有一个词具有独立的时间区间
+func hasWordTiming(line LyricLine) bool {
+	if line.IsBackground {
+		return false
+	}
+	for _, w := range line.Words {
+		if w.End > w.Start && (w.Start != line.Start || w.End != line.End) {
+			return true
+		}
+	}
+	return false
+}
+
+// lyricText 拼接歌词正文（不含翻译与音译）
+func lyricText(lyric *Lyric) string {
+	var sb strings.Builder
+	for _, line := range lyric.Lines {
+		sb.WriteString(line.Text)
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// detectLanguage 按文字系统粗略判断文本的主要语言：
+// 含一定比例假名视为日语，谚文为主视为韩语，汉字为主视为中文，拉丁字母为主视为英语
+func detectLanguage(texts ...string) string {
+	var han, kana, hangul, latin int
+	for _, text := range texts {
+		for _, r := range text {
+			switch {
+			case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+				kana++
+			case unicode.Is(unicode.Hangul, r):
+				hangul++
+			case unicode.Is(unicode.Han, r):
+				han++
+			case r < unicode.MaxASCII && unicode.IsLetter(r):
+				latin++
+			}
+		}
+	}
+
+	cjk := han + kana + hangul
+	switch {
+	case cjk+latin == 0:
+		return ""
+	case kana > 0 && kana*10 >= cjk:
+		return "ja"
+	case hangul*2 > cjk && hangul*3 >= latin:
+		return "ko"
+	case han*2 > cjk && han*3 >= latin:
+		return "zh"
+	case latin > cjk:
+		return "en"
+	}
+	return ""
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// --- 单首歌词统计 ---
+
+// lyricStats 为单个歌词文件的统计信息，供质量看板与筛选使用
+type lyricStats struct {
+	Format          string   `json:"format"`
+	Lines           int      `json:"lines"`           // 主歌词行数
+	BackgroundLines int      `json:"backgroundLines"` // 背景人声行数
+	Words           int      `json:"words"`           // 带逐字时间的词数，仅有逐行时间时为 0
+	DurationMs      int64    `json:"durationMs"`      // 第一行开始到最后一行结束
+	WordsPerSecond  float64  `json:"wordsPerSecond"`  // 逐字时间密度
+	Languages       []string `json:"languages"`       // 正文与翻译中检测到的语言
+	HasBackground   bool     `json:"hasBackground"`
+	HasTranslation  bool     `json:"hasTranslation"`
+	HasRoman        bool     `json:"hasRoman"`
+	WordTimed       bool     `json:"wordTimed"`
+}
+
+// computeLyricStats 统计解析后的歌词
+func computeLyricStats(lyric *Lyric, format string) *lyricStats {
+	stats := &lyricStats{Format: format, Languages: []string{}}
+	var start, end int64 = math.MaxInt64, 0
+	var translation strings.Builder
+	for _, line := range lyric.Lines {
+		if line.IsBackground {
+			stats.BackgroundLines++
+		} else {
+			stats.Lines++
+		}
+		if hasWordTiming(line) || (line.IsBackground && len(line.Words) > 0) {
+			stats.Words += len(line.Words)
+		}
+		start, end = min(start, line.Start), max(end, line.End)
+		translation.WriteString(line.Translation + "\n")
+		stats.HasTranslation = stats.HasTranslation || line.Translation != ""
+		stats.HasRoman = stats.HasRoman || line.Roman != ""
+		stats.WordTimed = stats.WordTimed || hasWordTiming(line)
+	}
+	stats.HasBackground = stats.BackgroundLines > 0
+	if end > start {
+		stats.DurationMs = end - start
+		stats.WordsPerSecond = math.Round(float64(stats.Words)/(float64(stats.DurationMs)/1000)*100) / 100
+	}
+	for _, lang := range []string{detectLanguage(lyricText(lyric)), detectLanguage(translation.String())} {
+		if lang != "" && !slices.Contains(stats.Languages, lang) {
+			stats.Languages = append(stats.Languages, lang)
+		}
+	}
+	return stats
+}
+
+// lyricStatsHandler 处理 GET /api/lyric-stats?platform=ncm&musicId=12345 或 POST 歌词内容，
+// format 默认为 auto（根据内容识别）
+func lyricStatsHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "auto"
+	}
+	if format != "auto" && !slices.Contains(convertInputFormats, format) {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "format must be auto or one of "+strings.Join(convertInputFormats, ", "))
+		return
+	}
+	data, ok := readLyricRequest(w, r, format)
+	if !ok {
+		return
+	}
+	if format == "auto" {
+		format = detectLyricFormat(data)
+	}
+	lyric, err := parseLyric(data, format)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "Cannot parse lyric: "+err.Error())
+		return
+	}
+	writeJSON(w, r, computeLyricStats(lyric, format))
+}
@@ -0,0 +1,319 @@
+package server
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// --- TTML 校验 ---
+
+// maxLyricBodySize 为提交校验的歌词内容大小上限
+const maxLyricBodySize = 5 << 20
+
+const ttmlNS = "http://www.w3.org/ns/ttml"
+
+type lyricDiagnostic struct {
+	Severity  string `json:"severity"` // error 或 warning
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Line      int    `json:"line,omitempty"`      // 源文件中的行号
+	LineIndex int    `json:"lineIndex,omitempty"` // 第几个歌词行（从 1 开始）
+}
+
+type lyricValidation struct {
+	Valid       bool              `json:"valid"`
+	Lines       int               `json:"lines"`
+	Diagnostics []lyricDiagnostic `json:"diagnostics"`
+}
+
+// validateTTML 检查 TTML 的 XML 格式、AMLL 所需属性、时间戳单调性以及行重叠
+func validateTTML(data []byte) *lyricValidation {
+	result := &lyricValidation{Diagnostics: []lyricDiagnostic{}}
+	add := func(severity, code, message string, offset int64, lineIndex int) {
+		result.Diagnostics = append(result.Diagnostics, lyricDiagnostic{
+			Severity:  severity,
+			Code:      code,
+			Message:   message,
+			Line:      bytes.Count(data[:offset], []byte("\n")) + 1,
+			LineIndex: lineIndex,
+		})
+	}
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var (
+		sawRoot, sawBody   bool
+		prevBegin, prevEnd int64 = -1, -1
+		inLine             bool
+		lineBegin, lineEnd int64
+		prevWordBegin      int64
+		bgDepth            int
+		depth              int
+		malformed          bool
+	)
+
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			malformed = true
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				result.Diagnostics = append(result.Diagnostics, lyricDiagnostic{Severity: "error", Code: "XML_MALFORMED", Message: syntaxErr.Msg, Line: syntaxErr.Line})
+			} else {
+				add("error", "XML_MALFORMED", err.Error(), dec.InputOffset(), 0)
+			}
+			break
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				sawRoot = true
+				if t.Name.Local != "tt" || t.Name.Space != ttmlNS {
+					add("error", "TTML_ROOT_INVALID", "root element must be <tt> in the "+ttmlNS+" namespace", offset, 0)
+				}
+			}
+			switch t.Name.Local {
+			case "body":
+				sawBody = true
+			case "p":
+				result.Lines++
+				inLine = true
+				prevWordBegin = -1
+				begin, end, ok := checkTimedElement(t, "line", offset, result.Lines, add)
+				lineBegin, lineEnd = begin, end
+				if xmlAttr(t, ttmlMetadataNS, "agent") == "" {
+					add("warning", "AGENT_MISSING", "line has no ttm:agent attribute", offset, result.Lines)
+				}
+				if !ok {
+					continue
+				}
+				if prevBegin >= 0 && begin < prevBegin {
+					add("warning", "TIMESTAMP_NOT_MONOTONIC", fmt.Sprintf("line begins at %dms, before the previous line (%dms)", begin, prevBegin), offset, result.Lines)
+				}
+				if prevEnd >= 0 && begin < prevEnd {
+					add("warning", "LINE_OVERLAP", fmt.Sprintf("line begins at %dms, before the previous line ends (%dms)", begin, prevEnd), offset, result.Lines)
+				}
+				prevBegin, prevEnd = begin, end
+			case "span":
+				if !inLine {
+					continue
+				}
+				switch xmlAttr(t, ttmlMetadataNS, "role") {
+				case "x-translation", "x-roman":
+					continue
+				case "x-bg":
+					bgDepth = depth
+					continue
+				}
+				// 未标注时间的 span 视为纯文本
+				if xmlAttr(t, "", "begin") == "" && xmlAttr(t, "", "end") == "" {
+					continue
+				}
+				begin, end, ok := checkTimedElement(t, "word", offset, result.Lines, add)
+				if !ok {
+					continue
+				}
+				if bgDepth == 0 {
+					if prevWordBegin >= 0 && begin < prevWordBegin {
+						add("warning", "TIMESTAMP_NOT_MONOTONIC", fmt.Sprintf("word begins at %dms, before the previous word (%dms)", begin, prevWordBegin), offset, result.Lines)
+					}
+					prevWordBegin = begin
+				}
+				if lineEnd > lineBegin && (begin < lineBegin || end > lineEnd) {
+					add("warning", "WORD_OUTSIDE_LINE", fmt.Sprintf("word %dms-%dms lies outside its line %dms-%dms", begin, end, lineBegin, lineEnd), offset, result.Lines)
+				}
+			}
+		case xml.EndElement:
+			if t.Name.Local == "p" {
+				inLine = false
+			}
+			if depth == bgDepth {
+				bgDepth = 0
+			}
+			depth--
+		}
+	}
+
+	switch {
+	case malformed:
+		// 文档不完整时不再报告结构性问题
+	case !sawRoot:
+		result.Diagnostics = append(result.Diagnostics, lyricDiagnostic{Severity: "error", Code: "XML_MALFORMED", Message: "document is empty"})
+	case !sawBody:
+		result.Diagnostics = append(result.Diagnostics, lyricDiagnostic{Severity: "error", Code: "BODY_MISSING", Message: "document has no <body> element"})
+	case result.Lines == 0:
+		result.Diagnostics = append(result.Diagnostics, lyricDiagnostic{Severity: "error", Code: "NO_LINES", Message: "document contains no <p> lines"})
+	}
+
+	result.Valid = true
+	for _, d := range result.Diagnostics {
+		if d.Severity == "error" {
+			result.Valid = false
+			break
+		}
+	}
+	return result
+}
+
+// validateTimedLyric 校验 LRC、YRC、QRC 等非 TTML 格式：先按格式解析，再检查解析出的行与词的时间；
+// 这些格式没有 XML 结构，诊断只给出歌词行序号
+func validateTimedLyric(data []byte, format string) *lyricValidation {
+	result := &lyricValidation{Diagnostics: []lyricDiagnostic{}}
+	add := func(severity, code, message string, lineIndex int) {
+		result.Diagnostics = append(result.Diagnostics, lyricDiagnostic{Severity: severity, Code: code, Message: message, LineIndex: lineIndex})
+	}
+
+	lyric, err := parseLyric(data, format)
+	switch {
+	case errors.Is(err, errEmptyLyric):
+		add("error", "NO_LINES", "document contains no timed lines", 0)
+	case err != nil:
+		add("error", "PARSE_FAILED", err.Error(), 0)
+	default:
+		result.Lines = len(lyric.Lines)
+		var prevBegin, prevEnd int64 = -1, -1
+		for i, line := range lyric.Lines {
+			n := i + 1
+			if line.End < line.Start {
+				add("error", "TIMING_REVERSED", fmt.Sprintf("line ends (%dms) before it begins (%dms)", line.End, line.Start), n)
+				continue
+			}
+			if prevBegin >= 0 && line.Start < prevBegin {
+				add("warning", "TIMESTAMP_NOT_MONOTONIC", fmt.Sprintf("line begins at %dms, before the previous line (%dms)", line.Start, prevBegin), n)
+			}
+			if prevEnd >= 0 && line.Start < prevEnd {
+				add("warning", "LINE_OVERLAP", fmt.Sprintf("line begins at %dms, before the previous line ends (%dms)", line.Start, prevEnd), n)
+			}
+			prevBegin, prevEnd = line.Start, line.End
+
+			var prevWord int64 = -1
+			for _, w := range line.Words {
+				if w.End < w.Start {
+					add("error", "TIMING_REVERSED", fmt.Sprintf("word ends (%dms) before it begins (%dms)", w.End, w.Start), n)
+					continue
+				}
+				if prevWord >= 0 && w.Start < prevWord {
+					add("warning", "TIMESTAMP_NOT_MONOTONIC", fmt.Sprintf("word begins at %dms, before the previous word (%dms)", w.Start, prevWord), n)
+				}
+				prevWord = w.Start
+				if line.End > line.Start && (w.Start < line.Start || w.End > line.End) {
+					add("warning", "WORD_OUTSIDE_LINE", fmt.Sprintf("word %dms-%dms lies outside its line %dms-%dms", w.Start, w.End, line.Start, line.End), n)
+				}
+			}
+		}
+	}
+
+	result.Valid = true
+	for _, d := range result.Diagnostics {
+		if d.Severity == "error" {
+			result.Valid = false
+			break
+		}
+	}
+	return result
+}
+
+// checkTimedElement 校验元素的 begin/end 属性，返回解析后的时间以及是否有效
+func checkTimedElement(el xml.StartElement, kind string, offset int64, lineIndex int,
+	add func(severity, code, message string, offset int64, lineIndex int)) (int64, int64, bool) {
+	beginAttr, endAttr := xmlAttr(el, "", "begin"), xmlAttr(el, "", "end")
+	if beginAttr == "" || endAttr == "" {
+		add("error", "TIMING_MISSING", kind+" is missing a begin or end attribute", offset, lineIndex)
+		return 0, 0, false
+	}
+	begin, err := parseTTMLTime(beginAttr)
+	if err != nil {
+		add("error", "TIMING_INVALID", fmt.Sprintf("%s has an invalid begin time %q", kind, beginAttr), offset, lineIndex)
+		return 0, 0, false
+	}
+	end, err := parseTTMLTime(endAttr)
+	if err != nil {
+		add("error", "TIMING_INVALID", fmt.Sprintf("%s has an invalid end time %q", kind, endAttr), offset, lineIndex)
+		return 0, 0, false
+	}
+	if end < begin {
+		add("error", "TIMING_REVERSED", fmt.Sprintf("%s ends (%dms) before it begins (%dms)", kind, end, begin), offset, lineIndex)
+		return begin, end, false
+	}
+	return begin, end, true
+}
+
+// validateLyricHandler 校验 POST 提交的歌词内容，或通过 platform/musicId 引用的已存储文件；format 默认为 ttml
+func validateLyricHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "ttml"
+	}
+	if format != "auto" && !slices.Contains(convertInputFormats, format) {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "format must be auto or one of "+strings.Join(convertInputFormats, ", "))
+		return
+	}
+
+	data, ok := readLyricRequest(w, r, format)
+	if !ok {
+		return
+	}
+
+	if format == "auto" {
+		if format = detectLyricFormat(data); !slices.Contains(convertInputFormats, format) {
+			writeJSON(w, r, &lyricValidation{Diagnostics: []lyricDiagnostic{{Severity: "error", Code: "FORMAT_UNKNOWN", Message: "cannot detect a supported lyric format"}}})
+			return
+		}
+	}
+	if format == "ttml" {
+		writeJSON(w, r, validateTTML(data))
+		return
+	}
+	writeJSON(w, r, validateTimedLyric(data, format))
+}
+
+// readLyricRequest 读取请求指定的歌词内容：有 platform 参数时读取已存储的文件，否则读取 POST 请求体；
+// 失败时已写出错误响应并返回 false
+func readLyricRequest(w http.ResponseWriter, r *http.Request, format string) ([]byte, bool) {
+	var data []byte
+	var err error
+	if platform := r.URL.Query().Get("platform"); platform != "" {
+		resolve := func(format string) (string, error) {
+			return resolveLyricFile(platform, r.URL.Query().Get("musicId"), format)
+		}
+		var filePath string
+		var rerr error
+		if format == "auto" {
+			filePath, _, rerr = resolveAutoLyricFile(resolve)
+		} else {
+			filePath, rerr = resolve(format)
+		}
+		if rerr != nil {
+			writeError(w, r, http.StatusNotFound, codeLyricNotFound, "Lyric file not found")
+			return nil, false
+		}
+		data, err = os.ReadFile(filePath)
+	} else if r.Method == http.MethodPost {
+		data, err = io.ReadAll(http.MaxBytesReader(w, r.Body, maxLyricBodySize))
+	} else {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "POST a lyric body or specify platform and musicId")
+		return nil, false
+	}
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeError(w, r, http.StatusRequestEntityTooLarge, codePayloadTooLarge, "Lyric body exceeds the 5 MB limit")
+		return nil, false
+	case err != nil:
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return nil, false
+	}
+	return data, true
+}
@@ -0,0 +1,1456 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// --- 数据结构定义 ---
+
+// IndexEntry 对应 index.jsonl 中的行
+type IndexEntry struct {
+	ID             string          `json:"id"`
+	RawLyricFile   string          `json:"rawLyricFile"`
+	MetadataRaw    [][]interface{} `json:"metadata"`
+	Metadata       Metadata        `json:"-"` // 加载时由 MetadataRaw 解析得到
+	SearchBlob     []byte          `json:"-"` // 预处理的全文本索引（小写字节）
+	Updated        time.Time       `json:"-"` // 原始歌词文件的最后修改时间（Git 提交时间或 mtime）
+	Lang           string          `json:"-"` // 检测到的主要语言（zh/ja/en/ko），未知时为空
+	HasTranslation bool            `json:"-"` // 歌词含翻译
+	HasRoman       bool            `json:"-"` // 歌词含音译
+	WordTimed      bool            `json:"-"` // 歌词含逐字时间
+	Content        *contentDoc     `json:"-"` // 歌词正文词频（-index-lyrics），同一歌词文件的条目共用
+	ContentHash    string          `json:"-"` // 原始歌词文件内容的哈希，供 dedupe=content 使用
+	FileSize       *int64          `json:"-"` // 原始歌词文件的字节数，未知时为 nil
+	FileMtime      time.Time       `json:"-"` // 原始歌词文件的 mtime（与 Updated 不同，不取 Git 提交时间）
+	Phonetic       [][]string      `json:"-"` // 歌名与歌手（含别名）各值的读音键，供 phonetic=true 使用
+	Source         string          `json:"-"` // 数据来源：-repo 仓库或叠加目录的名称，主数据目录为空（见 entrySource）
+}
+
+// SearchResult 对应 API 文档中的搜索结果格式
+type SearchResult struct {
+	ID             string            `json:"id"`
+	RawLyricFile   string            `json:"rawLyricFile"`
+	Metadata       [][]interface{}   `json:"metadata"`
+	Platforms      []string          `json:"platforms"`
+	PlatformIDs    map[string]string `json:"platformIds,omitempty"` // 各平台中该歌词对应的歌曲 ID
+	Score          float64           `json:"score"`                 // 匹配评分，基础范围 (0, 1]，精确匹配加成后可能超过 1，见 score.go
+	Updated        time.Time         `json:"updated,omitzero"`
+	Lang           string            `json:"lang,omitempty"`
+	HasTranslation bool              `json:"hasTranslation"`
+	HasRoman       bool              `json:"hasRoman"`
+	WordTimed      bool              `json:"wordTimed"`
+	FileSize       *int64            `json:"fileSize,omitempty"` // 原始歌词文件的字节数
+	FileMtime      time.Time         `json:"fileMtime,omitzero"` // 原始歌词文件的 mtime
+	Title          string            `json:"title,omitempty"`    // 以下三项取自元数据（见 withParsedFields）
+	Artists        []string          `json:"artists,omitempty"`
+	Album          string            `json:"album,omitempty"`        // 元数据没有专辑时由 -enrich 从上游平台补全，与以下两项相同
+	DurationMs     int64             `json:"durationMs,omitempty"`   // 歌曲时长（毫秒）
+	CoverURL       string            `json:"coverUrl,omitempty"`     // 专辑封面地址
+	DownloadURL    string            `json:"downloadUrl,omitempty"`  // 原始歌词文件的下载地址，见 downloadurl.go
+	DownloadURLs   map[string]string `json:"downloadUrls,omitempty"` // 各格式的下载地址
+	Source         string            `json:"source,omitempty"`       // 结果的来源（见 entrySource），来自回退 API 或对等实例时为其名称
+	ContentHash    string            `json:"-"`                      // 原始歌词文件内容的哈希，供 dedupe=content 使用
+	Sources        []songSource      `json:"-"`                      // 合并前各平台的原始条目，供 groupBy=song 使用
+}
+
+// --- 全局变量 ---
+
+var (
+	// 命令行参数（由各子命令的 FlagSet 注册，见 registerXxxFlags）
+	repoURL       = "https://github.com/Steve-xmh/amll-ttml-db.git"
+	noSync        = new(bool)
+	noDownload    = new(bool)
+	inputDataDir  = new(string)
+	syncInterval  = new(time.Duration)
+	port          = new(string)
+	searchWorkers = new(int)
+	shardSize     = new(int)
+	grpcPort      = new(string)
+	stdioMode     = new(bool)
+	aliasesFile   = new(string)
+	synonymsFile  = new(string)
+	stopwordsFile = new(string)
+	requireData   = new(bool) // 启动时没有可用的数据（初次克隆失败或找不到数据目录）则退出
+	legacyTimes   = new(bool) // 兼容旧客户端：last_update_time 使用不带时区的 "2006-01-02 15:04:05" 格式，下个版本移除
+
+	// 内存数据库
+	dataStore       = make(map[string][]IndexEntry)
+	platformPaths   = make(map[string]string) // 各平台歌词文件所在目录
+	platformSources = make(map[string]Source)
+	platforms       = knownPlatforms // 加载时替换为实际发现的平台（见 indexFiles）
+	actualDataDir   string
+	lastUpdateTime  time.Time
+	startedAt       = time.Now()
+
+	// 并发控制
+	mu    sync.RWMutex // 保护数据索引
+	gitMu sync.Mutex   // 保护 Git 操作
+
+	// 查询缓存
+	queryCache     = make(map[string][]SearchResult)
+	queryCacheGen  uint64 // 查询缓存的代数（受 queryCacheMu 保护），见 saveToCache
+	queryCacheMu   sync.RWMutex
+	queryCacheTTL  = 5 * time.Minute
+	queryTimestamp = make(map[string]time.Time)
+)
+
+// --- 命令行参数注册 ---
+
+// registerDataFlags 注册所有子命令共用的数据目录参数
+func registerDataFlags(fs *flag.FlagSet) {
+	*inputDataDir = "lyric-data"
+	fs.Var(&dataDirs, "data-dir", "Preferred path to the data directory (default lyric-data); repeat to overlay further data directories, later ones winning on ID conflicts")
+	fs.Var(&extraPlatformAliases, "platform-aliases", "Extra platform aliases accepted by the API, e.g. kg=kugou (comma-separated)")
+	fs.Var(&enabledPlatforms, "platforms", "Only index and serve these platforms (comma-separated, default all)")
+	fs.Var(&disabledPlatforms, "disable-platforms", "Platforms to skip when loading the index (comma-separated)")
+	fs.Var(&platformPriority, "platform-priority", "Platforms whose metadata represents merged results, highest priority first (comma-separated)")
+	fs.Var(&extraSources, "source", "Extra lyric source as name=dir or name=git-url, surfaced as a platform (repeatable)")
+	fs.Var(&extraRepos, "repo", "Additional data repository with the same layout as the main one, as name=git-url; synced and indexed independently and tagged by source in results (repeatable)")
+	fs.StringVar(sourcesDir, "sources-dir", "sources", "Directory where git sources and repositories are cloned")
+	fs.StringVar(s3Endpoint, "s3-endpoint", "https://s3.amazonaws.com", "Endpoint of the S3-compatible storage used by s3:// data dirs and sources")
+	fs.StringVar(s3Region, "s3-region", "us-east-1", "Region used to sign S3 requests")
+	fs.StringVar(s3CacheDir, "s3-cache-dir", "s3-cache", "Directory caching lyric files downloaded from S3")
+	fs.Var(&minLogLevel, "log-level", "Minimum level of log messages: debug, info, warn or error (default info)")
+}
+
+// registerSearchFlags 注册影响搜索执行的参数
+func registerSearchFlags(fs *flag.FlagSet) {
+	fs.IntVar(searchWorkers, "search-workers", runtime.NumCPU(), "Number of workers scanning index shards per search")
+	fs.IntVar(shardSize, "shard-size", 4096, "Number of index entries per scan shard")
+	fs.StringVar(aliasesFile, "aliases", "", "Path to an artist alias file (one group of equivalent names per line, separated by |)")
+	fs.StringVar(synonymsFile, "synonyms", "", "Path to a synonym file (one group per line separated by |, first word is canonical)")
+	fs.StringVar(stopwordsFile, "stopwords", "", "Path to a stopword file (one word per line)")
+	fs.StringVar(rewriteRulesFile, "rewrite-rules", "", "Path to a query rewrite file (one \"regex => replacement\" rule per line) applied to incoming queries")
+	fs.DurationVar(searchTimeout, "search-timeout", 30*time.Second, "Maximum time a search may run before it is cancelled (client deadlines are capped to this)")
+	fs.IntVar(maxQueryLength, "max-query-length", 256, "Maximum length in characters of a search query or structured field (0 disables)")
+	fs.IntVar(minQueryLength, "min-query-length", 1, "Minimum length in characters of a search query; structured searches need one field this long")
+	fs.IntVar(maxQueryArtists, "max-query-artists", 16, "Maximum number of artists in a structured search (0 disables)")
+	fs.Float64Var(exactTitleBoost, "exact-title-boost", 0.5, "Score bonus when a title equals the query exactly (0 disables)")
+	fs.Float64Var(exactArtistBoost, "exact-artist-boost", 0.25, "Score bonus when an artist equals the query exactly (0 disables)")
+	fs.Var(&fieldWeights, "field-weights", "Ranking weights per field as field=weight, e.g. title=2,album=0.5 (fields: title, artist, album, filename, content; default 1)")
+	fs.BoolVar(indexLyrics, "index-lyrics", false, "Index lyric content (text and translations) so full-text searches also match lyrics, ranked with BM25")
+	fs.DurationVar(slowQueryThreshold, "slow-query", 500*time.Millisecond, "Log searches taking at least this long (0 disables)")
+}
+
+// registerServeFlags 注册 serve 子命令的参数
+func registerServeFlags(fs *flag.FlagSet) {
+	registerDataFlags(fs)
+	registerSearchFlags(fs)
+	fs.BoolVar(noSync, "no-sync", false, "Disable git sync and use local data only")
+	fs.DurationVar(consistencyInterval, "consistency-interval", time.Hour, "Interval between checks that sampled lyric files still exist with the size seen at load (0 disables)")
+	fs.IntVar(consistencySample, "consistency-sample", 200, "Number of index entries sampled by each consistency check")
+	fs.BoolVar(fullHistory, "full-history", false, "Clone the data repository with full history (unshallowing an existing clone on the next sync) so lyric update times are known; shallow clones only know times of lyrics changed after cloning")
+	fs.Int64Var(gitPruneThresholdMB, "git-prune-threshold-mb", 0, "After each scheduled sync, prune git objects (gc, then a fresh shallow clone if still needed) when .git exceeds this many MB (0 disables)")
+	fs.BoolVar(requireData, "require-data", false, "Exit with a non-zero status if the initial clone fails or no index entries can be loaded at startup")
+	fs.BoolVar(quiet, "quiet", false, "Do not log each HTTP request")
+	fs.IntVar(accessLogSample, "access-log-sample", 1, "Log only 1 in N successful requests; errors and requests slower than -slow-query are always logged")
+	fs.IntVar(maxInFlightSearch, "max-inflight-search", 0, "Maximum concurrent search requests; beyond it requests get 429 with Retry-After (0 means unlimited)")
+	fs.IntVar(maxInFlightDownload, "max-inflight-download", 0, "Maximum concurrent download requests; beyond it requests get 429 with Retry-After (0 means unlimited)")
+	fs.BoolVar(noDownload, "no-download", false, "Disable the download API")
+	fs.BoolVar(legacyTimes, "legacy-time-format", false, "Deprecated: report last_update_time in /api/status as \"2006-01-02 15:04:05\" without timezone")
+	fs.StringVar(primaryURL, "primary", "", "Run as a replica: load the index snapshot from this instance instead of the data directory")
+	fs.StringVar(primaryAdminURL, "primary-admin", "", "Admin listener of the primary (its -admin-addr, e.g. http://10.0.0.1:43595) serving /api/snapshot (default: -primary)")
+	fs.StringVar(primaryToken, "primary-token", "", "Admin token of the primary, sent as a Bearer token when fetching the snapshot and lyric files")
+	fs.StringVar(replicaCacheDir, "replica-cache-dir", "replica-cache", "Directory caching lyric files fetched from the primary")
+	fs.StringVar(adminAddr, "admin-addr", "", "Serve update, cache clear, reindex, prune, analytics, stats and pprof endpoints only on this address (e.g. 127.0.0.1:43595) instead of the public port")
+	fs.StringVar(tlsCertFile, "tls-cert", "", "TLS certificate (PEM); with -tls-key, serve HTTPS on the main and admin listeners")
+	fs.StringVar(tlsKeyFile, "tls-key", "", "TLS private key (PEM) for -tls-cert")
+	fs.StringVar(clientCAFile, "client-ca", "", "CA bundle (PEM) for client certificates; listeners in -client-cert-listeners require one, and a verified certificate replaces the API key")
+	fs.StringVar(clientCertListeners, "client-cert-listeners", "main,admin,grpc", "Listeners requiring a client certificate when -client-ca is set: main, admin, grpc (comma-separated, empty for none)")
+	fs.StringVar(adminToken, "admin-token", "", "Bearer token required by admin endpoints such as /api/analytics (admin API disabled when empty)")
+	fs.StringVar(analyticsFile, "analytics-file", "", "File where query analytics are persisted across restarts")
+	fs.StringVar(auditLogFile, "audit-log", "", "Append-only JSON Lines file recording admin actions (update, cache clear)")
+	fs.StringVar(apiKeysFile, "api-keys", "", "File listing API keys (one \"key name [daily=N] [monthly=N]\" per line) required by the public data endpoints, with optional per-key quotas")
+	fs.StringVar(apiKeyUsageFile, "api-key-usage-file", "", "File where per-key API usage is persisted across restarts")
+	fs.StringVar(downloadStatsFile, "download-stats-file", "", "File where per-track download counts are persisted across restarts")
+	fs.StringVar(downloadMode, "download-mode", "serve", "How /api/download delivers files: serve or redirect (302 to the CDN)")
+	fs.StringVar(cdnProvider, "cdn", "jsdelivr", "CDN used by -download-mode=redirect: jsdelivr or github")
+	fs.StringVar(downloadSigningKey, "download-signing-key", "", "HMAC key; when set, /api/download only serves URLs signed with it (expires and signature parameters) and search results carry signed URLs")
+	fs.DurationVar(signedURLTTL, "signed-url-ttl", 15*time.Minute, "Lifetime of the signed download URLs included in search results")
+	fs.StringVar(publicURL, "public-url", "", "Public base URL of this server (e.g. https://lyrics.example.com) used for downloadUrl in search results (default: relative URLs)")
+	fs.DurationVar(updateCooldown, "update-cooldown", time.Minute, "Minimum interval between manual update triggers (0 disables)")
+	fs.DurationVar(syncInterval, "interval", 10*time.Minute, "Interval for automatic sync")
+	fs.StringVar(port, "port", "43594", "Server port")
+	fs.BoolVar(enrichEnabled, "enrich", false, "Fill in album, duration and cover URL of search results from the NCM/QQ public APIs")
+	fs.Float64Var(enrichRate, "enrich-rate", 5, "Maximum upstream requests per second made by -enrich")
+	fs.BoolVar(submitHints, "submit-hints", false, "Add a prefilled upstream issue URL to searches with no results and record them for /api/missing")
+	fs.Var(&webhookURLs, "webhook", "URL receiving a signed POST whenever a sync changes the index (repeatable)")
+	fs.StringVar(webhookSecret, "webhook-secret", "", "HMAC-SHA256 key used to sign webhook payloads")
+	fs.Var(&peers, "peer", "Peer instance searched alongside the local index, as name=url or name=url,key=api-key (repeatable)")
+	fs.DurationVar(peerTimeout, "peer-timeout", 2*time.Second, "Timeout for searches sent to peers")
+	fs.IntVar(peerLimit, "peer-limit", 100, "Maximum results requested from each peer")
+	fs.StringVar(fallbackURL, "fallback", "", "LRCLIB-compatible API queried when a search has no local match, e.g. https://lrclib.net (disabled when empty)")
+	fs.StringVar(grpcPort, "grpc-port", "", "Port for the gRPC API (disabled when empty)")
+	fs.BoolVar(stdioMode, "stdio", false, "Serve JSON-RPC on stdin/stdout instead of HTTP")
+}
+
+// --- 路径嗅探逻辑 ---
+
+func isDataDir(path string) bool {
+	if info, err := os.Stat(filepath.Join(path, "metadata")); err == nil && info.IsDir() {
+		return true
+	}
+	matches, _ := filepath.Glob(filepath.Join(path, "*-lyrics", "index.jsonl"))
+	return len(matches) > 0
+}
+
+func findValidDataDir() string {
+	if replicaMode() {
+		return *primaryURL
+	}
+	if isS3URL(*inputDataDir) {
+		return *inputDataDir
+	}
+	if isDataDir(*inputDataDir) {
+		p, _ := filepath.Abs(*inputDataDir)
+		return p
+	}
+	if isDataDir(".") {
+		p, _ := filepath.Abs(".")
+		return p
+	}
+	if isDataDir("..") {
+		p, _ := filepath.Abs("..")
+		return p
+	}
+	subDirs := []string{"lyric-data", "amll-ttml-db", "data"}
+	for _, sub := range subDirs {
+		if isDataDir(sub) {
+			p, _ := filepath.Abs(sub)
+			return p
+		}
+	}
+	return ""
+}
+
+// --- Git 同步与索引加载 ---
+
+func syncRepo() (updated bool) {
+	if *noSync || isS3URL(*inputDataDir) || replicaMode() {
+		return false
+	}
+	gitMu.Lock()
+	defer gitMu.Unlock()
+
+	publishEvent("sync-started", nil)
+	defer func() {
+		publishEvent("sync-finished", map[string]interface{}{"updated": updated})
+	}()
+
+	absTarget, _ := filepath.Abs(*inputDataDir)
+	if _, err := os.Stat(filepath.Join(absTarget, ".git")); os.IsNotExist(err) {
+		logInfof("Repository not found. Initializing clone to %s...", absTarget)
+		args := []string{"clone", "--depth", "1", repoURL, absTarget}
+		if *fullHistory {
+			args = []string{"clone", repoURL, absTarget}
+		}
+		cmd := exec.Command("git", args...)
+		output, err := cmd.CombinedOutput()
+		logCommandOutput("git clone", output)
+		if err != nil {
+			logErrorf("Git clone failed: %v", err)
+			return false
+		}
+		return true
+	}
+
+	if *fullHistory && isShallowRepo(absTarget) {
+		logInfof("Fetching full history of %s (-full-history)...", absTarget)
+		output, err := exec.Command("git", "-C", absTarget, "fetch", "--unshallow").CombinedOutput()
+		logCommandOutput("git fetch --unshallow", output)
+		if err != nil {
+			logErrorf("Git fetch --unshallow failed: %v", err)
+		}
+	}
+
+	logInfof("Performing incremental update (git pull)...")
+	cmd := exec.Command("git", "-C", absTarget, "pull")
+	output, err := cmd.CombinedOutput()
+	logCommandOutput("git pull", output)
+	if err != nil {
+		logErrorf("Git pull failed: %v", err)
+		return false
+	}
+	return !strings.Contains(string(output), "Already up to date")
+}
+
+// currentCommit 返回数据目录当前检出的 commit hash，非 Git 仓库时返回空字符串
+func currentCommit(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// commitInfo 描述数据目录检出的上游版本
+type commitInfo struct {
+	Hash   string
+	Time   time.Time // 提交时间
+	Branch string    // 处于分离 HEAD 状态时为空
+}
+
+// readCommitInfo 读取数据目录当前检出的 commit、提交时间与分支，非 Git 仓库时返回零值
+func readCommitInfo(dir string) commitInfo {
+	out, err := exec.Command("git", "-C", dir, "log", "-1", "--format=%H %ct").Output()
+	if err != nil {
+		return commitInfo{}
+	}
+	hash, ts, _ := strings.Cut(strings.TrimSpace(string(out)), " ")
+	info := commitInfo{Hash: hash}
+	if sec, err := strconv.ParseInt(ts, 10, 64); err == nil {
+		info.Time = time.Unix(sec, 0)
+	}
+	if out, err := exec.Command("git", "-C", dir, "symbolic-ref", "--short", "-q", "HEAD").Output(); err == nil {
+		info.Branch = strings.TrimSpace(string(out))
+	}
+	return info
+}
+
+// knownPlatforms 为上游仓库已知的平台，用于确定平台的展示顺序
+var knownPlatforms = []string{"ncm", "qq", "am", "spotify", "raw"}
+
+// indexFiles 扫描数据根目录，返回各平台 index 文件的路径：
+// 每个 <平台>-lyrics/index.jsonl 注册为一个平台，raw 平台的索引位于 metadata/raw-lyrics-index.jsonl
+func indexFiles(root string) map[string]string {
+	files := make(map[string]string)
+	matches, _ := filepath.Glob(filepath.Join(root, "*-lyrics", "index.jsonl"))
+	for _, path := range matches {
+		name := strings.TrimSuffix(filepath.Base(filepath.Dir(path)), "-lyrics")
+		if name != "" {
+			files[name] = path
+		}
+	}
+	files["raw"] = filepath.Join(root, "metadata", "raw-lyrics-index.jsonl")
+	return files
+}
+
+// sortPlatforms 按 knownPlatforms 的顺序排列平台，其余平台按名称排在后面
+func sortPlatforms(names []string) []string {
+	sorted := make([]string, 0, len(names))
+	for _, p := range knownPlatforms {
+		if slices.Contains(names, p) {
+			sorted = append(sorted, p)
+		}
+	}
+	var extra []string
+	for _, p := range names {
+		if !slices.Contains(knownPlatforms, p) {
+			extra = append(extra, p)
+		}
+	}
+	sort.Strings(extra)
+	return append(sorted, extra...)
+}
+
+// platformList 返回当前已加载的平台
+func platformList() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	return platforms
+}
+
+// entryPreparer 在加载索引时解析条目的元数据并预处理搜索文本，同时收集元数据问题
+type entryPreparer struct {
+	aliases map[string][]string
+	rules   *textRules
+	issues  []metadataIssue
+}
+
+// prepare 处理某个平台的条目；local 为 false 时没有本地歌词目录，保留数据源给出的更新时间与语言等字段
+func (p *entryPreparer) prepare(key string, entries []IndexEntry, times *lyricTimes, infos *lyricInfos, local bool) {
+	for i := range entries {
+		entry := &entries[i]
+
+		// 解析类型化元数据；校验失败的条目仍然保留，仅记录问题
+		md, err := parseMetadata(entry.MetadataRaw)
+		if err == nil {
+			err = md.validate()
+		}
+		if err != nil {
+			p.issues = append(p.issues, metadataIssue{Platform: key, ID: entry.ID, Error: err.Error()})
+		}
+		entry.Metadata = md
+
+		// 语言优先依据歌词正文判断，无法读取歌词时退回到歌名；
+		// 没有本地数据目录时保留数据源给出的值（如副本模式下快照中的字段）
+		if local {
+			entry.Updated = times.get(entry.RawLyricFile)
+			info := infos.get(entry.RawLyricFile)
+			entry.Lang = info.Lang
+			entry.HasTranslation = info.HasTranslation
+			entry.HasRoman = info.HasRoman
+			entry.WordTimed = info.WordTimed
+			entry.Content = info.Content
+			entry.FileSize, entry.FileMtime = info.FileSize, info.FileMtime
+			entry.ContentHash = info.ContentHash
+		}
+		if entry.Lang == "" {
+			entry.Lang = detectLanguage(md.MusicName...)
+		}
+
+		// 预处理 SearchBlob
+		blob := make([]byte, 0, len(entry.ID)+len(entry.RawLyricFile)+256) // 预分配容量
+
+		blob = append(blob, strings.ToLower(entry.ID)...)
+		blob = append(blob, ' ')
+		blob = append(blob, strings.ToLower(entry.RawLyricFile)...)
+		blob = append(blob, ' ')
+
+		for _, pair := range entry.MetadataRaw {
+			if len(pair) >= 2 {
+				if values, ok := pair[1].([]interface{}); ok {
+					for _, v := range values {
+						if s, ok := v.(string); ok {
+							blob = append(blob, p.rules.apply(strings.ToLower(s))...)
+							blob = append(blob, ' ')
+						}
+					}
+				}
+			}
+		}
+		// 追加歌手别名，使本地化的名称也能搜到同一首歌
+		artists := expandArtists(p.aliases, md.Artists)
+		entry.Phonetic = entryPhoneticKeys(md, artists)
+		for _, alias := range artists[len(md.Artists):] {
+			blob = append(blob, strings.ToLower(alias)...)
+			blob = append(blob, ' ')
+		}
+		entry.SearchBlob = blob
+	}
+}
+
+func loadMetadata() {
+	defer beginReload()()
+	root := findValidDataDir()
+	if root == "" {
+		logWarnf("Warning: No valid data directory found. API will return empty results.")
+		return
+	}
+
+	// 数据目录中发现的平台在前，-source 注册的附加数据源在后
+	var sources []Source
+	var rootSources []*s3Source
+	localRoot := root // 歌词修改时间与语言检测需要本地的 raw-lyrics 目录，存储桶中的数据不做检测
+	var commit commitInfo
+	if replicaMode() {
+		localRoot = ""
+		replicas, err := replicaSources()
+		if err != nil {
+			logWarnf("Warning: failed to fetch index snapshot from %s: %v", root, err)
+			return
+		}
+		sources = append(sources, replicas...)
+		latestSnapshotMu.Lock()
+		commit = commitInfo{Hash: latestSnapshot.Commit, Time: latestSnapshot.CommitTime, Branch: latestSnapshot.Branch}
+		latestSnapshotMu.Unlock()
+	} else if isS3URL(root) {
+		localRoot = ""
+		var err error
+		if rootSources, err = discoverS3Sources(root); err != nil {
+			logWarnf("Warning: failed to list %s: %v", root, err)
+		}
+		for _, src := range rootSources {
+			sources = append(sources, src)
+		}
+	} else {
+		configs := indexFiles(root)
+		for _, key := range sortPlatforms(slices.Collect(maps.Keys(configs))) {
+			sources = append(sources, newDirSource(key, configs[key]))
+		}
+		sources = layeredSources(sources, overlayRoots())
+	}
+	sources = append(sources, extraSources...)
+
+	tempStore := make(map[string][]IndexEntry)
+	tempPaths := make(map[string]string)
+	tempSources := make(map[string]Source)
+	times := newLyricTimes(localRoot)
+	infos := newLyricInfos(localRoot)
+
+	aliases := make(map[string][]string)
+	if *aliasesFile != "" {
+		if loaded, err := loadAliases(*aliasesFile); err != nil {
+			logWarnf("Warning: failed to load aliases from %s: %v", *aliasesFile, err)
+		} else {
+			aliases = loaded
+		}
+	}
+	rules, err := loadTextRules(*synonymsFile, *stopwordsFile)
+	if err != nil {
+		logWarnf("Warning: failed to load synonym/stopword rules: %v", err)
+	}
+	rewrites, err := loadRewriteRules(*rewriteRulesFile)
+	if err != nil {
+		logWarnf("Warning: failed to load query rewrite rules from %s: %v", *rewriteRulesFile, err)
+	}
+
+	prep := &entryPreparer{aliases: aliases, rules: rules}
+
+	for _, src := range sources {
+		key := src.Name()
+		if !platformEnabled(key) {
+			continue
+		}
+		if _, dup := tempSources[key]; dup {
+			logWarnf("Warning: source %s conflicts with an existing platform, skipped", key)
+			continue
+		}
+		entries, err := src.List()
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logWarnf("Warning: failed to load source %s: %v", key, err)
+			}
+			continue
+		}
+		tempSources[key] = src
+		if d, ok := src.(interface{ Dir() string }); ok {
+			tempPaths[key] = d.Dir()
+		}
+
+		prep.prepare(key, entries, times, infos, localRoot != "")
+		tempStore[key] = entries
+	}
+
+	// -repo 注册的仓库各自建立索引，条目标记来源仓库后并入对应平台
+	var repoPlatforms map[string]map[string]Source
+	if !replicaMode() {
+		repoPlatforms = loadRepos(tempStore, prep)
+	}
+
+	if localRoot != "" {
+		commit = readCommitInfo(localRoot)
+	}
+	var content *contentStats
+	if *indexLyrics {
+		content = newContentStats(infos)
+	}
+
+	total := 0
+	for _, entries := range tempStore {
+		total += len(entries)
+	}
+
+	// 新状态已全部构建完成，在写锁内一次性替换，并清空基于旧索引的查询缓存
+	swapStart := time.Now()
+	mu.Lock()
+	previous, reloaded := platforms, !lastUpdateTime.IsZero()
+	actualDataDir = root
+	dataStore = tempStore
+	platformPaths = tempPaths
+	platformSources = tempSources
+	repoSources = repoPlatforms
+	s3RootSources = rootSources
+	dataCommit = commit
+	platforms = sortPlatforms(slices.Collect(maps.Keys(tempStore)))
+	artistAliases = aliases
+	activeTextRules = rules
+	activeRewriteRules = rewrites
+	lyricContentStats = content
+	lastUpdateTime = time.Now()
+	resetQueryCache()
+	mu.Unlock()
+	lastReloadSwap.Store(int64(time.Since(swapStart)))
+	setMetadataIssues(prep.issues)
+	if reloaded {
+		announcePlatformChanges(previous, platformList())
+		announceCacheCleared()
+	}
+
+	logInfof("Metadata reloaded. Root: %s, Total entries: %d", root, total)
+	publishEvent("reload", map[string]interface{}{"root": root, "total": total})
+	runHooks(&reloadHooks, ReloadEvent{Root: root, Entries: total})
+}
+
+// platformCounts 返回各平台当前的条目数
+func platformCounts() map[string]int {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	counts := make(map[string]int, len(dataStore))
+	for k, v := range dataStore {
+		counts[k] = len(v)
+	}
+	return counts
+}
+
+func getTotalCount() int {
+	count := 0
+	for _, v := range dataStore {
+		count += len(v)
+	}
+	return count
+}
+
+// --- 查询缓存管理 ---
+
+// cacheGeneration 返回查询缓存的代数，每次清空缓存时加一
+func cacheGeneration() uint64 {
+	queryCacheMu.RLock()
+	defer queryCacheMu.RUnlock()
+	return queryCacheGen
+}
+
+func getFromCache(query string) ([]SearchResult, bool) {
+	queryCacheMu.RLock()
+	defer queryCacheMu.RUnlock()
+
+	if results, ok := queryCache[query]; ok {
+		if time.Since(queryTimestamp[query]) < queryCacheTTL {
+			return results, true
+		}
+	}
+	return nil, false
+}
+
+// saveToCache 缓存搜索结果；gen 为搜索开始时的缓存代数，期间缓存被清空（如重新加载了索引）时不再保存旧结果
+func saveToCache(query string, results []SearchResult, gen uint64) {
+	queryCacheMu.Lock()
+	defer queryCacheMu.Unlock()
+	if gen != queryCacheGen {
+		logDebugf("Not caching results for %s: index reloaded during the search", query)
+		return
+	}
+
+	queryCache[query] = results
+	queryTimestamp[query] = time.Now()
+
+	// 清理过期缓存
+	if len(queryCache) > 1000 {
+		now := time.Now()
+		for k, t := range queryTimestamp {
+			if now.Sub(t) > queryCacheTTL {
+				delete(queryCache, k)
+				delete(queryTimestamp, k)
+			}
+		}
+	}
+}
+
+func clearCache() {
+	resetQueryCache()
+	announceCacheCleared()
+}
+
+// resetQueryCache 清空查询缓存并增加缓存代数，可在持有 mu 时调用
+func resetQueryCache() {
+	queryCacheMu.Lock()
+	defer queryCacheMu.Unlock()
+
+	queryCache = make(map[string][]SearchResult)
+	queryTimestamp = make(map[string]time.Time)
+	queryCacheGen++
+}
+
+func announceCacheCleared() {
+	logInfof("Query cache cleared")
+	publishEvent("cache-cleared", nil)
+}
+
+// --- 中间件 ---
+
+func Middleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start, epoch := time.Now(), reloadEpoch.Load()
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-ID, X-Request-Deadline-Ms, Request-Timeout")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, "+strings.Join(downloadExposedHeaders, ", "))
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		r = withRequestID(w, r)
+		defer recoverPanic(w, r)
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		sw := &statusWriter{ResponseWriter: w}
+		next(sw, r)
+		elapsed := time.Since(start)
+		observeReloadLatency(r.URL.Path, epoch, elapsed)
+		logAccess(r, sw.status, elapsed)
+	}
+}
+
+// allowMethods 限定处理器接受的 HTTP 方法，其余方法返回 405 并附带 Allow 头
+func allowMethods(next http.HandlerFunc, methods ...string) http.HandlerFunc {
+	allow := strings.Join(methods, ", ")
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, m := range methods {
+			if r.Method == m {
+				next(w, r)
+				return
+			}
+		}
+		w.Header().Set("Allow", allow)
+		writeError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method "+r.Method+" not allowed, use "+allow)
+	}
+}
+
+// --- 接口处理器 ---
+
+// statusSnapshot 汇总服务器当前状态，HTTP 与 gRPC 接口共用
+func statusSnapshot() map[string]interface{} {
+	disk := currentDiskUsage() // 可能需要遍历数据目录，不在读锁内进行
+	mu.RLock()
+	defer mu.RUnlock()
+
+	stats := make(map[string]int)
+	for k, v := range dataStore {
+		stats[k] = len(v)
+	}
+	updateTime := lastUpdateTime.Format(time.RFC3339)
+	if *legacyTimes {
+		updateTime = lastUpdateTime.Format("2006-01-02 15:04:05")
+	}
+	var commitTime string
+	if !dataCommit.Time.IsZero() {
+		commitTime = dataCommit.Time.UTC().Format(time.RFC3339)
+	}
+
+	queryCacheMu.RLock()
+	cacheSize := len(queryCache)
+	queryCacheMu.RUnlock()
+
+	return map[string]interface{}{
+		"status":             "active",
+		"last_update_time":   updateTime,
+		"started_at":         startedAt.Format(time.RFC3339),
+		"uptime_seconds":     int64(time.Since(startedAt).Seconds()),
+		"total_entries":      getTotalCount(),
+		"platform_stats":     stats,
+		"repo_url":           repoURL,
+		"data_commit":        dataCommit.Hash,
+		"data_commit_time":   commitTime,
+		"data_branch":        dataCommit.Branch,
+		"cache_size":         cacheSize,
+		"metadata_issues":    metadataIssueCount(),
+		"allocations":        allocationStats(),
+		"slow_queries":       slowQueryCount.Load(),
+		"access_log_skipped": accessLogSkipped.Load(),
+		"in_flight":          inFlightStatus(),
+		"repos":              repoStatuses(),
+		"reload":             reloadStatus(),
+		"consistency":        consistencyStatus(),
+		"disk":               disk,
+	}
+}
+
+func statusHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, statusSnapshot())
+}
+
+func searchHandler(w http.ResponseWriter, r *http.Request) {
+	// 添加上下文超时控制（客户端可通过请求头缩短）
+	ctx, cancel := withRequestDeadline(r)
+	defer cancel()
+
+	var query string
+	var opts searchOptions
+	var fields fieldQuery
+	var groupBy, cursor, sortBy, musicID, dedupe string
+	var rawSources []string
+
+	if r.Method == http.MethodPost {
+		var body struct {
+			Query          string   `json:"query"`
+			Platforms      []string `json:"platforms"`
+			Limit          int      `json:"limit"`
+			MinScore       float64  `json:"minScore"`
+			GroupBy        string   `json:"groupBy"`
+			Dedupe         string   `json:"dedupe"`
+			Cursor         string   `json:"cursor"`
+			Sort           string   `json:"sort"`
+			Lang           string   `json:"lang"`
+			HasTranslation *bool    `json:"hasTranslation"`
+			HasRoman       *bool    `json:"hasRoman"`
+			WordTimed      *bool    `json:"wordTimed"`
+			MusicID        string   `json:"musicId"`
+			Sources        []string `json:"sources"`
+			Phonetic       bool     `json:"phonetic"`
+			fieldQuery
+		}
+		if err := decodeJSONBody(r, &body); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid request body: "+err.Error())
+			return
+		}
+		query = body.Query
+		groupBy = body.GroupBy
+		dedupe = body.Dedupe
+		cursor = body.Cursor
+		sortBy = body.Sort
+		musicID = body.MusicID
+		opts = searchOptions{
+			Platforms:      body.Platforms,
+			Limit:          body.Limit,
+			MinScore:       body.MinScore,
+			Lang:           body.Lang,
+			HasTranslation: body.HasTranslation,
+			HasRoman:       body.HasRoman,
+			WordTimed:      body.WordTimed,
+			Phonetic:       body.Phonetic,
+		}
+		fields = body.fieldQuery
+		rawSources = body.Sources
+	} else {
+		query = r.URL.Query().Get("query")
+		groupBy = r.URL.Query().Get("groupBy")
+		dedupe = r.URL.Query().Get("dedupe")
+		cursor = r.URL.Query().Get("cursor")
+		sortBy = r.URL.Query().Get("sort")
+		musicID = r.URL.Query().Get("musicId")
+		opts.Lang = r.URL.Query().Get("lang")
+		opts.Platforms = r.URL.Query()["platforms"]
+		rawSources = r.URL.Query()["source"]
+		if v := r.URL.Query().Get("limit"); v != "" {
+			var err error
+			if opts.Limit, err = strconv.Atoi(v); err != nil {
+				writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "limit must be a non-negative integer")
+				return
+			}
+		}
+		if v := r.URL.Query().Get("minScore"); v != "" {
+			var err error
+			if opts.MinScore, err = strconv.ParseFloat(v, 64); err != nil {
+				writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "minScore must be a number")
+				return
+			}
+		}
+		var err error
+		if opts.HasTranslation, err = queryBool(r, "hasTranslation"); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "hasTranslation must be true or false")
+			return
+		}
+		if opts.HasRoman, err = queryBool(r, "hasRoman"); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "hasRoman must be true or false")
+			return
+		}
+		if opts.WordTimed, err = queryBool(r, "wordTimed"); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "wordTimed must be true or false")
+			return
+		}
+		phonetic, err := queryBool(r, "phonetic")
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "phonetic must be true or false")
+			return
+		}
+		opts.Phonetic = phonetic != nil && *phonetic
+	}
+	var unknown string
+	if opts.Sources, unknown = parseSources(rawSources); unknown != "" {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "Unknown source: "+unknown)
+		return
+	}
+	if opts.Limit < 0 {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "limit must be a non-negative integer")
+		return
+	}
+	if opts.MinScore < 0 || opts.MinScore > 1 {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "minScore must be between 0 and 1")
+		return
+	}
+	if opts.Lang != "" && !slices.Contains(searchLanguages, opts.Lang) {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "lang must be one of "+strings.Join(searchLanguages, ", "))
+		return
+	}
+	if groupBy != "" && groupBy != "song" {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "groupBy must be \"song\"")
+		return
+	}
+	if dedupe != "" && !slices.Contains(dedupeModes, dedupe) {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "dedupe must be \"content\"")
+		return
+	}
+	if sortBy == "" {
+		sortBy = "score"
+	}
+	if _, ok := searchSorts[sortBy]; !ok {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "sort must be one of score, updated, wordTimed")
+		return
+	}
+	var after *searchCursor
+	if cursor != "" {
+		var err error
+		if after, err = decodeCursor(cursor); err != nil || after.Sort != sortBy {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid cursor")
+			return
+		}
+	}
+
+	rawQuery := strings.TrimSpace(query)
+	query = strings.ToLower(rawQuery)
+	structured := !fields.empty()
+	if structured && query != "" {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "query cannot be combined with title/artists/album")
+		return
+	}
+	if query == "" && !structured {
+		writeJSON(w, r, map[string]interface{}{"status": "success", "count": 0, "results": []SearchResult{}})
+		return
+	}
+	if err := validateQuery(rawQuery, fields); err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+
+	// 分页基于完整的排序结果（会被缓存），limit 仅决定每页的大小
+	pageSize := opts.Limit
+	opts.Limit = 0
+
+	var outcome searchOutcome
+	var err error
+	if structured {
+		outcome, err = runFieldSearch(ctx, fields, opts)
+	} else {
+		outcome, err = runSearch(ctx, query, opts)
+	}
+	if err != nil {
+		writeError(w, r, http.StatusGatewayTimeout, codeSearchTimeout, "Search timeout")
+		return
+	}
+
+	results := outcome.Results
+	// 并入对等实例的结果（来自其他实例的联邦请求不再转发）
+	var failedPeers []string
+	if len(peers) > 0 && r.Header.Get(federatedHeader) == "" {
+		results, failedPeers = federatedSearch(ctx, results, query, fields, opts)
+	}
+	// 本地无结果时查询回退 API（仅第一页；语言与歌词特征筛选无法作用于回退结果）
+	if len(results) == 0 && after == nil && fallbackEnabled() && opts.Lang == "" &&
+		opts.HasTranslation == nil && opts.HasRoman == nil && opts.WordTimed == nil &&
+		(len(opts.Sources) == 0 || slices.Contains(opts.Sources, fallbackPlatform)) {
+		fallback, err := fallbackSearch(ctx, query, fields, opts)
+		if err != nil {
+			logErrorf("Fallback search failed: %v", err)
+		}
+		results = fallback
+	}
+	results = filterResultSources(results, opts.Sources)
+	if after == nil {
+		recordQuery(analyticsKey(rawQuery, fields), len(results) > 0)
+	}
+	var missing *missingRequest
+	if len(results) == 0 && after == nil && *submitHints {
+		missing = newMissingRequest(rawQuery, fields, opts.Platforms, musicID)
+		recordMissing(missing)
+	}
+	if sortBy != "score" {
+		results = sortedResults(results, searchSorts[sortBy])
+	}
+	if dedupe == "content" {
+		results = dedupeByContent(results)
+	}
+	page := paginate(results, sortBy, after, pageSize)
+	resp := map[string]interface{}{
+		"status":    "success",
+		"count":     len(page.Results),
+		"total":     page.Total,
+		"results":   page.Results,
+		"truncated": page.HasMore,
+		"hasMore":   page.HasMore,
+	}
+	if page.HasMore {
+		resp["nextCursor"] = page.NextCursor
+	}
+	if after != nil && after.Version != indexVersion() {
+		resp["indexChanged"] = true
+	}
+	if *enrichEnabled {
+		page.Results = enrichResults(ctx, page.Results)
+	}
+	page.Results = withDownloadURLs(withParsedFields(page.Results))
+	resp["results"] = page.Results
+	if groupBy == "song" {
+		resp["results"] = groupBySong(page.Results)
+	}
+	if outcome.Cached {
+		resp["cached"] = true
+	}
+	if len(failedPeers) > 0 {
+		resp["failedPeers"] = failedPeers
+	}
+	if missing != nil && missing.SubmitURL != "" {
+		resp["submitUrl"] = missing.SubmitURL
+	}
+	runHooks(&searchHooks, SearchEvent{Request: r, Query: analyticsKey(rawQuery, fields), Results: page.Total, Cached: outcome.Cached})
+	writeSearchResponse(w, r, resp, page, outcome.Cached)
+}
+
+// queryBool 解析可选的布尔查询参数，未提供时返回 nil
+func queryBool(r *http.Request, name string) (*bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+var (
+	errInvalidPlatform = errors.New("invalid platform")
+	errLyricNotFound   = errors.New("lyric file not found")
+)
+
+// lookupEntry 按平台与歌曲 ID 精确查找索引条目
+func lookupEntry(platform, musicId string) (IndexEntry, error) {
+	platform = resolvePlatform(platform)
+	mu.RLock()
+	defer mu.RUnlock()
+
+	data, ok := dataStore[platform]
+	if !ok {
+		return IndexEntry{}, errInvalidPlatform
+	}
+	for _, entry := range data {
+		if entry.ID == musicId {
+			return entry, nil
+		}
+	}
+	return IndexEntry{}, errLyricNotFound
+}
+
+// resolveLyricFile 根据平台、歌曲 ID 与格式定位歌词文件路径，format 为空时默认为 ttml
+func resolveLyricFile(platform, musicId, format string) (string, error) {
+	if format == "" {
+		format = "ttml"
+	}
+
+	mu.RLock()
+	src, ok := platformSources[resolvePlatform(platform)]
+	mu.RUnlock()
+
+	if !ok {
+		return "", errInvalidPlatform
+	}
+	path, err := src.Get(musicId, format)
+	// 文件不存在可能是因为数据目录在运行期间被移动或重建，此时重新探测并加载后再试一次
+	if errors.Is(err, errLyricNotFound) && redetectDataDir() {
+		mu.RLock()
+		src, ok = platformSources[resolvePlatform(platform)]
+		mu.RUnlock()
+		if !ok {
+			return "", errInvalidPlatform
+		}
+		return src.Get(musicId, format)
+	}
+	return path, err
+}
+
+// lookupHandler 按平台与歌曲 ID 精确查询索引条目，与 gRPC 的 Lookup 对应
+func lookupHandler(w http.ResponseWriter, r *http.Request) {
+	platform := r.URL.Query().Get("platform")
+	entry, err := lookupEntry(platform, r.URL.Query().Get("musicId"))
+	switch {
+	case errors.Is(err, errInvalidPlatform):
+		writeError(w, r, http.StatusBadRequest, codePlatformInvalid, "Invalid platform")
+		return
+	case err != nil:
+		writeError(w, r, http.StatusNotFound, codeLyricNotFound, "Entry not found")
+		return
+	}
+	results := withDownloadURLs(withParsedFields([]SearchResult{{
+		ID:             entry.ID,
+		RawLyricFile:   entry.RawLyricFile,
+		Metadata:       entry.MetadataRaw,
+		Platforms:      []string{resolvePlatform(platform)},
+		PlatformIDs:    map[string]string{resolvePlatform(platform): entry.ID},
+		Updated:        entry.Updated,
+		Lang:           entry.Lang,
+		HasTranslation: entry.HasTranslation,
+		HasRoman:       entry.HasRoman,
+		WordTimed:      entry.WordTimed,
+		FileSize:       entry.FileSize,
+		FileMtime:      entry.FileMtime,
+		Source:         entrySource(&entry),
+	}}))
+	writeJSON(w, r, results[0])
+}
+
+func downloadHandler(w http.ResponseWriter, r *http.Request) {
+	if *noDownload {
+		writeError(w, r, http.StatusForbidden, codeDownloadDisabled, "Download API is disabled by server configuration")
+		return
+	}
+
+	var platform, musicId, format, source string
+	var opts convertOptions
+	if r.Method == http.MethodPost {
+		var body struct {
+			Platform string `json:"platform"`
+			MusicID  string `json:"musicId"`
+			Format   string `json:"format"`
+			Source   string `json:"source"`
+			convertOptions
+		}
+		if err := decodeJSONBody(r, &body); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "Invalid request body: "+err.Error())
+			return
+		}
+		platform, musicId, format, source = body.Platform, body.MusicID, body.Format, body.Source
+		opts = body.convertOptions
+	} else {
+		platform = r.URL.Query().Get("platform")
+		musicId = r.URL.Query().Get("musicId")
+		format = r.URL.Query().Get("format")
+		source = r.URL.Query().Get("source")
+		opts.Layer = r.URL.Query().Get("layer")
+		var err error
+		if opts.timingTransform, err = parseTimingQuery(r.URL.Query()); err != nil {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+			return
+		}
+	}
+	if !validDownloadFormat(format) {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "format must be one of auto, "+strings.Join(lyricFileFormats, ", "))
+		return
+	}
+	if err := opts.validate(); err != nil {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	if err := verifyDownloadSignature(r.URL.Query(), platform, musicId, format, source); err != nil {
+		writeError(w, r, http.StatusForbidden, codeSignatureInvalid, err.Error())
+		return
+	}
+
+	resolve := func(format string) (string, error) { return resolveLyricFile(platform, musicId, format) }
+	switch _, isRepo := findRepo(source); {
+	case isRepo:
+		resolve = func(format string) (string, error) { return resolveRepoLyricFile(source, platform, musicId, format) }
+	case isLocalOrigin(source):
+		resolve = func(format string) (string, error) { return resolveOriginLyricFile(source, platform, musicId, format) }
+	case source != "" && source != fallbackPlatform:
+		// 来自对等实例的结果由该实例提供文件
+		p, ok := findPeer(source)
+		if !ok {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "Unknown source")
+			return
+		}
+		if opts.Translation != "" {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "Translation merge is not supported for peer sources")
+			return
+		}
+		redirectToPeer(w, r, p, platform, musicId, format, opts)
+		return
+	}
+
+	// format=auto 时取第一个存在的歌词文件，并根据内容报告其实际格式
+	var filePath, detected string
+	var err error
+	if format == "auto" {
+		filePath, detected, err = resolveAutoLyricFile(resolve)
+	} else {
+		filePath, err = resolve(format)
+	}
+	if errors.Is(err, errInvalidPlatform) && resolvePlatform(platform) == fallbackPlatform && fallbackEnabled() {
+		if format == "auto" {
+			format = ""
+		}
+		serveFallbackLyric(w, r, musicId, format, opts)
+		return
+	}
+	switch {
+	case errors.Is(err, errInvalidPlatform):
+		writeError(w, r, http.StatusBadRequest, codePlatformInvalid, "Invalid platform")
+		return
+	case err != nil:
+		writeError(w, r, http.StatusNotFound, codeLyricNotFound, "Lyric file not found")
+		return
+	}
+	if r.Method != http.MethodHead {
+		recordDownload(resolvePlatform(platform), musicId)
+		runHooks(&downloadHooks, DownloadEvent{Request: r, Platform: resolvePlatform(platform), MusicID: musicId, Format: format})
+	}
+
+	if !opts.isZero() {
+		serveConvertedLyric(w, r, platform, musicId, filePath, detected, opts)
+		return
+	}
+
+	if *downloadMode == "redirect" {
+		if u, ok := cdnURL(filePath); ok {
+			http.Redirect(w, r, u, http.StatusFound)
+			return
+		}
+	}
+
+	filename := filepath.Base(filePath)
+	if detected != "" {
+		filename = strings.TrimSuffix(filename, filepath.Ext(filename)) + "." + detected
+		w.Header().Set(headerFormat, detected)
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	setDownloadHeaders(w, platform, musicId, filePath)
+	http.ServeFile(w, r, filePath)
+}
+
+func formatsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, lyricFileFormats)
+}
+
+// runUpdate 同步数据仓库，有更新时重新加载索引、清除缓存并推送更新事件
+func runUpdate() bool {
+	if !syncRepo() {
+		return false
+	}
+	before := platformCounts()
+	loadMetadata()
+
+	after := platformCounts()
+	total := 0
+	deltas := make(map[string]int, len(after))
+	for k, v := range after {
+		deltas[k] = v - before[k]
+		total += v
+	}
+	for k, v := range before {
+		if _, ok := after[k]; !ok {
+			deltas[k] = -v
+		}
+	}
+	var added []string
+	for k := range after {
+		if _, ok := before[k]; !ok {
+			added = append(added, k)
+		}
+	}
+	publishEvent("index-updated", map[string]interface{}{
+		"commit":          currentCommit(actualDataDir),
+		"entry_deltas":    deltas,
+		"total":           total,
+		"added_platforms": sortPlatforms(added),
+	})
+	return true
+}
+
+func updateHandler(w http.ResponseWriter, r *http.Request) {
+	if *noSync {
+		auditHTTP(r, "update", "rejected", "git sync disabled")
+		writeError(w, r, http.StatusForbidden, codeSyncDisabled, "Git sync is disabled by server configuration")
+		return
+	}
+
+	if r.URL.Query().Get("dryRun") == "true" {
+		if wait := reserveDryRun(); wait > 0 {
+			secs := retryAfterSeconds(wait)
+			auditHTTP(r, "update-dry-run", "rejected", "cooldown")
+			w.Header().Set("Retry-After", strconv.Itoa(secs))
+			writeError(w, r, http.StatusTooManyRequests, codeUpdateCooldown, fmt.Sprintf("Update was triggered recently, retry in %d seconds", secs))
+			return
+		}
+		preview, err := previewSync()
+		if err != nil {
+			auditHTTP(r, "update-dry-run", "failed", err.Error())
+			writeError(w, r, http.StatusBadGateway, codeSyncFailed, err.Error())
+			return
+		}
+		auditHTTP(r, "update-dry-run", "previewed", "")
+		writeJSON(w, r, preview)
+		return
+	}
+
+	res := triggerManualUpdate()
+	if res.RetryAfter > 0 {
+		secs := retryAfterSeconds(res.RetryAfter)
+		auditHTTP(r, "update", "rejected", "cooldown")
+		w.Header().Set("Retry-After", strconv.Itoa(secs))
+		writeError(w, r, http.StatusTooManyRequests, codeUpdateCooldown, fmt.Sprintf("Update was triggered recently, retry in %d seconds", secs))
+		return
+	}
+	detail := ""
+	if res.Coalesced {
+		detail = "coalesced with a running sync"
+	}
+	auditHTTP(r, "update", updateOutcome(res.Updated), detail)
+	if res.Updated {
+		writeJSON(w, r, map[string]string{"message": "Update successful and metadata reloaded"})
+	} else {
+		writeJSON(w, r, map[string]string{"message": "Already up to date"})
+	}
+}
+
+// --- 命令行入口 ---
+
+// Main 解析命令行参数并执行对应的子命令（未指定时为 serve），供可执行文件的 main 调用
+func Main(args []string) {
+	log.SetFlags(log.LstdFlags)
+	runCommand(args)
+}
+
+// checkRequiredData 在 -require-data 下确认启动时已加载到数据，否则返回原因；
+// serve 子命令据此以非零状态退出，便于编排系统重启或告警，而不是静默地返回空结果
+func checkRequiredData() error {
+	if !*noSync && !isS3URL(*inputDataDir) && !replicaMode() {
+		if _, err := os.Stat(filepath.Join(*inputDataDir, ".git")); err != nil {
+			return fmt.Errorf("initial clone of %s into %s failed", repoURL, *inputDataDir)
+		}
+	}
+	mu.RLock()
+	root, total := actualDataDir, getTotalCount()
+	mu.RUnlock()
+	switch {
+	case root == "":
+		return fmt.Errorf("no valid data directory found (checked -data-dir %s)", *inputDataDir)
+	case total == 0:
+		return fmt.Errorf("no index entries loaded from %s", root)
+	}
+	return nil
+}
+
+// runServe 启动 HTTP API 服务（serve 子命令，也是未指定子命令时的默认行为）
+func runServe(args []string) {
+	logInfof("Starting AMLL TTML API Server (Optimized)...")
+
+	// 1. 解析参数，执行首次同步并加载元数据
+	srv, err := New(WithArgs(args...), withCommandLine())
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// stdio 模式下不监听任何端口
+	if *stdioMode {
+		ctx, cancel := context.WithCancel(context.Background())
+		startBackgroundTasks(ctx)
+		runStdioMode()
+		cancel()
+		wait, cancelWait := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancelWait()
+		waitStatsSaved(wait)
+		return
+	}
+
+	// 2. 启动后台任务与各端口的服务，收到 SIGINT、SIGTERM 后等待进行中的请求完成再退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := srv.Start(); err != nil {
+		log.Fatal(err)
+	}
+	select {
+	case <-ctx.Done():
+		logInfof("Shutting down...")
+	case err := <-srv.serveErr:
+		log.Fatalf("Server failed: %v", err)
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Stop(shutdownCtx); err != nil {
+		logWarnf("Warning: shutdown did not complete cleanly: %v", err)
+	}
+}
+
+// validateServeFlags 检查 serve 参数中的枚举值与地址，并读取 -api-keys 与 TLS 证书
+func validateServeFlags() error {
+	if !slices.Contains(downloadModes, *downloadMode) {
+		return fmt.Errorf("invalid -download-mode %q, expected one of %s", *downloadMode, strings.Join(downloadModes, ", "))
+	}
+	if *cdnProvider != "jsdelivr" && *cdnProvider != "github" {
+		return fmt.Errorf("invalid -cdn %q, expected jsdelivr or github", *cdnProvider)
+	}
+	if *searchTimeout <= 0 {
+		return fmt.Errorf("invalid -search-timeout %v, expected a positive duration", *searchTimeout)
+	}
+	if err := validatePublicURL(); err != nil {
+		return fmt.Errorf("invalid -public-url %q: %v", *publicURL, err)
+	}
+	if err := loadAPIKeys(); err != nil {
+		return fmt.Errorf("invalid -api-keys %s: %v", *apiKeysFile, err)
+	}
+	if *stdioMode && len(apiKeys) > 0 {
+		return errors.New("-stdio cannot be combined with -api-keys: JSON-RPC requests carry no API key")
+	}
+	return loadTLSConfig()
+}
+
+// loadInitialData 执行启动时的首次同步并加载索引；-require-data 下没有可用数据时返回原因
+func loadInitialData() error {
+	if !*noSync {
+		syncRepo()
+	}
+	loadMetadata()
+	if *requireData {
+		if err := checkRequiredData(); err != nil {
+			return fmt.Errorf("-require-data: %w", err)
+		}
+	}
+	return nil
+}
+
+// startBackgroundTasks 启动定时同步、附加数据源监听、一致性自检与统计持久化等后台任务；
+// ctx 取消后定时同步与各类监听停止，统计数据最后保存一次（见 waitStatsSaved）
+func startBackgroundTasks(ctx context.Context) {
+	startWebhooks()
+	startAnalytics(ctx)
+	startDownloadStats(ctx)
+	startAPIKeyUsage(ctx)
+	watchSources(ctx)
+	watchOverlays(ctx)
+	watchRepos(ctx)
+	watchConsistency(ctx)
+	go watchS3Root(ctx)
+	go watchPrimary(ctx)
+	if !*noSync {
+		go func() {
+			ticker := time.NewTicker(*syncInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					runCoalescedUpdate()
+					maybePruneDataRepo()
+				}
+			}
+		}()
+	}
+}
+
+// newServeMux 注册全部 HTTP 路由；指定 -admin-addr 时管理与诊断路由只在管理端口上提供（见 newAdminMux）
+func newServeMux() *http.ServeMux {
+	const (
+		get  = http.MethodGet
+		head = http.MethodHead
+		post = http.MethodPost
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/status", Middleware(allowMethods(statusHandler, get, head)))
+	mux.HandleFunc("/api/search", Middleware(allowMethods(requireAPIKey(limitInFlight(searchInFlight, searchHandler)), get, post)))
+	mux.HandleFunc("/api/download", Middleware(allowMethods(requireAPIKey(limitInFlight(downloadInFlight, downloadHandler)), get, head, post)))
+	mux.HandleFunc("/api/lookup", Middleware(allowMethods(requireAPIKey(lookupHandler), get, head)))
+	mux.HandleFunc("/api/formats", Middleware(allowMethods(formatsHandler, get, head)))
+	mux.Handle("/api/ws", wsHandler)
+	mux.HandleFunc("/api/events", Middleware(allowMethods(eventsHandler, get)))
+	mux.HandleFunc("/api/missing", Middleware(allowMethods(requireAPIKey(missingHandler), get, head)))
+	mux.HandleFunc("/api/validate-lyric", Middleware(allowMethods(requireAPIKey(validateLyricHandler), get, post)))
+	mux.HandleFunc("/api/lyric-stats", Middleware(allowMethods(requireAPIKey(lyricStatsHandler), get, post)))
+	if *adminAddr == "" {
+		registerAdminRoutes(mux)
+	}
+	return mux
+}
@@ -0,0 +1,52 @@
+package server
+
+import "bytes"
+
+// --- 字节匹配 ---
+
+// horspoolMinLen 查询长度达到该值时改用 Boyer-Moore-Horspool 算法，
+// 短查询直接使用 bytes.Index（标准库已针对短模式做了向量化优化）
+const horspoolMinLen = 16
+
+// matcher 针对单个查询预先计算匹配所需的数据，扫描过程中不产生任何分配
+type matcher struct {
+	pattern []byte
+	long    bool
+	shift   [256]int // Horspool 坏字符跳转表
+}
+
+func newMatcher(query string) *matcher {
+	m := &matcher{pattern: []byte(query)}
+	if len(m.pattern) >= horspoolMinLen {
+		m.useHorspool()
+	}
+	return m
+}
+
+// useHorspool 计算坏字符跳转表，使 match 改用 Horspool 算法
+func (m *matcher) useHorspool() {
+	m.long = true
+	n := len(m.pattern)
+	for i := range m.shift {
+		m.shift[i] = n
+	}
+	for i := 0; i < n-1; i++ {
+		m.shift[m.pattern[i]] = n - 1 - i
+	}
+}
+
+// match 判断 text 中是否包含查询（text 应为预处理后的小写数据）
+func (m *matcher) match(text []byte) bool {
+	if !m.long {
+		return bytes.Index(text, m.pattern) >= 0
+	}
+
+	n := len(m.pattern)
+	last := n - 1
+	for i := 0; i+n <= len(text); i += m.shift[text[i+last]] {
+		if text[i+last] == m.pattern[last] && bytes.Equal(text[i:i+last], m.pattern[:last]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// benchmarkText 模拟一条条目预处理后的可搜索文本（小写的歌名、歌手、专辑等）
+var benchmarkText = []byte(strings.Repeat("song title artist name album name the little yellow flower drifting ", 8))
+
+// BenchmarkMatcher 在 horspoolMinLen（16 字节）两侧比较 bytes.Index 与 Horspool，用于确认切换阈值；
+// 查询取自文本片段并改动末尾字节，频繁部分命中却不出现在文本中，需要扫描全文
+func BenchmarkMatcher(b *testing.B) {
+	for _, n := range []int{4, 8, 12, 15, 16, 20, 32, 64} {
+		pattern := string(benchmarkText[5:5+n-1]) + "~"
+		for _, impl := range []string{"index", "horspool"} {
+			m := &matcher{pattern: []byte(pattern)}
+			if impl == "horspool" {
+				m.useHorspool()
+			}
+			if m.match(benchmarkText) != (bytes.Index(benchmarkText, m.pattern) >= 0) {
+				b.Fatalf("%s disagrees with bytes.Index for %q", impl, pattern)
+			}
+			b.Run(fmt.Sprintf("len=%d/%s", n, impl), func(b *testing.B) {
+				b.SetBytes(int64(len(benchmarkText)))
+				for i := 0; i < b.N; i++ {
+					m.match(benchmarkText)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// --- 类型化元数据 ---
+
+// Metadata 是 index.jsonl 中 [key, [values...]] 元数据对解析后的结构，所有字段均可能有多个值
+type Metadata struct {
+	MusicName             []string            `json:"musicName,omitempty"`
+	Artists               []string            `json:"artists,omitempty"`
+	Album                 []string            `json:"album,omitempty"`
+	NCMMusicID            []string            `json:"ncmMusicId,omitempty"`
+	QQMusicID             []string            `json:"qqMusicId,omitempty"`
+	AppleMusicID          []string            `json:"appleMusicId,omitempty"`
+	SpotifyID             []string            `json:"spotifyId,omitempty"`
+	ISRC                  []string            `json:"isrc,omitempty"`
+	TTMLAuthorGithub      []string            `json:"ttmlAuthorGithub,omitempty"`
+	TTMLAuthorGithubLogin []string            `json:"ttmlAuthorGithubLogin,omitempty"`
+	Extra                 map[string][]string `json:"extra,omitempty"` // 未识别的键
+}
+
+// metadataIssue 记录一条未通过元数据校验的条目
+type metadataIssue struct {
+	Platform string `json:"platform"`
+	ID       string `json:"id"`
+	Error    string `json:"error"`
+}
+
+var (
+	// 最近一次加载时收集到的元数据问题
+	metadataIssues   []metadataIssue
+	metadataIssuesMu sync.RWMutex
+)
+
+// field 返回键对应的结构体字段，未识别的键返回 nil
+func (m *Metadata) field(key string) *[]string {
+	switch key {
+	case "musicName":
+		return &m.MusicName
+	case "artists":
+		return &m.Artists
+	case "album":
+		return &m.Album
+	case "ncmMusicId":
+		return &m.NCMMusicID
+	case "qqMusicId":
+		return &m.QQMusicID
+	case "appleMusicId":
+		return &m.AppleMusicID
+	case "spotifyId":
+		return &m.SpotifyID
+	case "isrc":
+		return &m.ISRC
+	case "ttmlAuthorGithub":
+		return &m.TTMLAuthorGithub
+	case "ttmlAuthorGithubLogin":
+		return &m.TTMLAuthorGithubLogin
+	}
+	return nil
+}
+
+// parseMetadata 将原始元数据对解析为 Metadata，结构不符合 [key, [values...]] 时返回错误
+func parseMetadata(raw [][]interface{}) (Metadata, error) {
+	var m Metadata
+	for i, pair := range raw {
+		if len(pair) != 2 {
+			return m, fmt.Errorf("metadata pair %d does not have exactly 2 elements", i)
+		}
+		key, ok := pair[0].(string)
+		if !ok {
+			return m, fmt.Errorf("metadata pair %d has a non-string key", i)
+		}
+		values, ok := pair[1].([]interface{})
+		if !ok {
+			return m, fmt.Errorf("metadata pair %d has a non-array value", i)
+		}
+		strs := make([]string, 0, len(values))
+		for _, v := range values {
+			s, ok := v.(string)
+			if !ok {
+				return m, fmt.Errorf("metadata pair %d contains a non-string value", i)
+			}
+			strs = append(strs, s)
+		}
+
+		if f := m.field(key); f != nil {
+			*f = append(*f, strs...)
+			continue
+		}
+		if m.Extra == nil {
+			m.Extra = make(map[string][]string)
+		}
+		m.Extra[key] = append(m.Extra[key], strs...)
+	}
+	return m, nil
+}
+
+// validate 检查必填字段
+func (m Metadata) validate() error {
+	switch {
+	case len(m.MusicName) == 0:
+		return errors.New("missing musicName")
+	case len(m.Artists) == 0:
+		return errors.New("missing artists")
+	}
+	return nil
+}
+
+// Values 按键取出字段值，未识别的键从 Extra 中查找
+func (m Metadata) Values(key string) []string {
+	if f := m.field(key); f != nil {
+		return *f
+	}
+	return m.Extra[key]
+}
+
+// withParsedFields 从原始元数据中取出第一个歌名、全部歌手与第一个专辑填入结果的便捷字段，
+// 元数据没有专辑时保留 -enrich 补全的值；返回副本，不修改缓存中的结果
+func withParsedFields(results []SearchResult) []SearchResult {
+	out := append([]SearchResult(nil), results...)
+	for i := range out {
+		r := &out[i]
+		md, _ := parseMetadata(r.Metadata)
+		if len(md.MusicName) > 0 {
+			r.Title = md.MusicName[0]
+		}
+		r.Artists = md.Artists
+		if len(md.Album) > 0 {
+			r.Album = md.Album[0]
+		}
+	}
+	return out
+}
+
+// setMetadataIssues 替换当前的元数据问题列表并记录日志
+func setMetadataIssues(issues []metadataIssue) {
+	metadataIssuesMu.Lock()
+	metadataIssues = issues
+	metadataIssuesMu.Unlock()
+
+	if len(issues) > 0 {
+		logWarnf("Warning: %d entr(ies) failed metadata validation (see /api/validate)", len(issues))
+	}
+}
+
+// replacePlatformIssues 只替换某个平台的元数据问题，用于单平台重建索引
+func replacePlatformIssues(platform string, issues []metadataIssue) {
+	metadataIssuesMu.Lock()
+	defer metadataIssuesMu.Unlock()
+	kept := make([]metadataIssue, 0, len(metadataIssues)+len(issues))
+	for _, issue := range metadataIssues {
+		if issue.Platform != platform {
+			kept = append(kept, issue)
+		}
+	}
+	metadataIssues = append(kept, issues...)
+}
+
+func metadataIssueCount() int {
+	metadataIssuesMu.RLock()
+	defer metadataIssuesMu.RUnlock()
+	return len(metadataIssues)
+}
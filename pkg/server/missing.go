@@ -0,0 +1,149 @@
+package server
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- 缺失歌词提交辅助 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	submitHints = new(bool)
+)
+
+// maxMissingRequests 为记录的缺失请求上限，超出时淘汰请求次数最少且最久未请求的一项
+const maxMissingRequests = 1000
+
+// missingRequest 为一首搜索无结果的歌曲，同样的请求累计计数
+type missingRequest struct {
+	Title         string    `json:"title,omitempty"`
+	Artists       []string  `json:"artists,omitempty"`
+	Album         string    `json:"album,omitempty"`
+	Platform      string    `json:"platform,omitempty"`
+	MusicID       string    `json:"musicId,omitempty"`
+	Count         int       `json:"count"`
+	LastRequested time.Time `json:"lastRequested"`
+	SubmitURL     string    `json:"submitUrl,omitempty"`
+}
+
+var (
+	missingRequests   = make(map[string]*missingRequest)
+	missingRequestsMu sync.Mutex
+)
+
+// newMissingRequest 由搜索参数构造缺失请求：全文搜索时查询词作为歌名；仅限定一个平台时记录该平台的歌曲 ID
+func newMissingRequest(query string, fields fieldQuery, platforms []string, musicID string) *missingRequest {
+	req := &missingRequest{Title: query}
+	if !fields.empty() {
+		req.Title, req.Artists, req.Album = fields.Title, fields.Artists, fields.Album
+	}
+	if musicID != "" && len(platforms) == 1 {
+		req.Platform, req.MusicID = resolvePlatform(platforms[0]), musicID
+	}
+	req.SubmitURL = req.issueURL()
+	return req
+}
+
+// key 为合并相同请求的键（不区分大小写与空白）
+func (m *missingRequest) key() string {
+	parts := []string{normalizeField(m.Title), normalizeField(m.Album), m.Platform, m.MusicID}
+	for _, a := range m.Artists {
+		parts = append(parts, normalizeField(a))
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// issueURL 返回上游数据仓库预填好的 GitHub Issue 地址，数据仓库不在 GitHub 上时返回空
+func (m *missingRequest) issueURL() string {
+	repo := strings.TrimSuffix(repoURL, ".git")
+	if !strings.HasPrefix(repo, "https://github.com/") {
+		return ""
+	}
+
+	title := m.Title
+	if len(m.Artists) > 0 {
+		title += " - " + strings.Join(m.Artists, " / ")
+	}
+	var body strings.Builder
+	body.WriteString("搜索时未找到以下歌曲的歌词：\n\n")
+	writeLine := func(name, value string) {
+		if value != "" {
+			body.WriteString("- " + name + "：" + value + "\n")
+		}
+	}
+	writeLine("歌名", m.Title)
+	writeLine("歌手", strings.Join(m.Artists, " / "))
+	writeLine("专辑", m.Album)
+	writeLine("平台", m.Platform)
+	writeLine("歌曲 ID", m.MusicID)
+
+	params := url.Values{"title": {"[歌词请求] " + title}, "body": {body.String()}}
+	return repo + "/issues/new?" + params.Encode()
+}
+
+// recordMissing 累计一次无结果的请求
+func recordMissing(req *missingRequest) {
+	key := req.key()
+	missingRequestsMu.Lock()
+	defer missingRequestsMu.Unlock()
+
+	if existing, ok := missingRequests[key]; ok {
+		existing.Count++
+		existing.LastRequested = time.Now()
+		return
+	}
+	if len(missingRequests) >= maxMissingRequests {
+		var victim string
+		var least *missingRequest
+		for k, v := range missingRequests {
+			if least == nil || v.Count < least.Count || (v.Count == least.Count && v.LastRequested.Before(least.LastRequested)) {
+				victim, least = k, v
+			}
+		}
+		delete(missingRequests, victim)
+	}
+	req.Count = 1
+	req.LastRequested = time.Now()
+	missingRequests[key] = req
+}
+
+// topMissing 返回请求次数最多的缺失歌曲，limit 不大于 0 时返回全部
+func topMissing(limit int) []missingRequest {
+	missingRequestsMu.Lock()
+	list := make([]missingRequest, 0, len(missingRequests))
+	for _, v := range missingRequests {
+		list = append(list, *v)
+	}
+	missingRequestsMu.Unlock()
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].LastRequested.After(list[j].LastRequested)
+	})
+	if limit > 0 && len(list) > limit {
+		list = list[:limit]
+	}
+	return list
+}
+
+func missingHandler(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "limit must be a non-negative integer")
+			return
+		}
+		limit = n
+	}
+	results := topMissing(limit)
+	writeJSON(w, r, map[string]interface{}{"status": "success", "count": len(results), "results": results})
+}
@@ -0,0 +1,221 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// --- 叠加数据目录 ---
+
+var (
+	// 命令行参数（见 registerDataFlags）
+	dataDirs dataDirFlag
+	// overlayDataDirs 为第二个及之后的 -data-dir，按顺序叠加在主数据目录之上（后指定的优先），不参与 Git 同步
+	overlayDataDirs []string
+)
+
+// dataDirFlag 为 -data-dir 的取值：第一次指定时替换默认的主数据目录，之后的每次指定追加一个叠加目录
+type dataDirFlag struct {
+	set bool
+}
+
+func (f *dataDirFlag) String() string {
+	if inputDataDir == nil {
+		return ""
+	}
+	return strings.Join(append([]string{*inputDataDir}, overlayDataDirs...), ",")
+}
+
+func (f *dataDirFlag) Set(v string) error {
+	if !f.set {
+		f.set = true
+		*inputDataDir = v
+		return nil
+	}
+	overlayDataDirs = append(overlayDataDirs, v)
+	return nil
+}
+
+// overlayRoots 返回有效的叠加目录的绝对路径，无效的目录记录警告后跳过
+func overlayRoots() []string {
+	var roots []string
+	for _, dir := range overlayDataDirs {
+		if !isDataDir(dir) {
+			logWarnf("Warning: overlay data directory %s is not a valid data directory, skipped", dir)
+			continue
+		}
+		abs, _ := filepath.Abs(dir)
+		roots = append(roots, abs)
+	}
+	return roots
+}
+
+// layeredSources 将主数据目录与叠加目录中的同名平台合并为一个数据源，只出现在叠加目录中的平台也会被加入
+func layeredSources(base []Source, roots []string) []Source {
+	if len(roots) == 0 {
+		return base
+	}
+	layers := make(map[string][]sourceLayer)
+	var names []string
+	for _, src := range base {
+		if d, ok := src.(*dirSource); ok {
+			layers[d.name] = append(layers[d.name], sourceLayer{origin: mainSourceName, dirSource: d})
+			names = append(names, d.name)
+		}
+	}
+	for _, root := range roots {
+		configs := indexFiles(root)
+		for _, key := range sortPlatforms(slices.Collect(maps.Keys(configs))) {
+			if _, err := os.Stat(configs[key]); err != nil {
+				continue
+			}
+			if _, ok := layers[key]; !ok {
+				names = append(names, key)
+			}
+			layers[key] = append(layers[key], sourceLayer{origin: overlaySourceName(root), dirSource: newDirSource(key, configs[key])})
+		}
+	}
+
+	sources := make([]Source, 0, len(names))
+	for _, name := range sortPlatforms(names) {
+		if l := layers[name]; len(l) == 1 && l[0].origin == mainSourceName {
+			sources = append(sources, l[0].dirSource)
+		} else {
+			sources = append(sources, &layeredSource{name: name, layers: l})
+		}
+	}
+	return sources
+}
+
+// overlaySourceName 返回叠加目录在结果 source 字段中的名称，即目录名
+func overlaySourceName(root string) string {
+	return strings.ToLower(filepath.Base(root))
+}
+
+// overlayNames 返回各叠加目录的来源名称（不检查目录是否有效）
+func overlayNames() []string {
+	names := make([]string, 0, len(overlayDataDirs))
+	for _, dir := range overlayDataDirs {
+		abs, _ := filepath.Abs(dir)
+		names = append(names, overlaySourceName(abs))
+	}
+	return names
+}
+
+// sourceLayer 为某个数据目录中的一个平台索引
+type sourceLayer struct {
+	origin string // 所属数据目录的来源名称（见 entrySource）
+	*dirSource
+}
+
+// layeredSource 为同一平台在多个数据目录中的索引，后面的层优先：
+// 同一 ID 的条目以最上层为准，歌词文件从上往下查找第一个存在的
+type layeredSource struct {
+	name   string
+	layers []sourceLayer
+}
+
+func (s *layeredSource) Name() string { return s.name }
+
+// Dir 返回最底层（通常为主数据目录）的歌词目录
+func (s *layeredSource) Dir() string { return s.layers[0].Dir() }
+
+// List 合并各层的条目，来自叠加目录的条目标记其来源
+func (s *layeredSource) List() ([]IndexEntry, error) {
+	var merged []IndexEntry
+	pos := make(map[string]int)
+	loaded := false
+	for _, layer := range s.layers {
+		entries, err := layer.List()
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logWarnf("Warning: failed to load %s: %v", layer.indexPath, err)
+			}
+			continue
+		}
+		loaded = true
+		for _, e := range entries {
+			if layer.origin != mainSourceName {
+				e.Source = layer.origin
+			}
+			if i, ok := pos[e.ID]; ok {
+				merged[i] = e
+				continue
+			}
+			pos[e.ID] = len(merged)
+			merged = append(merged, e)
+		}
+	}
+	if !loaded {
+		return nil, os.ErrNotExist
+	}
+	return merged, nil
+}
+
+func (s *layeredSource) Get(id, format string) (string, error) {
+	for i := len(s.layers) - 1; i >= 0; i-- {
+		if p, err := s.layers[i].Get(id, format); err == nil {
+			return p, nil
+		}
+	}
+	return "", errLyricNotFound
+}
+
+// getFrom 只在指定来源的数据目录中查找歌词文件
+func (s *layeredSource) getFrom(origin, id, format string) (string, error) {
+	for _, layer := range s.layers {
+		if layer.origin == origin {
+			return layer.Get(id, format)
+		}
+	}
+	return "", errLyricNotFound
+}
+
+// Watch 叠加目录不参与 Git 同步，由 watchOverlays 监听其索引文件
+func (s *layeredSource) Watch(ctx context.Context, changed func()) {}
+
+// watchOverlays 每 30 秒检查一次叠加目录中各平台的索引文件（包括新出现的 <平台>-lyrics 目录），
+// 有变化时重新加载索引并清除缓存
+func watchOverlays(ctx context.Context) {
+	roots := overlayRoots()
+	if len(roots) == 0 {
+		return
+	}
+	go func() {
+		last := overlayFingerprint(roots)
+		ticker := time.NewTicker(sourcePollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if fp := overlayFingerprint(roots); fp != last {
+					last = fp
+					logInfof("Overlay index changed, reloading index")
+					loadMetadata()
+				}
+			}
+		}
+	}()
+}
+
+// overlayFingerprint 汇总各叠加目录中索引文件的路径与修改时间
+func overlayFingerprint(roots []string) string {
+	var b strings.Builder
+	for _, root := range roots {
+		files := indexFiles(root)
+		for _, name := range sortPlatforms(slices.Collect(maps.Keys(files))) {
+			if info, err := os.Stat(files[name]); err == nil {
+				fmt.Fprintf(&b, "%s %d\n", files[name], info.ModTime().UnixNano())
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,219 @@
+package server
+
+import (
+	"strings"
+	"unicode"
+)
+
+// --- 拉丁字母名称的读音匹配 ---
+
+// phoneticScoreMax 为仅靠读音命中的评分上限，低于字面命中的高覆盖率结果
+const phoneticScoreMax = 0.3
+
+// metaphone 计算单个英文单词的简化 Metaphone 读音键（如 linkin → LNKN、lincoln → LNKLN），
+// 非拉丁字母被忽略；只处理常见的辅音组合，不追求 Double Metaphone 的完整规则
+func metaphone(word string) string {
+	var w []byte
+	for _, r := range strings.ToUpper(word) {
+		if r >= 'A' && r <= 'Z' {
+			w = append(w, byte(r))
+		}
+	}
+	if len(w) == 0 {
+		return ""
+	}
+	switch {
+	case len(w) > 1 && (string(w[:2]) == "KN" || string(w[:2]) == "GN" || string(w[:2]) == "PN" || string(w[:2]) == "AE" || string(w[:2]) == "WR"):
+		w = w[1:]
+	case w[0] == 'X':
+		w[0] = 'S'
+	case len(w) > 1 && string(w[:2]) == "WH":
+		w = append([]byte{'W'}, w[2:]...)
+	}
+
+	isVowel := func(i int) bool { return i >= 0 && i < len(w) && strings.IndexByte("AEIOU", w[i]) >= 0 }
+	at := func(i int) byte {
+		if i >= 0 && i < len(w) {
+			return w[i]
+		}
+		return 0
+	}
+	var key strings.Builder
+	for i, c := range w {
+		// 相邻重复字母只计一次（C 除外，如 ACCEPT）
+		if i > 0 && c == w[i-1] && c != 'C' {
+			continue
+		}
+		switch c {
+		case 'A', 'E', 'I', 'O', 'U':
+			if i == 0 {
+				key.WriteByte(c)
+			}
+		case 'B':
+			if !(i == len(w)-1 && at(i-1) == 'M') {
+				key.WriteByte('B')
+			}
+		case 'C':
+			switch {
+			case at(i+1) == 'I' && at(i+2) == 'A', at(i+1) == 'H':
+				key.WriteByte('X')
+			case strings.IndexByte("IEY", at(i+1)) >= 0:
+				if at(i-1) != 'S' {
+					key.WriteByte('S')
+				}
+			default:
+				key.WriteByte('K')
+			}
+		case 'D':
+			if at(i+1) == 'G' && strings.IndexByte("EIY", at(i+2)) >= 0 {
+				key.WriteByte('J')
+			} else {
+				key.WriteByte('T')
+			}
+		case 'G':
+			switch {
+			case at(i+1) == 'H' && !isVowel(i+2):
+				// 词中不发音的 GH（NIGHT）
+			case at(i+1) == 'N' && (i+2 == len(w) || (at(i+2) == 'E' && at(i+3) == 'D' && i+4 == len(w))):
+				// SIGN、SIGNED
+			case strings.IndexByte("EIY", at(i+1)) >= 0:
+				key.WriteByte('J')
+			default:
+				key.WriteByte('K')
+			}
+		case 'H':
+			if isVowel(i+1) && strings.IndexByte("CSPTG", at(i-1)) < 0 {
+				key.WriteByte('H')
+			}
+		case 'K':
+			if at(i-1) != 'C' {
+				key.WriteByte('K')
+			}
+		case 'P':
+			if at(i+1) == 'H' {
+				key.WriteByte('F')
+			} else {
+				key.WriteByte('P')
+			}
+		case 'Q':
+			key.WriteByte('K')
+		case 'S':
+			switch {
+			case at(i+1) == 'H', at(i+1) == 'I' && (at(i+2) == 'O' || at(i+2) == 'A'):
+				key.WriteByte('X')
+			default:
+				key.WriteByte('S')
+			}
+		case 'T':
+			switch {
+			case at(i+1) == 'I' && (at(i+2) == 'O' || at(i+2) == 'A'):
+				key.WriteByte('X')
+			case at(i+1) == 'H':
+				key.WriteByte('0')
+			case !(at(i+1) == 'C' && at(i+2) == 'H'):
+				key.WriteByte('T')
+			}
+		case 'V':
+			key.WriteByte('F')
+		case 'W', 'Y':
+			if isVowel(i + 1) {
+				key.WriteByte(c)
+			}
+		case 'X':
+			key.WriteString("KS")
+		case 'Z':
+			key.WriteByte('S')
+		default:
+			key.WriteByte(c)
+		}
+	}
+	return key.String()
+}
+
+// phoneticKeys 将文本中的拉丁字母与数字单词依次转换为读音键；含非拉丁文字的单词被跳过
+func phoneticKeys(text string) []string {
+	var keys []string
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		if !isLatinWord(word) {
+			continue
+		}
+		// 纯数字等没有读音键的单词按原样比较
+		k := metaphone(word)
+		if k == "" {
+			k = word
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func isLatinWord(word string) bool {
+	for _, r := range word {
+		if r > unicode.MaxLatin1 {
+			return false
+		}
+	}
+	return true
+}
+
+// phoneticClose 判断两个读音键是否视为相同：完全相同，或均不短于 4 且编辑距离为 1
+func phoneticClose(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if len(a) < 4 || len(b) < 4 {
+		return false
+	}
+	return editDistanceOne(a, b)
+}
+
+// editDistanceOne 判断两个字符串是否恰好相差一次插入、删除或替换
+func editDistanceOne(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	if len(a) == len(b) {
+		return i == len(a) || a[i+1:] == b[i+1:]
+	}
+	return a[i:] == b[i+1:]
+}
+
+// phoneticScore 判断查询读音键是否作为连续片段出现在某个值的读音键中，返回 (0, phoneticScoreMax] 内的评分：
+// 查询单词占该值单词数的比例越高评分越高
+func phoneticScore(values [][]string, query []string) (float64, bool) {
+	best := 0.0
+	for _, keys := range values {
+		for start := 0; start+len(query) <= len(keys); start++ {
+			matched := true
+			for j, q := range query {
+				if !phoneticClose(keys[start+j], q) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				best = max(best, phoneticScoreMax*float64(len(query))/float64(len(keys)))
+				break
+			}
+		}
+	}
+	return best, best > 0
+}
+
+// entryPhoneticKeys 计算条目歌名与歌手（含别名）各个值的读音键
+func entryPhoneticKeys(md Metadata, artists []string) [][]string {
+	var keys [][]string
+	for _, v := range append(append([]string(nil), md.MusicName...), artists...) {
+		if k := phoneticKeys(v); len(k) > 0 {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
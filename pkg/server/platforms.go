@@ -0,0 +1,135 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// --- 平台别名与禁用 ---
+
+var (
+	// 命令行参数（见 registerDataFlags）
+	extraPlatformAliases aliasList
+	disabledPlatforms    stringList
+	enabledPlatforms     stringList // 为空时加载全部发现的平台
+	platformPriority     stringList // 合并结果时优先采用的平台顺序
+)
+
+// aliasList 为 -platform-aliases 的取值，形如 "netease=ncm,kg=kugou"
+type aliasList map[string]string
+
+func (a *aliasList) String() string {
+	pairs := make([]string, 0, len(*a))
+	for k, v := range *a {
+		pairs = append(pairs, k+"="+v)
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (a *aliasList) Set(v string) error {
+	if *a == nil {
+		*a = make(aliasList)
+	}
+	for _, pair := range strings.Split(v, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		alias, target, ok := strings.Cut(pair, "=")
+		alias, target = strings.ToLower(strings.TrimSpace(alias)), strings.TrimSpace(target)
+		if !ok || alias == "" || target == "" {
+			return fmt.Errorf("invalid platform alias %q, expected alias=platform", pair)
+		}
+		(*a)[alias] = target
+	}
+	return nil
+}
+
+// defaultPlatformAliases 为内置的平台别名，可通过 -platform-aliases 追加或覆盖
+var defaultPlatformAliases = map[string]string{
+	"netease":    "ncm",
+	"163":        "ncm",
+	"qqmusic":    "qq",
+	"tencent":    "qq",
+	"apple":      "am",
+	"applemusic": "am",
+	"itunes":     "am",
+}
+
+// resolvePlatform 将客户端传入的平台名（可为别名，不区分大小写）转换为实际的平台名
+func resolvePlatform(name string) string {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if target, ok := extraPlatformAliases[key]; ok {
+		return target
+	}
+	if target, ok := defaultPlatformAliases[key]; ok {
+		return target
+	}
+	return key
+}
+
+// resolvePlatforms 对列表中的每个平台名调用 resolvePlatform 并去重
+func resolvePlatforms(names []string) []string {
+	if len(names) == 0 {
+		return names
+	}
+	resolved := make([]string, 0, len(names))
+	for _, name := range names {
+		if p := resolvePlatform(name); !slices.Contains(resolved, p) {
+			resolved = append(resolved, p)
+		}
+	}
+	return resolved
+}
+
+// platformEnabled 判断平台是否在 -platforms 列表中（未指定时视为全部启用）且未被 -disable-platforms 禁用
+func platformEnabled(name string) bool {
+	if len(enabledPlatforms) > 0 && !slices.Contains(resolvePlatforms(enabledPlatforms), name) {
+		return false
+	}
+	return !slices.Contains(resolvePlatforms(disabledPlatforms), name)
+}
+
+// platformRanks 返回各平台的合并优先级（值越小越优先）：先按 -platform-priority 的顺序，
+// 未列出的平台按 platforms 的顺序排在其后
+func platformRanks() map[string]int {
+	ranks := make(map[string]int)
+	for _, p := range resolvePlatforms(platformPriority) {
+		if _, ok := ranks[p]; !ok {
+			ranks[p] = len(ranks)
+		}
+	}
+	for _, p := range platformList() {
+		if _, ok := ranks[p]; !ok {
+			ranks[p] = len(ranks)
+		}
+	}
+	return ranks
+}
+
+// rankOf 返回平台在 ranks 中的优先级，未知平台排在最后
+func rankOf(ranks map[string]int, p string) int {
+	if r, ok := ranks[p]; ok {
+		return r
+	}
+	return len(ranks)
+}
+
+// announcePlatformChanges 比较重新加载前后的平台列表，为新出现与消失的平台推送事件；
+// 同步带来的新 <平台>-lyrics 目录在重新加载时自动注册，无需重启
+func announcePlatformChanges(before, after []string) {
+	counts := platformCounts()
+	for _, p := range after {
+		if !slices.Contains(before, p) {
+			logInfof("Discovered new platform %s (%d entries)", p, counts[p])
+			publishEvent("platform-added", map[string]interface{}{"platform": p, "entries": counts[p]})
+		}
+	}
+	for _, p := range before {
+		if !slices.Contains(after, p) {
+			logInfof("Platform %s is no longer present", p)
+			publishEvent("platform-removed", map[string]interface{}{"platform": p})
+		}
+	}
+}
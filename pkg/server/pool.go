@@ -0,0 +1,86 @@
+package server
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// --- 对象池 ---
+
+// 超过该容量的切片不再放回池中，避免偶发的大结果集长期占用内存
+const maxPooledResults = 4096
+
+var (
+	resultSlicePool = sync.Pool{
+		New: func() interface{} {
+			poolStats.resultSliceNew.Add(1)
+			s := make([]SearchResult, 0, 64)
+			return &s
+		},
+	}
+	mergeMapPool = sync.Pool{
+		New: func() interface{} {
+			poolStats.mergeMapNew.Add(1)
+			return make(map[string]*SearchResult, 128)
+		},
+	}
+
+	// 对象池统计，用于衡量池化带来的分配减少
+	poolStats struct {
+		resultSliceGets atomic.Int64
+		resultSliceNew  atomic.Int64
+		mergeMapGets    atomic.Int64
+		mergeMapNew     atomic.Int64
+	}
+)
+
+func getResultSlice() []SearchResult {
+	poolStats.resultSliceGets.Add(1)
+	return (*resultSlicePool.Get().(*[]SearchResult))[:0]
+}
+
+func putResultSlice(s []SearchResult) {
+	if cap(s) > maxPooledResults {
+		return
+	}
+	// 清空元素以免池中残留对元数据的引用
+	clear(s[:cap(s)])
+	s = s[:0]
+	resultSlicePool.Put(&s)
+}
+
+func getMergeMap() map[string]*SearchResult {
+	poolStats.mergeMapGets.Add(1)
+	return mergeMapPool.Get().(map[string]*SearchResult)
+}
+
+func putMergeMap(m map[string]*SearchResult) {
+	if len(m) > maxPooledResults {
+		return
+	}
+	clear(m)
+	mergeMapPool.Put(m)
+}
+
+// allocationStats 汇总运行时分配数据与对象池命中情况，供 /api/status 展示
+func allocationStats() map[string]interface{} {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	return map[string]interface{}{
+		"heap_alloc_bytes":  ms.HeapAlloc,
+		"total_alloc_bytes": ms.TotalAlloc,
+		"mallocs":           ms.Mallocs,
+		"frees":             ms.Frees,
+		"num_gc":            ms.NumGC,
+		"result_slice_pool": map[string]int64{
+			"gets":   poolStats.resultSliceGets.Load(),
+			"allocs": poolStats.resultSliceNew.Load(),
+		},
+		"merge_map_pool": map[string]int64{
+			"gets":   poolStats.mergeMapGets.Load(),
+			"allocs": poolStats.mergeMapNew.Load(),
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package server
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --- QRC（QQ 音乐逐字歌词）解析与输出 ---
+
+// qrcWordPattern 匹配 QRC 词后的时间 (开始,持续)
+var qrcWordPattern = regexp.MustCompile(`\((\d+),(\d+)\)`)
+
+// parseQRC 解析 QRC 歌词：每行形如 [开始,持续]词(词开始,词持续)词(词开始,词持续)；
+// 内容包裹在 <QrcInfos> XML 中时取其 LyricContent 属性
+func parseQRC(data []byte) (*Lyric, error) {
+	if content, ok := qrcXMLContent(data); ok {
+		data = []byte(content)
+	}
+	lyric, err := scanTimedLines(data, func(text string) []LyricWord {
+		locs := qrcWordPattern.FindAllStringSubmatchIndex(text, -1)
+		if len(locs) == 0 {
+			return nil
+		}
+		words := make([]LyricWord, 0, len(locs))
+		prev := 0
+		for _, loc := range locs {
+			start, _ := strconv.ParseInt(text[loc[2]:loc[3]], 10, 64)
+			dur, _ := strconv.ParseInt(text[loc[4]:loc[5]], 10, 64)
+			words = append(words, LyricWord{Start: start, End: start + dur, Text: text[prev:loc[0]]})
+			prev = loc[1]
+		}
+		return words
+	})
+	if err != nil && !errors.Is(err, errEmptyLyric) {
+		return nil, fmt.Errorf("invalid QRC: %w", err)
+	}
+	return lyric, err
+}
+
+// qrcXMLContent 从 XML 包装的 QRC 中取出歌词内容，不是 XML 时返回 false
+func qrcXMLContent(data []byte) (string, bool) {
+	if !bytes.HasPrefix(bytes.TrimSpace(data), []byte("<")) {
+		return "", false
+	}
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF || err != nil {
+			return "", false
+		}
+		if el, ok := tok.(xml.StartElement); ok {
+			if content := xmlAttr(el, "", "LyricContent"); content != "" {
+				return content, true
+			}
+		}
+	}
+}
+
+// renderQRC 输出不带 XML 包装的 QRC，元数据写为 [ti:]、[ar:]、[al:] 标签
+func renderQRC(lyric *Lyric) string {
+	var sb strings.Builder
+	for _, tag := range []struct{ tag, key string }{{"ti", "musicName"}, {"ar", "artists"}, {"al", "album"}} {
+		if values := lyric.Metadata[tag.key]; len(values) > 0 {
+			fmt.Fprintf(&sb, "[%s:%s]\n", tag.tag, strings.Join(values, "/"))
+		}
+	}
+	for _, line := range lyric.Lines {
+		if line.IsBackground {
+			continue
+		}
+		fmt.Fprintf(&sb, "[%d,%d]", line.Start, max(line.End-line.Start, 0))
+		for _, w := range lineWords(line) {
+			fmt.Fprintf(&sb, "%s(%d,%d)", w.Text, w.Start, max(w.End-w.Start, 0))
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
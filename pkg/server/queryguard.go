@@ -0,0 +1,46 @@
+package server
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// --- 查询长度限制 ---
+
+var (
+	// 命令行参数（见 registerSearchFlags）
+	maxQueryLength  = new(int) // 查询词与各结构化字段的最大字符数
+	minQueryLength  = new(int) // 查询词的最小字符数，结构化搜索要求至少一个字段达到该长度
+	maxQueryArtists = new(int) // 结构化搜索中 artists 的最大个数
+)
+
+// validateQuery 检查查询长度，不符合时返回可直接展示给客户端的错误；
+// 过短的查询几乎匹配所有条目，扫描代价最高，过长的查询则没有实际意义
+func validateQuery(query string, fields fieldQuery) error {
+	if fields.empty() {
+		n := utf8.RuneCountInString(query)
+		if *maxQueryLength > 0 && n > *maxQueryLength {
+			return fmt.Errorf("query is too long (%d characters, maximum %d)", n, *maxQueryLength)
+		}
+		if n < *minQueryLength {
+			return fmt.Errorf("query is too short (%d characters, minimum %d)", n, *minQueryLength)
+		}
+		return nil
+	}
+
+	if *maxQueryArtists > 0 && len(fields.Artists) > *maxQueryArtists {
+		return fmt.Errorf("too many artists (%d, maximum %d)", len(fields.Artists), *maxQueryArtists)
+	}
+	values := append([]string{fields.Title, fields.Album}, fields.Artists...)
+	longest := 0
+	for _, v := range values {
+		if n := utf8.RuneCountInString(v); *maxQueryLength > 0 && n > *maxQueryLength {
+			return fmt.Errorf("title, artist or album is too long (%d characters, maximum %d)", n, *maxQueryLength)
+		}
+		longest = max(longest, utf8.RuneCountInString(normalizeField(v)))
+	}
+	if longest < *minQueryLength {
+		return fmt.Errorf("title, artists and album are all too short (minimum %d characters)", *minQueryLength)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// --- 查询改写规则 ---
+
+var rewriteRulesFile = new(string)
+
+// rewriteRule 为一条正则改写规则，匹配不区分大小写
+type rewriteRule struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// activeRewriteRules 为当前生效的改写规则，由 mu 保护
+var activeRewriteRules []rewriteRule
+
+// loadRewriteRules 读取改写规则文件：每行为 "正则 => 替换文本"，替换文本可为空（即删除匹配部分），
+// 替换文本中可用 $1 等引用分组；跳过空行与 # 注释，路径为空时返回 nil
+func loadRewriteRules(path string) ([]rewriteRule, error) {
+	if path == "" {
+		return nil, nil
+	}
+	lines, err := readRuleLines(path)
+	if err != nil {
+		return nil, err
+	}
+	rules := make([]rewriteRule, 0, len(lines))
+	for _, line := range lines {
+		pattern, replacement, ok := strings.Cut(line, "=>")
+		if !ok {
+			return nil, fmt.Errorf("rule %q: missing =>", line)
+		}
+		re, err := regexp.Compile("(?i)" + strings.TrimSpace(pattern))
+		if err != nil {
+			return nil, fmt.Errorf("rule %q: %w", line, err)
+		}
+		rules = append(rules, rewriteRule{pattern: re, replacement: strings.TrimSpace(replacement)})
+	}
+	return rules, nil
+}
+
+// rewriteQuery 依次应用改写规则并合并多余空白；改写后为空时返回原查询
+func rewriteQuery(rules []rewriteRule, s string) string {
+	if len(rules) == 0 {
+		return s
+	}
+	out := s
+	for _, r := range rules {
+		out = r.pattern.ReplaceAllString(out, r.replacement)
+	}
+	if out = strings.Join(strings.Fields(out), " "); out == "" {
+		return s
+	}
+	return out
+}
+
+// currentRewriteRules 返回当前生效的改写规则
+func currentRewriteRules() []rewriteRule {
+	mu.RLock()
+	defer mu.RUnlock()
+	return activeRewriteRules
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"time"
+)
+
+// --- 单平台重建索引 ---
+
+// reindexPlatform 只重新读取一个平台的索引文件并替换其条目，其余平台保持不变；
+// -repo 仓库并入该平台的条目原样保留。返回重新加载后的条目数
+func reindexPlatform(name string) (int, error) {
+	defer beginReload()()
+	platform := resolvePlatform(name)
+	mu.RLock()
+	src, ok := platformSources[platform]
+	prep := &entryPreparer{aliases: artistAliases, rules: activeTextRules}
+	root := actualDataDir
+	current := dataStore
+	mu.RUnlock()
+	if !ok {
+		return 0, errInvalidPlatform
+	}
+
+	entries, err := src.List()
+	if err != nil {
+		return 0, err
+	}
+	localRoot := root
+	if replicaMode() || isS3URL(root) {
+		localRoot = ""
+	}
+	prep.prepare(platform, entries, newLyricTimes(localRoot), newLyricInfos(localRoot), localRoot != "")
+	count := len(entries)
+
+	// 加载由 beginReload 串行化，读取之后 dataStore 不会被替换，可在锁外构建新状态
+	for _, e := range current[platform] {
+		if _, ok := findRepo(e.Source); ok {
+			entries = append(entries, e)
+		}
+	}
+	// 复制映射后再替换，不影响仍持有旧映射的读取方
+	store := maps.Clone(current)
+	store[platform] = entries
+	// BM25 的文档频率与平均长度依赖全部条目，须与条目一同重新计算
+	var content *contentStats
+	if *indexLyrics {
+		content = storeContentStats(store, platform)
+	}
+
+	swapStart := time.Now()
+	mu.Lock()
+	dataStore = store
+	lyricContentStats = content
+	lastUpdateTime = time.Now()
+	resetQueryCache()
+	mu.Unlock()
+	lastReloadSwap.Store(int64(time.Since(swapStart)))
+
+	replacePlatformIssues(platform, prep.issues)
+	announceCacheCleared()
+	logInfof("Platform %s reindexed, %d entries", platform, count)
+	publishEvent("reindex", map[string]interface{}{"platform": platform, "entries": count})
+	runHooks(&reloadHooks, ReloadEvent{Root: root, Platform: platform, Entries: count})
+	return count, nil
+}
+
+// reindexHandler 处理 POST /api/reindex?platform=ncm，适合在 -no-sync 模式下手动修改某个 index.jsonl 后使用
+func reindexHandler(w http.ResponseWriter, r *http.Request) {
+	platform := r.URL.Query().Get("platform")
+	if platform == "" {
+		writeError(w, r, http.StatusBadRequest, codeInvalidRequest, "platform is required")
+		return
+	}
+	count, err := reindexPlatform(platform)
+	switch {
+	case errors.Is(err, errInvalidPlatform):
+		auditHTTP(r, "reindex", "rejected", "invalid platform "+platform)
+		writeError(w, r, http.StatusBadRequest, codePlatformInvalid, "Invalid platform")
+		return
+	case err != nil:
+		logErrorf("Reindex of %s failed: %v", platform, err)
+		auditHTTP(r, "reindex", "failed", err.Error())
+		writeError(w, r, http.StatusInternalServerError, codeInternal, "Failed to reload index: "+err.Error())
+		return
+	}
+	auditHTTP(r, "reindex", "reindexed", resolvePlatform(platform))
+	writeJSON(w, r, map[string]interface{}{
+		"message":  "Platform reindexed",
+		"platform": resolvePlatform(platform),
+		"entries":  count,
+	})
+}
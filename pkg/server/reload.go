@@ -0,0 +1,72 @@
+package server
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// --- 索引重新加载的协调 ---
+
+// 完整加载与单平台重建先在锁外构建全部新状态（条目、派生索引、数据目录等），
+// 再在 mu 写锁内一次性替换并清空查询缓存；构建期间的请求继续使用旧状态，不受影响。
+
+var (
+	// reloadMu 串行化完整加载与单平台重建，避免较早开始的加载覆盖较新的结果
+	reloadMu sync.Mutex
+	// reloadEpoch 在每次加载开始与结束时各加一，为奇数时表示加载进行中
+	reloadEpoch atomic.Int64
+
+	lastReloadBuild   atomic.Int64 // 最近一次加载的总耗时（纳秒）
+	lastReloadSwap    atomic.Int64 // 最近一次加载持有写锁替换状态的耗时（纳秒）
+	maxReloadLatency  atomic.Int64 // 与加载重叠的请求中最长的耗时（纳秒）
+	reloadOverlapping atomic.Int64 // 与加载重叠的请求数
+	reloadCount       atomic.Int64 // 启动以来完成的加载次数
+)
+
+// beginReload 开始一次加载并返回结束时调用的函数
+func beginReload() func() {
+	reloadMu.Lock()
+	reloadEpoch.Add(1)
+	start := time.Now()
+	return func() {
+		lastReloadBuild.Store(int64(time.Since(start)))
+		reloadCount.Add(1)
+		reloadEpoch.Add(1)
+		reloadMu.Unlock()
+	}
+}
+
+// reloadTriggerPaths 为本身会执行加载的接口（/api/prune 的 reclone 模式同样会重新加载），
+// streamingPaths 为长时间保持连接的推送接口；二者的耗时都不是等待加载的延迟，不计入加载期间的请求延迟
+var (
+	reloadTriggerPaths = []string{"/api/update", "/api/reindex", "/api/prune"}
+	streamingPaths     = []string{"/api/events"}
+)
+
+// observeReloadLatency 记录与加载重叠（开始时加载进行中，或期间有加载开始）的请求耗时，epoch 为请求开始时的 reloadEpoch
+func observeReloadLatency(path string, epoch int64, elapsed time.Duration) {
+	if epoch%2 == 0 && reloadEpoch.Load() == epoch || slices.Contains(reloadTriggerPaths, path) || slices.Contains(streamingPaths, path) {
+		return
+	}
+	reloadOverlapping.Add(1)
+	for {
+		cur := maxReloadLatency.Load()
+		if int64(elapsed) <= cur || maxReloadLatency.CompareAndSwap(cur, int64(elapsed)) {
+			return
+		}
+	}
+}
+
+// reloadStatus 汇总加载耗时与加载期间的请求延迟，见 /api/status
+func reloadStatus() map[string]interface{} {
+	return map[string]interface{}{
+		"in_progress":            reloadEpoch.Load()%2 == 1,
+		"count":                  reloadCount.Load(),
+		"last_duration_ms":       time.Duration(lastReloadBuild.Load()).Milliseconds(),
+		"last_swap_us":           time.Duration(lastReloadSwap.Load()).Microseconds(),
+		"overlapping_requests":   reloadOverlapping.Load(),
+		"max_request_latency_ms": time.Duration(maxReloadLatency.Load()).Milliseconds(),
+	}
+}
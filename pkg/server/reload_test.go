@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// loadTestIndex 在临时目录中写入 ncm 平台的索引（每行一个条目），以 -no-sync 及 args 加载，返回公开端口的路由
+func loadTestIndex(t *testing.T, lines []string, args ...string) http.Handler {
+	t.Helper()
+	root := t.TempDir()
+	dir := filepath.Join(root, "ncm-lyrics")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.jsonl"), []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	resetListFlags()
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	registerServeFlags(fs)
+	if err := fs.Parse(append([]string{"-no-sync", "-data-dir", root, "-log-level", "error"}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	loadMetadata()
+	if getTotalCount() != len(lines) {
+		t.Fatalf("loaded %d entries, want %d", getTotalCount(), len(lines))
+	}
+	return newServeMux()
+}
+
+// testEntry 返回一行 ncm 索引条目
+func testEntry(id int, name, artist string) string {
+	return fmt.Sprintf(`{"metadata": [["musicName", [%q]], ["artists", [%q]], ["ncmMusicId", ["%d"]]], "id": "%d", "rawLyricFile": "%d.ttml"}`, name, artist, id, id, id)
+}
+
+// setupReloadFixture 加载 entries 个歌名为 Song N 的条目
+func setupReloadFixture(t *testing.T, entries int) http.Handler {
+	t.Helper()
+	lines := make([]string, entries)
+	for i := range lines {
+		lines[i] = testEntry(1000+i, fmt.Sprintf("Song %d", i), fmt.Sprintf("Artist %d", i%50))
+	}
+	return loadTestIndex(t, lines)
+}
+
+// searchTotal 通过 handler 执行一次搜索，返回状态码与结果总数
+func searchTotal(t *testing.T, h http.Handler, query string) (int, int) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search?query="+query, nil))
+	var body struct {
+		Total int `json:"total"`
+	}
+	json.Unmarshal(rec.Body.Bytes(), &body)
+	return rec.Code, body.Total
+}
+
+func TestSearchNotBlockedByReload(t *testing.T) {
+	h := setupReloadFixture(t, 500)
+
+	// 加载持有 reloadMu 期间（构建新状态的阶段），请求应继续使用旧索引立即返回
+	finish := beginReload()
+	done := make(chan [2]int, 1)
+	go func() {
+		code, total := searchTotal(t, h, "song")
+		done <- [2]int{code, total}
+	}()
+	select {
+	case got := <-done:
+		if got[0] != http.StatusOK || got[1] != 500 {
+			t.Errorf("search during reload: status %d, total %d, want 200 and 500", got[0], got[1])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("search blocked while a reload was in progress")
+	}
+	finish()
+	if reloadOverlapping.Load() == 0 {
+		t.Error("search overlapping the reload was not counted")
+	}
+
+	// 与连续的完整加载并发执行的搜索既不应失败，也不应看到替换过程中的空索引
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				loadMetadata()
+			}
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		if code, total := searchTotal(t, h, fmt.Sprintf("song+%d", i)); code != http.StatusOK || total == 0 {
+			t.Fatalf("search %d during reloads: status %d, total %d", i, code, total)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestReloadLatencyExcludesTriggersAndStreams(t *testing.T) {
+	maxReloadLatency.Store(0)
+	inProgress := int64(1) // 奇数 epoch 表示请求开始时加载正在进行
+	for _, path := range []string{"/api/update", "/api/reindex", "/api/prune", "/api/events"} {
+		observeReloadLatency(path, inProgress, time.Hour)
+	}
+	if got := maxReloadLatency.Load(); got != 0 {
+		t.Fatalf("max_request_latency recorded %v from reload triggers or streams", time.Duration(got))
+	}
+	observeReloadLatency("/api/search", inProgress, time.Second)
+	if got := time.Duration(maxReloadLatency.Load()); got != time.Second {
+		t.Fatalf("max_request_latency = %v, want 1s", got)
+	}
+}
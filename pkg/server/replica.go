@@ -0,0 +1,328 @@
+package server
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// --- 索引快照与副本模式 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	primaryURL      = new(string) // 非空时以副本模式运行，从该实例拉取索引快照
+	primaryAdminURL = new(string) // 主实例的管理端口（其 -admin-addr），非空时从该地址拉取快照
+	primaryToken    = new(string) // 主实例的 -admin-token，拉取快照与歌词文件时携带
+	replicaCacheDir = new(string)
+
+	replicaClient = &http.Client{Timeout: 2 * time.Minute}
+
+	// latestSnapshot 为副本最近一次拉取的快照，loadMetadata 由此构建各平台
+	latestSnapshot     *indexSnapshot
+	latestSnapshotETag string
+	latestSnapshotMu   sync.Mutex
+	// replicaOrigins 为快照中出现的非主数据目录来源（-repo 仓库或叠加目录），使副本的结果与主实例一样带有来源
+	replicaOrigins []string
+	replicaMulti   atomic.Bool
+)
+
+// snapshotEntry 为快照中的单个索引条目，包含主实例加载时计算出的字段（副本没有歌词文件，无法自行检测）
+type snapshotEntry struct {
+	ID             string          `json:"id"`
+	RawLyricFile   string          `json:"rawLyricFile"`
+	Metadata       [][]interface{} `json:"metadata"`
+	Updated        time.Time       `json:"updated,omitzero"`
+	Lang           string          `json:"lang,omitempty"`
+	HasTranslation bool            `json:"hasTranslation,omitempty"`
+	HasRoman       bool            `json:"hasRoman,omitempty"`
+	WordTimed      bool            `json:"wordTimed,omitempty"`
+	FileSize       *int64          `json:"fileSize,omitempty"`
+	FileMtime      time.Time       `json:"fileMtime,omitzero"`
+	ContentHash    string          `json:"contentHash,omitempty"`
+	Source         string          `json:"source,omitempty"` // -repo 仓库或叠加目录的名称，主数据目录为空
+}
+
+// indexSnapshot 为 /api/snapshot 的响应：已加载的全部平台及其条目
+type indexSnapshot struct {
+	Version    string                     `json:"version"`
+	Commit     string                     `json:"commit,omitempty"`
+	CommitTime time.Time                  `json:"commitTime,omitzero"`
+	Branch     string                     `json:"branch,omitempty"`
+	Platforms  map[string][]snapshotEntry `json:"platforms"`
+}
+
+func replicaMode() bool {
+	return *primaryURL != ""
+}
+
+// buildSnapshot 序列化当前已加载的索引
+func buildSnapshot() *indexSnapshot {
+	version := indexVersion()
+	mu.RLock()
+	defer mu.RUnlock()
+
+	snap := &indexSnapshot{
+		Version:    version,
+		Commit:     dataCommit.Hash,
+		CommitTime: dataCommit.Time,
+		Branch:     dataCommit.Branch,
+		Platforms:  make(map[string][]snapshotEntry, len(dataStore)),
+	}
+	for p, entries := range dataStore {
+		list := make([]snapshotEntry, len(entries))
+		for i, e := range entries {
+			list[i] = snapshotEntry{
+				ID:             e.ID,
+				RawLyricFile:   e.RawLyricFile,
+				Metadata:       e.MetadataRaw,
+				Updated:        e.Updated,
+				Lang:           e.Lang,
+				HasTranslation: e.HasTranslation,
+				HasRoman:       e.HasRoman,
+				WordTimed:      e.WordTimed,
+				FileSize:       e.FileSize,
+				FileMtime:      e.FileMtime,
+				ContentHash:    e.ContentHash,
+				Source:         e.Source,
+			}
+		}
+		snap.Platforms[p] = list
+	}
+	return snap
+}
+
+// snapshotHandler 返回索引快照；以索引版本作为 ETag，版本未变时返回 304，客户端支持时使用 gzip 压缩
+func snapshotHandler(w http.ResponseWriter, r *http.Request) {
+	etag := `"` + indexVersion() + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	var out io.Writer = w
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		out = gz
+	}
+	json.NewEncoder(out).Encode(buildSnapshot())
+}
+
+// snapshotURL 返回主实例的快照地址：主实例指定了 -admin-addr 时快照只在其管理端口上提供
+func snapshotURL() string {
+	base := *primaryURL
+	if *primaryAdminURL != "" {
+		base = *primaryAdminURL
+	}
+	return strings.TrimRight(base, "/") + "/api/snapshot"
+}
+
+// primaryRequest 创建发往主实例的请求，指定了 -primary-token 时携带管理令牌
+// （快照接口要求管理令牌，主实例配置了 -api-keys 时下载接口同样接受管理令牌）
+func primaryRequest(ctx context.Context, target string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if *primaryToken != "" {
+		req.Header.Set("Authorization", "Bearer "+*primaryToken)
+	}
+	return req, nil
+}
+
+// fetchSnapshot 从主实例拉取快照；etag 非空且快照未变化时返回 nil
+func fetchSnapshot(ctx context.Context, etag string) (*indexSnapshot, string, error) {
+	req, err := primaryRequest(ctx, snapshotURL())
+	if err != nil {
+		return nil, "", err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := replicaClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return nil, etag, nil
+	case http.StatusOK:
+	default:
+		return nil, "", fmt.Errorf("primary returned %s", resp.Status)
+	}
+	var snap indexSnapshot
+	if err := json.NewDecoder(resp.Body).Decode(&snap); err != nil {
+		return nil, "", err
+	}
+	return &snap, resp.Header.Get("ETag"), nil
+}
+
+// replicaSources 将最近拉取的快照转换为数据源（尚未拉取时先拉取一次）
+func replicaSources() ([]Source, error) {
+	latestSnapshotMu.Lock()
+	snap := latestSnapshot
+	latestSnapshotMu.Unlock()
+	if snap == nil {
+		fetched, etag, err := fetchSnapshot(context.Background(), "")
+		if err != nil {
+			return nil, err
+		}
+		setLatestSnapshot(fetched, etag)
+		snap = fetched
+	}
+
+	var sources []Source
+	for _, p := range sortPlatforms(slices.Collect(maps.Keys(snap.Platforms))) {
+		src := &replicaSource{name: p, entries: snap.Platforms[p], hashes: make(map[string]string)}
+		for _, e := range src.entries {
+			if e.Source == "" {
+				src.hashes[e.ID] = e.ContentHash
+			}
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+func setLatestSnapshot(snap *indexSnapshot, etag string) {
+	var origins []string
+	for _, entries := range snap.Platforms {
+		for _, e := range entries {
+			if e.Source != "" && !slices.Contains(origins, e.Source) {
+				origins = append(origins, e.Source)
+			}
+		}
+	}
+	slices.Sort(origins)
+	latestSnapshotMu.Lock()
+	latestSnapshot, latestSnapshotETag = snap, etag
+	replicaOrigins = origins
+	latestSnapshotMu.Unlock()
+	replicaMulti.Store(len(origins) > 0)
+}
+
+// replicaOriginNames 返回副本快照中的 -repo 仓库与叠加目录名称
+func replicaOriginNames() []string {
+	latestSnapshotMu.Lock()
+	defer latestSnapshotMu.Unlock()
+	return replicaOrigins
+}
+
+// watchPrimary 按 -interval 检查主实例的快照是否更新，更新时重新加载索引并清空歌词缓存
+func watchPrimary(ctx context.Context) {
+	if !replicaMode() || *syncInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(*syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			latestSnapshotMu.Lock()
+			etag := latestSnapshotETag
+			latestSnapshotMu.Unlock()
+			snap, etag, err := fetchSnapshot(ctx, etag)
+			if err != nil {
+				logErrorf("Replica: failed to fetch snapshot from %s: %v", snapshotURL(), err)
+				continue
+			}
+			if snap == nil {
+				continue
+			}
+			logInfof("Replica: primary index changed (version %s), reloading", snap.Version)
+			setLatestSnapshot(snap, etag)
+			os.RemoveAll(*replicaCacheDir)
+			loadMetadata()
+		}
+	}
+}
+
+// replicaSource 为快照中的一个平台，歌词文件按需从主实例下载并缓存到 -replica-cache-dir/<平台>；
+// 缓存文件名带有快照中的内容哈希，主实例上的歌词更新后哈希变化，下次请求时重新下载
+type replicaSource struct {
+	name    string
+	entries []snapshotEntry
+	hashes  map[string]string // 主数据目录条目 ID 对应的内容哈希
+}
+
+func (s *replicaSource) Name() string { return s.name }
+
+func (s *replicaSource) List() ([]IndexEntry, error) {
+	entries := make([]IndexEntry, len(s.entries))
+	for i, e := range s.entries {
+		entries[i] = IndexEntry{
+			ID:             e.ID,
+			RawLyricFile:   e.RawLyricFile,
+			MetadataRaw:    e.Metadata,
+			Updated:        e.Updated,
+			Lang:           e.Lang,
+			HasTranslation: e.HasTranslation,
+			HasRoman:       e.HasRoman,
+			WordTimed:      e.WordTimed,
+			FileSize:       e.FileSize,
+			FileMtime:      e.FileMtime,
+			ContentHash:    e.ContentHash,
+			Source:         e.Source,
+		}
+	}
+	return entries, nil
+}
+
+func (s *replicaSource) Get(id, format string) (string, error) {
+	local, ok := lyricFilePath(filepath.Join(*replicaCacheDir, s.name), id, format)
+	if !ok {
+		return "", errLyricNotFound
+	}
+	if hash := s.hashes[id]; hash != "" {
+		local = strings.TrimSuffix(local, "."+format) + "." + hash + "." + format
+	}
+	if _, err := os.Stat(local); err == nil {
+		return local, nil
+	}
+
+	params := url.Values{"platform": {s.name}, "musicId": {id}, "format": {format}}
+	req, err := primaryRequest(context.Background(), strings.TrimRight(*primaryURL, "/")+"/api/download?"+params.Encode())
+	if err != nil {
+		return "", err
+	}
+	resp, err := replicaClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", errLyricNotFound
+	default:
+		return "", fmt.Errorf("primary returned %s", resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return local, writeCachedFile(local, data)
+}
+
+// Watch 由 watchPrimary 统一处理
+func (s *replicaSource) Watch(ctx context.Context, changed func()) {}
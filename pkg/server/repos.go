@@ -0,0 +1,231 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- 多仓库索引 ---
+
+var (
+	// 命令行参数（见 registerDataFlags）
+	extraRepos repoList
+
+	// repoSources 为各仓库中各平台的数据源（仓库名 → 平台 → 数据源），由 mu 保护
+	repoSources map[string]map[string]Source
+)
+
+// dataRepo 为 -repo 注册的一个完整数据仓库（与主仓库目录结构相同，如社区分支），
+// 克隆到 -sources-dir/<name>，独立同步与建立索引，其条目在结果中以 source 标记
+type dataRepo struct {
+	name string
+	url  string
+
+	mu        sync.Mutex // 保护以下同步状态
+	commit    commitInfo
+	lastSync  time.Time
+	lastError string
+	entries   int
+}
+
+// Dir 返回仓库的本地克隆目录
+func (r *dataRepo) Dir() string { return filepath.Join(*sourcesDir, r.name) }
+
+// clone 在本地尚未克隆时克隆仓库
+func (r *dataRepo) clone() error {
+	if _, err := os.Stat(filepath.Join(r.Dir(), ".git")); !os.IsNotExist(err) {
+		return nil
+	}
+	logInfof("Cloning repository %s from %s...", r.name, r.url)
+	out, err := exec.Command("git", "clone", "--depth", "1", r.url, r.Dir()).CombinedOutput()
+	logCommandOutput("git clone "+r.name, out)
+	r.recordSync(err, out)
+	if err != nil {
+		return fmt.Errorf("git clone failed: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// pull 拉取仓库，返回检出的 commit 是否变化
+func (r *dataRepo) pull() bool {
+	before := currentCommit(r.Dir())
+	out, err := exec.Command("git", "-C", r.Dir(), "pull").CombinedOutput()
+	logCommandOutput("git pull "+r.name, out)
+	r.recordSync(err, out)
+	if err != nil {
+		logErrorf("Repository %s: git pull failed: %v: %s", r.name, err, strings.TrimSpace(string(out)))
+		return false
+	}
+	return currentCommit(r.Dir()) != before
+}
+
+func (r *dataRepo) recordSync(err error, out []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastSync = time.Now()
+	r.lastError = ""
+	if err != nil {
+		r.lastError = fmt.Sprintf("%v: %s", err, strings.TrimSpace(string(out)))
+	}
+}
+
+// status 返回仓库的同步状态，供 /api/status 使用
+func (r *dataRepo) status() map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var commitTime, lastSync string
+	if !r.commit.Time.IsZero() {
+		commitTime = r.commit.Time.UTC().Format(time.RFC3339)
+	}
+	if !r.lastSync.IsZero() {
+		lastSync = r.lastSync.Format(time.RFC3339)
+	}
+	return map[string]interface{}{
+		"name":        r.name,
+		"url":         r.url,
+		"commit":      r.commit.Hash,
+		"commit_time": commitTime,
+		"branch":      r.commit.Branch,
+		"last_sync":   lastSync,
+		"last_error":  r.lastError,
+		"entries":     r.entries,
+	}
+}
+
+// repoList 为 -repo 的取值：name=仓库地址
+type repoList []*dataRepo
+
+func (l *repoList) String() string {
+	names := make([]string, 0, len(*l))
+	for _, r := range *l {
+		names = append(names, r.name)
+	}
+	return strings.Join(names, ",")
+}
+
+func (l *repoList) Set(v string) error {
+	name, url, ok := strings.Cut(v, "=")
+	name, url = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(url)
+	if !ok || name == "" || url == "" {
+		return fmt.Errorf("invalid repository %q, expected name=git-url", v)
+	}
+	for _, r := range *l {
+		if r.name == name {
+			return fmt.Errorf("duplicate repository name %q", name)
+		}
+	}
+	*l = append(*l, &dataRepo{name: name, url: url})
+	return nil
+}
+
+// findRepo 按名称查找 -repo 注册的仓库
+func findRepo(name string) (*dataRepo, bool) {
+	for _, r := range extraRepos {
+		if r.name == name {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// loadRepos 加载各仓库的索引，条目标记仓库名后追加到 store 中对应平台的列表末尾（主数据目录的同 ID 条目仍排在前面），
+// 返回各仓库中各平台的数据源
+func loadRepos(store map[string][]IndexEntry, prep *entryPreparer) map[string]map[string]Source {
+	loaded := make(map[string]map[string]Source, len(extraRepos))
+	for _, repo := range extraRepos {
+		if err := repo.clone(); err != nil {
+			logWarnf("Warning: failed to load repository %s: %v", repo.name, err)
+			continue
+		}
+		root := repo.Dir()
+		times := newLyricTimes(root)
+		infos := newLyricInfos(root)
+		configs := indexFiles(root)
+		srcs := make(map[string]Source)
+		count := 0
+		for _, key := range sortPlatforms(slices.Collect(maps.Keys(configs))) {
+			if !platformEnabled(key) {
+				continue
+			}
+			src := newDirSource(key, configs[key])
+			entries, err := src.List()
+			if err != nil {
+				if !os.IsNotExist(err) {
+					logWarnf("Warning: failed to load %s of repository %s: %v", key, repo.name, err)
+				}
+				continue
+			}
+			for i := range entries {
+				entries[i].Source = repo.name
+			}
+			prep.prepare(key, entries, times, infos, true)
+			store[key] = append(store[key], entries...)
+			srcs[key] = src
+			count += len(entries)
+		}
+		loaded[repo.name] = srcs
+
+		repo.mu.Lock()
+		repo.commit = readCommitInfo(root)
+		repo.entries = count
+		repo.mu.Unlock()
+	}
+	return loaded
+}
+
+// resolveRepoLyricFile 在指定仓库中定位歌词文件路径，format 为空时默认为 ttml
+func resolveRepoLyricFile(repo, platform, musicId, format string) (string, error) {
+	if format == "" {
+		format = "ttml"
+	}
+	mu.RLock()
+	src, ok := repoSources[repo][resolvePlatform(platform)]
+	mu.RUnlock()
+	if !ok {
+		return "", errInvalidPlatform
+	}
+	return src.Get(musicId, format)
+}
+
+// repoStatuses 返回各仓库的同步状态
+func repoStatuses() []map[string]interface{} {
+	statuses := make([]map[string]interface{}, 0, len(extraRepos))
+	for _, r := range extraRepos {
+		statuses = append(statuses, r.status())
+	}
+	return statuses
+}
+
+// watchRepos 按 -interval 独立拉取各仓库，某个仓库有更新时重新加载索引并清除缓存
+func watchRepos(ctx context.Context) {
+	if *noSync || *syncInterval <= 0 {
+		return
+	}
+	for _, repo := range extraRepos {
+		go func() {
+			ticker := time.NewTicker(*syncInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if !repo.pull() {
+						continue
+					}
+					logInfof("Repository %s updated, reloading index", repo.name)
+					loadMetadata()
+					publishEvent("repo-updated", map[string]interface{}{"repo": repo.name, "commit": currentCommit(repo.Dir())})
+				}
+			}
+		}()
+	}
+}
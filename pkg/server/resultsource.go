@@ -0,0 +1,108 @@
+package server
+
+import (
+	"slices"
+	"strings"
+)
+
+// --- 结果来源 ---
+
+// mainSourceName 为配置了多个数据来源时主数据目录的来源名称
+const mainSourceName = "main"
+
+// multiSource 判断是否配置了多个本地数据来源（-repo 仓库或叠加目录；副本模式下为主实例快照中的来源）
+func multiSource() bool {
+	return len(extraRepos) > 0 || len(overlayDataDirs) > 0 || replicaMulti.Load()
+}
+
+// entrySource 返回条目的来源名称：-repo 仓库或叠加目录的条目为其名称；
+// 配置了多个来源时主数据目录的条目为 main，否则为空（结果中不含 source 字段）
+func entrySource(entry *IndexEntry) string {
+	if entry.Source == "" && multiSource() {
+		return mainSourceName
+	}
+	return entry.Source
+}
+
+// knownSources 返回搜索可按其过滤的全部来源名称
+func knownSources() []string {
+	var names []string
+	if multiSource() {
+		names = append(names, mainSourceName)
+	}
+	names = append(names, overlayNames()...)
+	for _, r := range extraRepos {
+		names = append(names, r.name)
+	}
+	names = append(names, replicaOriginNames()...)
+	for _, p := range peers {
+		names = append(names, p.name)
+	}
+	if fallbackEnabled() {
+		names = append(names, fallbackPlatform)
+	}
+	return names
+}
+
+// parseSources 拆分逗号分隔的来源列表并检查名称，返回第一个未知的来源
+func parseSources(values []string) ([]string, string) {
+	known := knownSources()
+	var sources []string
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if !slices.Contains(known, name) {
+				return nil, name
+			}
+			if !slices.Contains(sources, name) {
+				sources = append(sources, name)
+			}
+		}
+	}
+	return sources, ""
+}
+
+// filterResultSources 去掉不属于指定来源的结果，用于对等实例与回退 API 的结果（本地结果在扫描时已过滤）
+func filterResultSources(results []SearchResult, sources []string) []SearchResult {
+	if len(sources) == 0 {
+		return results
+	}
+	filtered := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		if slices.Contains(sources, r.Source) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// isLocalOrigin 判断来源是否为主数据目录或某个叠加目录
+func isLocalOrigin(name string) bool {
+	if name == mainSourceName {
+		return multiSource()
+	}
+	return slices.Contains(overlayNames(), name)
+}
+
+// resolveOriginLyricFile 只在主数据目录或指定的叠加目录中定位歌词文件路径，format 为空时默认为 ttml
+func resolveOriginLyricFile(origin, platform, musicId, format string) (string, error) {
+	if format == "" {
+		format = "ttml"
+	}
+	mu.RLock()
+	src, ok := platformSources[resolvePlatform(platform)]
+	mu.RUnlock()
+	if !ok {
+		return "", errInvalidPlatform
+	}
+	if l, ok := src.(*layeredSource); ok {
+		return l.getFrom(origin, musicId, format)
+	}
+	if origin != mainSourceName {
+		return "", errLyricNotFound
+	}
+	return src.Get(musicId, format)
+}
@@ -0,0 +1,94 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// --- 注音（振假名） ---
+
+// LyricRuby 是词中一段被注音的文字及其读法，如 {Base: "漢字", Text: "かんじ"}
+type LyricRuby struct {
+	Base string `json:"base"`
+	Text string `json:"text"`
+}
+
+// rubySegment 是词文本的一段，Ruby 非空表示该段带注音
+type rubySegment struct {
+	Text string
+	Ruby string
+}
+
+// rubySegments 按注音把词文本切分为若干段，各注音的被注音文字按顺序在词文本中查找，找不到的注音被忽略
+func rubySegments(w LyricWord) []rubySegment {
+	var segs []rubySegment
+	rest := w.Text
+	for _, r := range w.Ruby {
+		i := strings.Index(rest, r.Base)
+		if r.Base == "" || i < 0 {
+			continue
+		}
+		if i > 0 {
+			segs = append(segs, rubySegment{Text: rest[:i]})
+		}
+		segs = append(segs, rubySegment{Text: r.Base, Ruby: r.Text})
+		rest = rest[i+len(r.Base):]
+	}
+	if rest != "" {
+		segs = append(segs, rubySegment{Text: rest})
+	}
+	return segs
+}
+
+// wordReading 返回以注音替换被注音文字后的词文本
+func wordReading(w LyricWord) string {
+	if len(w.Ruby) == 0 {
+		return w.Text
+	}
+	var sb strings.Builder
+	for _, seg := range rubySegments(w) {
+		if seg.Ruby != "" {
+			sb.WriteString(seg.Ruby)
+		} else {
+			sb.WriteString(seg.Text)
+		}
+	}
+	return sb.String()
+}
+
+// lineReading 返回行的读法，行中没有任何注音时返回空字符串
+func lineReading(line LyricLine) string {
+	hasRuby := false
+	for _, w := range line.Words {
+		hasRuby = hasRuby || len(w.Ruby) > 0
+	}
+	if !hasRuby {
+		return ""
+	}
+	var sb strings.Builder
+	for _, w := range line.Words {
+		sb.WriteString(wordReading(w))
+	}
+	return sb.String()
+}
+
+// selectReading 实现 reading 歌词层：带注音的行以读法替换文本，逐字时间保留（每个词换成其读法），
+// 没有注音的行原样保留（通常本就是假名）；整首都没有注音时返回错误
+func selectReading(lyric *Lyric) error {
+	found := false
+	for i := range lyric.Lines {
+		l := &lyric.Lines[i]
+		if l.Reading == "" {
+			continue
+		}
+		found = true
+		for j := range l.Words {
+			l.Words[j].Text, l.Words[j].Ruby = wordReading(l.Words[j]), nil
+		}
+		l.Text, l.Reading = l.Reading, ""
+	}
+	if !found {
+		return fmt.Errorf("lyric has no reading lines")
+	}
+	return nil
+}
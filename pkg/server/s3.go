@@ -0,0 +1,345 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- S3 兼容对象存储 ---
+
+var (
+	// 命令行参数（见 registerDataFlags）
+	s3Endpoint = new(string)
+	s3Region   = new(string)
+	s3CacheDir = new(string)
+
+	s3Client = &http.Client{Timeout: 30 * time.Second}
+)
+
+// emptyPayloadHash 为空请求体的 SHA-256，所有请求均为无请求体的 GET/HEAD
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func isS3URL(s string) bool {
+	return strings.HasPrefix(s, "s3://")
+}
+
+// parseS3URL 将 s3://bucket/prefix 拆分为存储桶与不含首尾斜杠的前缀
+func parseS3URL(s string) (bucket, prefix string) {
+	bucket, prefix, _ = strings.Cut(strings.TrimPrefix(s, "s3://"), "/")
+	return bucket, strings.Trim(prefix, "/")
+}
+
+// s3URIEncode 按 SigV4 的规则编码，encodeSlash 为 false 时保留路径中的 "/"
+func s3URIEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Request 以路径风格（endpoint/bucket/key）发送请求；设置了 AWS_ACCESS_KEY_ID 与 AWS_SECRET_ACCESS_KEY 时
+// 使用 SigV4 签名，否则匿名访问（公开存储桶）
+func s3Request(ctx context.Context, method, bucket, key string, query url.Values) (*http.Response, error) {
+	endpoint, err := url.Parse(strings.TrimRight(*s3Endpoint, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid -s3-endpoint: %v", err)
+	}
+	canonicalURI := endpoint.Path + "/" + s3URIEncode(bucket, true)
+	if key != "" {
+		canonicalURI += "/" + s3URIEncode(key, false)
+	}
+
+	// 查询参数按键排序并按 SigV4 规则编码（签名与实际请求使用同一字符串）
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		pairs = append(pairs, s3URIEncode(k, true)+"="+s3URIEncode(query.Get(k), true))
+	}
+	canonicalQuery := strings.Join(pairs, "&")
+
+	u := endpoint.Scheme + "://" + endpoint.Host + canonicalURI
+	if canonicalQuery != "" {
+		u += "?" + canonicalQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey != "" && secretKey != "" {
+		now := time.Now().UTC()
+		amzDate := now.Format("20060102T150405Z")
+		date := now.Format("20060102")
+		req.Header.Set("X-Amz-Date", amzDate)
+		req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+		signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+		headers := "host:" + endpoint.Host + "\nx-amz-content-sha256:" + emptyPayloadHash + "\nx-amz-date:" + amzDate + "\n"
+		if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+			req.Header.Set("X-Amz-Security-Token", token)
+			signed = append(signed, "x-amz-security-token")
+			headers += "x-amz-security-token:" + token + "\n"
+		}
+		signedHeaders := strings.Join(signed, ";")
+
+		canonical := strings.Join([]string{method, canonicalURI, canonicalQuery, headers, signedHeaders, emptyPayloadHash}, "\n")
+		sum := sha256.Sum256([]byte(canonical))
+		scope := date + "/" + *s3Region + "/s3/aws4_request"
+		stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(sum[:])
+
+		signingKey := hmacSHA256([]byte("AWS4"+secretKey), date)
+		signingKey = hmacSHA256(signingKey, *s3Region)
+		signingKey = hmacSHA256(signingKey, "s3")
+		signingKey = hmacSHA256(signingKey, "aws4_request")
+		signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+		req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+			accessKey, scope, signedHeaders, signature))
+	}
+	return s3Client.Do(req)
+}
+
+// s3Get 读取对象内容与 ETag，对象不存在时返回 os.ErrNotExist
+func s3Get(ctx context.Context, bucket, key string) ([]byte, string, error) {
+	resp, err := s3Request(ctx, http.MethodGet, bucket, key, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, "", os.ErrNotExist
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("GET s3://%s/%s: %s", bucket, key, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	return data, resp.Header.Get("ETag"), err
+}
+
+// s3ETag 通过 HEAD 请求获取对象的 ETag
+func s3ETag(ctx context.Context, bucket, key string) (string, error) {
+	resp, err := s3Request(ctx, http.MethodHead, bucket, key, nil)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", os.ErrNotExist
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("HEAD s3://%s/%s: %s", bucket, key, resp.Status)
+	}
+	return resp.Header.Get("ETag"), nil
+}
+
+// s3ListDirs 返回 prefix 下一级的“目录”名（ListObjectsV2 的 CommonPrefixes）
+func s3ListDirs(ctx context.Context, bucket, prefix string) ([]string, error) {
+	if prefix != "" {
+		prefix += "/"
+	}
+	var dirs []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "delimiter": {"/"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s3Request(ctx, http.MethodGet, bucket, "", query)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			CommonPrefixes []struct {
+				Prefix string `xml:"Prefix"`
+			} `xml:"CommonPrefixes"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("list s3://%s/%s: %s", bucket, prefix, resp.Status)
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range result.CommonPrefixes {
+			dirs = append(dirs, strings.TrimSuffix(strings.TrimPrefix(p.Prefix, prefix), "/"))
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return dirs, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// s3Source 为存放在对象存储中的数据源：索引对象与歌词文件（<id>.<格式>）位于同一前缀下，
+// 下载过的歌词文件缓存在 -s3-cache-dir/<name>，索引变化时清空
+type s3Source struct {
+	name     string
+	bucket   string
+	dir      string // 歌词文件所在的前缀
+	indexKey string
+
+	mu   sync.Mutex
+	etag string // 最近一次读取的索引 ETag
+}
+
+func newS3Source(name, bucket, indexKey string) *s3Source {
+	dir := path.Dir(indexKey)
+	if dir == "." {
+		dir = ""
+	}
+	return &s3Source{name: name, bucket: bucket, dir: dir, indexKey: indexKey}
+}
+
+func (s *s3Source) Name() string { return s.name }
+
+func (s *s3Source) cacheDir() string { return filepath.Join(*s3CacheDir, s.name) }
+
+func (s *s3Source) List() ([]IndexEntry, error) {
+	data, etag, err := s3Get(context.Background(), s.bucket, s.indexKey)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.etag = etag
+	s.mu.Unlock()
+	return readIndexEntries(bytes.NewReader(data), "s3://"+s.bucket+"/"+s.indexKey)
+}
+
+func (s *s3Source) Get(id, format string) (string, error) {
+	local, ok := lyricFilePath(s.cacheDir(), id, format)
+	if !ok {
+		return "", errLyricNotFound
+	}
+	if _, err := os.Stat(local); err == nil {
+		return local, nil
+	}
+
+	data, _, err := s3Get(context.Background(), s.bucket, path.Join(s.dir, id+"."+format))
+	if os.IsNotExist(err) {
+		return "", errLyricNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return local, writeCachedFile(local, data)
+}
+
+// modified 检查索引对象的 ETag 是否与上次读取时不同，不同时清空本地缓存
+func (s *s3Source) modified(ctx context.Context) bool {
+	etag, err := s3ETag(ctx, s.bucket, s.indexKey)
+	if os.IsNotExist(err) {
+		etag, err = "", nil // 索引不存在（如存储桶中没有 raw 平台）时与上次比较空 ETag
+	}
+	if err != nil {
+		logErrorf("Source %s: %v", s.name, err)
+		return false
+	}
+	s.mu.Lock()
+	modified := etag != s.etag
+	s.mu.Unlock()
+	if modified {
+		os.RemoveAll(s.cacheDir())
+	}
+	return modified
+}
+
+// Watch 定期检查索引对象是否变化
+func (s *s3Source) Watch(ctx context.Context, changed func()) {
+	ticker := time.NewTicker(sourcePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if s.modified(ctx) {
+				changed()
+			}
+		}
+	}
+}
+
+// discoverS3Sources 按上游仓库的目录结构发现存储桶中的平台：
+// 每个 <平台>-lyrics/index.jsonl 为一个平台，raw 平台的索引位于 metadata/raw-lyrics-index.jsonl
+func discoverS3Sources(root string) ([]*s3Source, error) {
+	bucket, prefix := parseS3URL(root)
+	dirs, err := s3ListDirs(context.Background(), bucket, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var sources []*s3Source
+	for _, d := range dirs {
+		if name := strings.TrimSuffix(d, "-lyrics"); name != d && name != "" {
+			sources = append(sources, newS3Source(name, bucket, path.Join(prefix, d, "index.jsonl")))
+		}
+	}
+	sources = append(sources, newS3Source("raw", bucket, path.Join(prefix, "metadata", "raw-lyrics-index.jsonl")))
+	return sources, nil
+}
+
+// s3RootSources 为 -data-dir 指向存储桶时当前加载的平台，由 mu 保护（每次加载重新发现）
+var s3RootSources []*s3Source
+
+// watchS3Root 在 -data-dir 指向存储桶时定期检查各平台索引，任一变化时重新发现平台并加载
+func watchS3Root(ctx context.Context) {
+	if !isS3URL(*inputDataDir) {
+		return
+	}
+	ticker := time.NewTicker(sourcePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			mu.RLock()
+			current := s3RootSources
+			mu.RUnlock()
+			for _, src := range current {
+				if src.modified(ctx) {
+					logInfof("Source %s changed, reloading index", src.Name())
+					loadMetadata()
+					break
+				}
+			}
+		}
+	}
+}
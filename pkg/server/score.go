@@ -0,0 +1,142 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// --- 匹配评分 ---
+
+// 基础评分取值范围为 (0, 1]：1 表示某个字段与查询完全相同，
+// 查询只占字段的一小部分或跨字段命中时评分较低；
+// 歌名或歌手与查询完全相同时再加上对应的加成，使其排在其他字段偶然命中的结果之前
+
+// crossFieldScore 为查询仅在拼接后的全文中命中（跨越多个字段）时的评分
+const crossFieldScore = 0.05
+
+var (
+	exactTitleBoost  = new(float64) // 歌名与查询完全相同时的评分加成
+	exactArtistBoost = new(float64) // 歌手与查询完全相同时的评分加成
+	fieldWeights     fieldWeightList
+)
+
+// rankingFields 为可通过 -field-weights 调整权重的字段；ID 等其余元数据的权重固定为 1
+var rankingFields = []string{"title", "artist", "album", "filename", "content"}
+
+// metadataRankingFields 将元数据键映射到 rankingFields 中的字段
+var metadataRankingFields = map[string]string{
+	"musicName": "title",
+	"artists":   "artist",
+	"album":     "album",
+}
+
+// fieldWeightList 为 -field-weights 的取值，形如 "title=2,album=0.5"，未列出的字段权重为 1；
+// 各字段的覆盖率（歌词正文为 BM25 映射后的评分）乘以对应权重后再参与比较
+type fieldWeightList map[string]float64
+
+func (f *fieldWeightList) String() string {
+	pairs := make([]string, 0, len(*f))
+	for k, v := range *f {
+		pairs = append(pairs, k+"="+strconv.FormatFloat(v, 'g', -1, 64))
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f *fieldWeightList) Set(v string) error {
+	if *f == nil {
+		*f = make(fieldWeightList)
+	}
+	for _, pair := range strings.Split(v, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		field, value, ok := strings.Cut(pair, "=")
+		field = strings.ToLower(strings.TrimSpace(field))
+		if !ok || !slices.Contains(rankingFields, field) {
+			return fmt.Errorf("invalid field weight %q, expected field=weight with field one of %s", pair, strings.Join(rankingFields, ", "))
+		}
+		w, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || w < 0 || math.IsInf(w, 0) || math.IsNaN(w) {
+			return fmt.Errorf("invalid weight %q for field %s, expected a non-negative number", value, field)
+		}
+		(*f)[field] = w
+	}
+	return nil
+}
+
+// weight 返回字段的权重，field 为空或未配置时为 1
+func (f fieldWeightList) weight(field string) float64 {
+	if w, ok := f[field]; ok {
+		return w
+	}
+	return 1
+}
+
+// coverage 返回 want 在 value 中所占的比例，value 不包含 want 时返回 0
+func coverage(value, want string) float64 {
+	if want == "" || !strings.Contains(value, want) {
+		return 0
+	}
+	return float64(len(want)) / float64(len(value))
+}
+
+// textScore 计算全文查询对单个条目的评分，query 须为小写；
+// 取 ID、文件名及各元数据值中加权覆盖率最高的字段，再加上歌名、歌手完全相同的加成
+func textScore(entry *IndexEntry, query string) float64 {
+	best := coverage(strings.ToLower(entry.ID), query)
+	if c := coverage(strings.ToLower(entry.RawLyricFile), query) * fieldWeights.weight("filename"); c > best {
+		best = c
+	}
+	var titleExact, artistExact bool
+	for _, pair := range entry.MetadataRaw {
+		if len(pair) < 2 {
+			continue
+		}
+		key, _ := pair[0].(string)
+		w := fieldWeights.weight(metadataRankingFields[key])
+		values, _ := pair[1].([]interface{})
+		for _, v := range values {
+			if s, ok := v.(string); ok {
+				c := coverage(strings.ToLower(s), query)
+				if c*w > best {
+					best = c * w
+				}
+				if c == 1 {
+					titleExact = titleExact || key == "musicName"
+					artistExact = artistExact || key == "artists"
+				}
+			}
+		}
+	}
+	if best == 0 {
+		return crossFieldScore
+	}
+	return best + exactBoost(titleExact, artistExact)
+}
+
+// exactBoost 返回歌名、歌手完全相同时的评分加成之和
+func exactBoost(titleExact, artistExact bool) float64 {
+	var boost float64
+	if titleExact {
+		boost += *exactTitleBoost
+	}
+	if artistExact {
+		boost += *exactArtistBoost
+	}
+	return boost
+}
+
+// bestCoverage 返回 values 归一化后对 want 的最高覆盖率，want 须已归一化
+func bestCoverage(values []string, want string) float64 {
+	best := 0.0
+	for _, v := range values {
+		if c := coverage(normalizeField(v), want); c > best {
+			best = c
+		}
+	}
+	return best
+}
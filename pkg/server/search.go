@@ -0,0 +1,425 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// --- 分片扫描 ---
+
+// scanJob 表示某个平台条目切片中的一段分片，扫描完成后携带该分片的匹配结果
+type scanJob struct {
+	platform string
+	entries  []IndexEntry
+	results  []SearchResult
+}
+
+// splitShards 将各平台的条目切分为固定大小的分片，条目较多的平台（如 raw）会产生更多分片
+func splitShards(targetPlatforms []string) []scanJob {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	size := *shardSize
+	if size < 1 {
+		size = 1
+	}
+
+	var jobs []scanJob
+	for _, p := range targetPlatforms {
+		data := dataStore[p]
+		for start := 0; start < len(data); start += size {
+			end := start + size
+			if end > len(data) {
+				end = len(data)
+			}
+			jobs = append(jobs, scanJob{platform: p, entries: data[start:end]})
+		}
+	}
+	return jobs
+}
+
+// entryScorer 判断单个条目是否命中并给出评分（见 score.go），扫描期间会被多个 worker 并发调用
+type entryScorer func(entry *IndexEntry) (float64, bool)
+
+// scanPlatforms 使用有界 worker 池扫描所有分片，返回各分片的匹配结果；评分低于 opts.MinScore 的条目不计入结果。
+// 结果按评分排序后才能确定前 limit 项，因此总是扫描全部分片，不因 limit 提前终止。
+// 结果切片来自对象池，调用方合并完成后应通过 releaseParts 归还
+func scanPlatforms(ctx context.Context, scorer entryScorer, opts searchOptions, stats *scanStats) []scanJob {
+	jobs := splitShards(opts.Platforms)
+	stats.Shards = len(jobs)
+	var scanned atomic.Int64
+	var platformMu sync.Mutex
+	stats.PlatformScanned = make(map[string]int64, len(opts.Platforms))
+	stats.PlatformMatched = make(map[string]int, len(opts.Platforms))
+
+	workers := *searchWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	jobChan := make(chan scanJob)
+	foundChan := make(chan scanJob, workers)
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobChan {
+				found := getResultSlice()
+				n := 0
+				for i := range job.entries {
+					// 每隔一段检查一次是否已超时
+					if i%256 == 0 && ctx.Err() != nil {
+						break
+					}
+					n++
+					entry := &job.entries[i]
+					if !opts.accepts(entry) {
+						continue
+					}
+					score, ok := scorer(entry)
+					if ok && score >= opts.MinScore {
+						found = append(found, SearchResult{
+							ID:             entry.ID,
+							RawLyricFile:   entry.RawLyricFile,
+							Metadata:       entry.MetadataRaw,
+							Platforms:      []string{job.platform},
+							Score:          score,
+							Updated:        entry.Updated,
+							Lang:           entry.Lang,
+							HasTranslation: entry.HasTranslation,
+							HasRoman:       entry.HasRoman,
+							WordTimed:      entry.WordTimed,
+							FileSize:       entry.FileSize,
+							FileMtime:      entry.FileMtime,
+							ContentHash:    entry.ContentHash,
+							Source:         entrySource(entry),
+						})
+					}
+				}
+				scanned.Add(int64(n))
+				platformMu.Lock()
+				stats.PlatformScanned[job.platform] += int64(n)
+				platformMu.Unlock()
+				if len(found) > 0 {
+					foundChan <- scanJob{platform: job.platform, results: found}
+				} else {
+					putResultSlice(found)
+				}
+			}
+		}()
+	}
+
+	// 分发分片，上下文取消后停止派发剩余分片
+	go func() {
+		defer close(jobChan)
+		for _, job := range jobs {
+			select {
+			case jobChan <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(foundChan)
+	}()
+
+	var parts []scanJob
+	for part := range foundChan {
+		parts = append(parts, part)
+		stats.Matched += len(part.results)
+		stats.PlatformMatched[part.platform] += len(part.results)
+	}
+	stats.Scanned = scanned.Load()
+	return parts
+}
+
+// releaseParts 将分片结果切片归还对象池
+func releaseParts(parts []scanJob) {
+	for _, part := range parts {
+		putResultSlice(part.results)
+	}
+}
+
+// --- 搜索执行 ---
+
+// errSearchTimeout 表示搜索在上下文截止前未能完成
+var errSearchTimeout = errors.New("search timeout")
+
+// searchOptions 为全文与结构化搜索共用的可选条件
+type searchOptions struct {
+	Platforms []string // 为空时搜索全部平台
+	Limit     int      // 大于 0 时限制返回的歌词文件数
+	MinScore  float64  // 仅返回评分不低于该值的结果
+	Lang      string   // 非空时仅返回该语言的条目
+	Sources   []string // 非空时仅返回这些来源的条目（见 entrySource）
+	Phonetic  bool     // 全文搜索同时按读音匹配歌名与歌手（见 phonetic.go）
+
+	// 非 nil 时要求条目是否含翻译/音译/逐字时间与之一致
+	HasTranslation *bool
+	HasRoman       *bool
+	WordTimed      *bool
+}
+
+// accepts 判断条目是否满足评分以外的过滤条件
+func (o searchOptions) accepts(entry *IndexEntry) bool {
+	switch {
+	case o.Lang != "" && entry.Lang != o.Lang:
+		return false
+	case o.HasTranslation != nil && entry.HasTranslation != *o.HasTranslation:
+		return false
+	case o.HasRoman != nil && entry.HasRoman != *o.HasRoman:
+		return false
+	case o.WordTimed != nil && entry.WordTimed != *o.WordTimed:
+		return false
+	case len(o.Sources) > 0 && !slices.Contains(o.Sources, entrySource(entry)):
+		return false
+	}
+	return true
+}
+
+// cacheSuffix 返回区分不同选项的整体结果缓存键后缀
+func (o searchOptions) cacheSuffix() string {
+	var suffix string
+	if o.Limit > 0 {
+		suffix += fmt.Sprintf("\x00limit=%d", o.Limit)
+	}
+	suffix += o.filterSuffix()
+	suffix += "\x00platforms=" + strings.Join(o.Platforms, ",")
+	return suffix
+}
+
+// filterSuffix 返回决定单个平台匹配结果的选项（不含 limit 与平台），用于按平台缓存
+func (o searchOptions) filterSuffix() string {
+	var suffix string
+	if o.MinScore > 0 {
+		suffix += fmt.Sprintf("\x00minScore=%g", o.MinScore)
+	}
+	if o.Lang != "" {
+		suffix += "\x00lang=" + o.Lang
+	}
+	if o.HasTranslation != nil {
+		suffix += fmt.Sprintf("\x00hasTranslation=%t", *o.HasTranslation)
+	}
+	if o.HasRoman != nil {
+		suffix += fmt.Sprintf("\x00hasRoman=%t", *o.HasRoman)
+	}
+	if o.WordTimed != nil {
+		suffix += fmt.Sprintf("\x00wordTimed=%t", *o.WordTimed)
+	}
+	if len(o.Sources) > 0 {
+		suffix += "\x00sources=" + strings.Join(o.Sources, ",")
+	}
+	if o.Phonetic {
+		suffix += "\x00phonetic"
+	}
+	return suffix
+}
+
+// searchOutcome 为一次搜索的结果，HTTP 与 gRPC 接口共用
+type searchOutcome struct {
+	Results   []SearchResult
+	Truncated bool
+	Cached    bool
+}
+
+// runSearch 在所有目标平台中执行全文搜索（优先命中缓存），query 须已转为小写并去除首尾空白；
+// 启用 -index-lyrics 时，歌词正文包含全部查询词元的条目也会命中，评分取元数据评分与正文 BM25 评分中的较高者
+func runSearch(ctx context.Context, query string, opts searchOptions) (searchOutcome, error) {
+	query = rewriteQuery(currentRewriteRules(), query)
+	content := currentContentStats()
+	terms := contentTokens(query)
+	var sounds []string
+	if opts.Phonetic {
+		sounds = phoneticKeys(query)
+	}
+	// 与构建 SearchBlob 时使用相同的同义词与停用词规则
+	query = currentTextRules().apply(query)
+	m := newMatcher(query)
+	return executeSearch(ctx, query, func(entry *IndexEntry) (float64, bool) {
+		score, ok := 0.0, m.match(entry.SearchBlob)
+		if ok {
+			score = textScore(entry, query)
+		}
+		if content != nil {
+			if s, hit := content.bm25(entry.Content, terms); hit {
+				score, ok = max(score, contentScore(s)), true
+			}
+		}
+		if !ok && len(sounds) > 0 {
+			score, ok = phoneticScore(entry.Phonetic, sounds)
+		}
+		return score, ok
+	}, opts)
+}
+
+// executeSearch 扫描、合并并缓存命中 scorer 的条目，key 须唯一标识该匹配条件
+func executeSearch(ctx context.Context, key string, scorer entryScorer, opts searchOptions) (searchOutcome, error) {
+	if len(opts.Platforms) == 0 {
+		opts.Platforms = platformList()
+	} else {
+		opts.Platforms = resolvePlatforms(opts.Platforms)
+	}
+	if opts.Limit < 0 {
+		opts.Limit = 0
+	}
+	limit := opts.Limit
+
+	// 截断或按评分过滤的结果与完整结果分开缓存
+	query := key
+	cacheKey := key + opts.cacheSuffix()
+
+	// 尝试从缓存获取；记录缓存代数，扫描期间索引被重新加载时不缓存基于旧索引的结果
+	gen := cacheGeneration()
+	if cachedResults, ok := getFromCache(cacheKey); ok {
+		logDebugf("Cache hit for query: %s", query)
+		return searchOutcome{
+			Results:   cachedResults,
+			Truncated: limit > 0 && len(cachedResults) >= limit,
+			Cached:    true,
+		}, nil
+	}
+
+	// 已缓存的平台直接复用其匹配结果，只扫描其余平台
+	filterKey := key + opts.filterSuffix()
+	var reused []scanJob
+	var missing []string
+	for _, p := range opts.Platforms {
+		if results, ok := getFromCache(platformCacheKey(filterKey, p)); ok {
+			// 合并时会修改条目，复制一份以免影响缓存
+			reused = append(reused, scanJob{platform: p, results: append([]SearchResult(nil), results...)})
+		} else {
+			missing = append(missing, p)
+		}
+	}
+	if len(reused) > 0 {
+		logDebugf("Platform cache hit for query: %s (%d of %d platforms)", query, len(reused), len(opts.Platforms))
+	} else {
+		logDebugf("Cache miss for query: %s", query)
+	}
+
+	// 分片并行扫描各平台
+	start := time.Now()
+	var parts []scanJob
+	var stats scanStats
+	if len(missing) > 0 {
+		scanOpts := opts
+		scanOpts.Platforms = missing
+		done := make(chan struct{})
+		go func() {
+			parts = scanPlatforms(ctx, scorer, scanOpts, &stats)
+			close(done)
+		}()
+
+		// 超时控制（超时时扫描仍在进行，统计不完整）
+		select {
+		case <-done:
+		case <-ctx.Done():
+			// worker 会在检查到 ctx 取消后停止扫描；等扫描退出后再归还已收集的结果切片
+			go func() {
+				<-done
+				releaseParts(parts)
+			}()
+			logSlowQuery(query, opts, scanStats{}, 0, time.Since(start), true)
+			return searchOutcome{}, errSearchTimeout
+		}
+		logDebugf("Scanned query %s in %v (scanned/matched): %s", query, time.Since(start).Round(time.Microsecond), stats.platformSummary())
+		savePlatformResults(filterKey, missing, parts, gen)
+	}
+
+	// 更高效的结果合并和去重（合并用 map 取自对象池）
+	finalMap := getMergeMap()
+
+	for _, part := range append(reused, parts...) {
+		list := part.results
+		for i := range list {
+			item := &list[i]
+			source := songSource{Platform: part.platform, ID: item.ID, Metadata: item.Metadata, Score: item.Score}
+			// 不同仓库中的同名歌词文件内容可能不同，不合并
+			key := item.RawLyricFile
+			if item.Source != "" {
+				key = item.Source + "\x00" + key
+			}
+			if existing, ok := finalMap[key]; ok {
+				// 避免重复分配，直接append到existing.Platforms
+				existing.Platforms = append(existing.Platforms, item.Platforms...)
+				existing.Sources = append(existing.Sources, source)
+				if item.Score > existing.Score {
+					existing.Score = item.Score
+				}
+			} else {
+				item.Sources = []songSource{source}
+				finalMap[key] = item
+			}
+		}
+	}
+
+	// 预分配最终结果切片（复制出结果后即可归还池化对象）；
+	// 跨平台合并的结果由优先级最高的平台提供 ID 与元数据，而不取决于 worker 的完成顺序
+	ranks := platformRanks()
+	finalResults := make([]SearchResult, 0, len(finalMap))
+	for _, v := range finalMap {
+		if len(v.Sources) > 1 {
+			sort.SliceStable(v.Sources, func(i, j int) bool {
+				return rankOf(ranks, v.Sources[i].Platform) < rankOf(ranks, v.Sources[j].Platform)
+			})
+			v.ID, v.Metadata = v.Sources[0].ID, v.Sources[0].Metadata
+			for i, src := range v.Sources {
+				v.Platforms[i] = src.Platform
+			}
+		}
+		v.PlatformIDs = sourcePlatformIDs(v.Sources)
+		finalResults = append(finalResults, *v)
+	}
+	putMergeMap(finalMap)
+	releaseParts(parts)
+
+	// 按评分从高到低排序，评分相同时按文件名与来源保证顺序稳定
+	sort.Slice(finalResults, func(i, j int) bool { return byScore(&finalResults[i], &finalResults[j]) })
+
+	// 排序后按 limit 截断，返回的是评分最高的前 limit 项
+	truncated := false
+	if limit > 0 && len(finalResults) > limit {
+		finalResults = finalResults[:limit]
+		truncated = true
+	}
+
+	logSlowQuery(query, opts, stats, len(finalResults), time.Since(start), false)
+
+	// 保存到缓存
+	if len(finalResults) > 0 {
+		saveToCache(cacheKey, finalResults, gen)
+	}
+
+	return searchOutcome{Results: finalResults, Truncated: truncated, Cached: len(missing) == 0}, nil
+}
+
+// platformCacheKey 返回单个平台匹配结果的缓存键，与整体结果共用查询缓存
+func platformCacheKey(filterKey, platform string) string {
+	return filterKey + "\x00platform=" + platform
+}
+
+// savePlatformResults 按平台缓存一次完整扫描的匹配结果（含无匹配的平台），结果从对象池切片中复制出来
+func savePlatformResults(filterKey string, platforms []string, parts []scanJob, gen uint64) {
+	byPlatform := make(map[string][]SearchResult, len(platforms))
+	for _, part := range parts {
+		byPlatform[part.platform] = append(byPlatform[part.platform], part.results...)
+	}
+	for _, p := range platforms {
+		saveToCache(platformCacheKey(filterKey, p), byPlatform[p], gen)
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// searchResponse 为 /api/search 响应中分页测试关心的字段
+type searchResponse struct {
+	Total      int    `json:"total"`
+	HasMore    bool   `json:"hasMore"`
+	NextCursor string `json:"nextCursor"`
+	Results    []struct {
+		ID    string  `json:"id"`
+		Score float64 `json:"score"`
+	} `json:"results"`
+}
+
+func getSearchResponse(t *testing.T, h http.Handler, params url.Values) searchResponse {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search?"+params.Encode(), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("search %s: status %d: %s", params.Encode(), rec.Code, rec.Body)
+	}
+	var page searchResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatal(err)
+	}
+	return page
+}
+
+// 评分最高的条目位于扫描顺序的末尾：limit 不能只取最先扫描到的匹配，total 与翻页也须覆盖全部匹配
+func TestSearchLimitKeepsBestMatchPastScanOrder(t *testing.T) {
+	const partial = 3000
+	lines := make([]string, 0, partial+1)
+	for i := 0; i < partial; i++ {
+		lines = append(lines, testEntry(1000+i, fmt.Sprintf("Needle in the haystack %d", i), "Someone"))
+	}
+	lines = append(lines, testEntry(9999, "Needle", "Someone"))
+	// 单个 worker、小分片使扫描顺序固定为文件顺序，完全匹配最后才被扫描到
+	h := loadTestIndex(t, lines, "-search-workers", "1", "-shard-size", "64")
+
+	page := getSearchResponse(t, h, url.Values{"query": {"needle"}, "limit": {"5"}})
+	if len(page.Results) != 5 || page.Results[0].ID != "9999" {
+		t.Fatalf("first page starts with %+v, want the exact match 9999", page.Results)
+	}
+	if page.Total != partial+1 || !page.HasMore {
+		t.Fatalf("total %d, hasMore %t; want %d and true", page.Total, page.HasMore, partial+1)
+	}
+
+	seen := make(map[string]bool)
+	params := url.Values{"query": {"needle"}, "limit": {"10"}}
+	for {
+		page := getSearchResponse(t, h, params)
+		for _, r := range page.Results {
+			if seen[r.ID] {
+				t.Fatalf("result %s returned twice while paging", r.ID)
+			}
+			seen[r.ID] = true
+		}
+		if !page.HasMore {
+			break
+		}
+		params.Set("cursor", page.NextCursor)
+	}
+	if len(seen) != partial+1 {
+		t.Fatalf("paging returned %d distinct results, want %d", len(seen), partial+1)
+	}
+}
+
+func TestSearchRejectsInvalidLimit(t *testing.T) {
+	h := setupReloadFixture(t, 10)
+	for _, limit := range []string{"abc", "-1", "1.5"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/search?query=song&limit="+limit, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("limit=%s: status %d, want 400", limit, rec.Code)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/search", strings.NewReader(`{"query": "song", "limit": -1}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("POST limit -1: status %d, want 400", rec.Code)
+	}
+}
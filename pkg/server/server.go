@@ -0,0 +1,291 @@
+// Package server 是 AMLL TTML 歌词搜索服务的实现，既供命令行（见 Main）使用，也可嵌入其他 Go 程序：
+//
+//	srv, err := server.New(server.WithDataDir("lyric-data"), server.WithAddr(":43594"))
+//	if err != nil { ... }
+//	if err := srv.Start(); err != nil { ... }
+//	defer srv.Stop(context.Background())
+//
+// 服务的索引、缓存与配置保存在包级状态中，因此同一进程内只能创建一个 Server，Stop 之后也不能再次创建；
+// New 失败时不占用这一名额，可以修正参数后重试。
+package server
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// --- 可嵌入的服务 ---
+
+// HTTP 监听的超时：只限制读取请求头与空闲连接，不设写超时，以免中断 SSE、WebSocket 等长连接
+const (
+	readHeaderTimeout = 10 * time.Second
+	idleTimeout       = 2 * time.Minute
+)
+
+// shutdownTimeout 为命令行启动时收到退出信号后等待进行中请求完成的最长时间
+const shutdownTimeout = 30 * time.Second
+
+// Server 为嵌入式的搜索服务，本身即为提供全部 HTTP API 的 http.Handler
+type Server struct {
+	addr       string
+	listenPort bool // 由命令行启动时监听 -port（见 runServe）
+	flagOutput io.Writer
+	args       []string
+	middleware []func(http.Handler) http.Handler
+	setters    []func()
+
+	handler    http.Handler
+	cancel     context.CancelFunc
+	servers    []*http.Server
+	grpcServer *grpc.Server
+	serveErr   chan error // 监听开始后服务失败的错误，见 runServe
+
+	mu      sync.Mutex
+	started bool
+	stopped bool
+}
+
+// Option 为 New 的配置项
+type Option func(*Server)
+
+// serverCreated 保证同一进程只创建一个 Server；只在 New 失败时复位，Stop 之后仍保持占用
+var serverCreated atomic.Bool
+
+// WithArgs 以命令行参数的形式设置 serve 子命令的任意参数（如 "-index-lyrics", "-admin-token=secret"），
+// 先于其余选项生效
+func WithArgs(args ...string) Option {
+	return func(s *Server) { s.args = append(s.args, args...) }
+}
+
+// WithDataDir 指定数据目录，重复使用时之后的目录作为叠加目录（同 -data-dir）
+func WithDataDir(dir string) Option {
+	return WithArgs("-data-dir", dir)
+}
+
+// WithRepoURL 指定同步的上游数据仓库地址，默认为 amll-ttml-db
+func WithRepoURL(url string) Option {
+	return func(s *Server) { s.setters = append(s.setters, func() { repoURL = url }) }
+}
+
+// WithSync 设置定时 Git 同步的间隔，interval 为 0 时禁止同步，只使用本地已有数据（同 -no-sync）
+func WithSync(interval time.Duration) Option {
+	return func(s *Server) {
+		s.setters = append(s.setters, func() {
+			*noSync = interval <= 0
+			if interval > 0 {
+				*syncInterval = interval
+			}
+		})
+	}
+}
+
+// WithCacheTTL 设置查询缓存中结果的有效期
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(s *Server) { s.setters = append(s.setters, func() { queryCacheTTL = ttl }) }
+}
+
+// WithAdminToken 设置管理接口所需的 Bearer 令牌（同 -admin-token）
+func WithAdminToken(token string) Option {
+	return func(s *Server) { s.setters = append(s.setters, func() { *adminToken = token }) }
+}
+
+// WithAddr 设置 Start 监听的地址，如 ":43594"；为空时 Start 只启动后台任务，由调用方自行挂载 Handler
+func WithAddr(addr string) Option {
+	return func(s *Server) { s.addr = addr }
+}
+
+// WithMiddleware 在全部路由外层包装中间件（如认证、计费、日志），先指定的位于最外层；
+// 需要搜索或下载的具体信息时改用 OnSearch、OnDownload（见 hooks.go）
+func WithMiddleware(mw ...func(http.Handler) http.Handler) Option {
+	return func(s *Server) { s.middleware = append(s.middleware, mw...) }
+}
+
+// withCommandLine 供 runServe 使用：监听 -port，参数错误与 -h 的说明输出到标准错误
+func withCommandLine() Option {
+	return func(s *Server) {
+		s.listenPort = true
+		s.flagOutput = os.Stderr
+	}
+}
+
+// New 按选项配置服务，执行首次同步（未禁用时）并加载索引后返回；
+// 未指定的参数取命令行的默认值，后台同步与监听在 Start 后才开始
+func New(opts ...Option) (*Server, error) {
+	if !serverCreated.CompareAndSwap(false, true) {
+		return nil, errors.New("server: only one Server can be created per process, even after Stop")
+	}
+	s, err := newServer(opts)
+	if err != nil {
+		// 失败时撤销已注册的回调，使修正参数后的重试不会重复调用
+		clearHooks()
+		serverCreated.Store(false)
+		return nil, err
+	}
+	return s, nil
+}
+
+func newServer(opts []Option) (*Server, error) {
+	s := &Server{flagOutput: io.Discard}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	resetListFlags()
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.SetOutput(s.flagOutput)
+	registerServeFlags(fs)
+	if err := fs.Parse(s.args); err != nil {
+		return nil, err
+	}
+	for _, set := range s.setters {
+		set()
+	}
+	if s.listenPort {
+		s.addr = ":" + *port
+	}
+	if err := validateServeFlags(); err != nil {
+		return nil, err
+	}
+	if err := loadInitialData(); err != nil {
+		return nil, err
+	}
+
+	var h http.Handler = newServeMux()
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		h = s.middleware[i](h)
+	}
+	s.handler = h
+	return s, nil
+}
+
+// resetListFlags 清空可重复指定的参数：注册时 fs.Var 不像 StringVar 那样恢复默认值，
+// New 失败后重试时会在上一次的取值后继续追加
+func resetListFlags() {
+	dataDirs, overlayDataDirs = dataDirFlag{}, nil
+	extraSources, extraRepos = nil, nil
+	extraPlatformAliases, enabledPlatforms, disabledPlatforms, platformPriority = nil, nil, nil, nil
+	fieldWeights, webhookURLs, peers = nil, nil, nil
+	minLogLevel = levelInfo
+}
+
+// ServeHTTP 处理 /api/ 下的全部 HTTP 请求
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// Start 启动后台任务，指定了 WithAddr 时同时在该地址上提供 HTTP 服务，
+// 以 WithArgs 指定的管理端口（-admin-addr）与 gRPC 端口（-grpc-port）也一并监听；监听失败时返回错误
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return errors.New("server: already started") // Stop 之后也不能再次启动
+	}
+	// 管理端口（-admin-addr）与 WithAddr 的公开端口分别监听
+	var listeners []net.Listener
+	var handlers []http.Handler
+	closeAll := func() {
+		for _, opened := range listeners {
+			opened.Close()
+		}
+	}
+	for _, l := range []struct {
+		name, addr string
+		handler    http.Handler
+	}{{"main", s.addr, s}, {"admin", *adminAddr, newAdminMux()}} {
+		if l.addr == "" {
+			continue
+		}
+		lis, err := listen(l.addr, l.name)
+		if err != nil {
+			closeAll()
+			return err
+		}
+		listeners = append(listeners, lis)
+		handlers = append(handlers, l.handler)
+	}
+	// gRPC 自行处理 TLS（见 newGRPCServer），直接监听 TCP
+	var grpcLis net.Listener
+	if *grpcPort != "" {
+		lis, err := net.Listen("tcp", ":"+*grpcPort)
+		if err != nil {
+			closeAll()
+			return fmt.Errorf("gRPC listen failed: %w", err)
+		}
+		grpcLis = lis
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.started = true
+	s.serveErr = make(chan error, len(listeners)+1)
+	startBackgroundTasks(ctx)
+	for i, lis := range listeners {
+		hs := &http.Server{Handler: handlers[i], ReadHeaderTimeout: readHeaderTimeout, IdleTimeout: idleTimeout}
+		s.servers = append(s.servers, hs)
+		go func() {
+			logInfof("Server is listening on %s", lis.Addr())
+			if err := hs.Serve(lis); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logErrorf("Server failed: %v", err)
+				s.serveErr <- err
+			}
+		}()
+	}
+	if grpcLis != nil {
+		gs := newGRPCServer()
+		s.grpcServer = gs
+		go func() {
+			logInfof("gRPC server is listening on %s", grpcLis.Addr())
+			if err := gs.Serve(grpcLis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+				logErrorf("gRPC server failed: %v", err)
+				s.serveErr <- err
+			}
+		}()
+	}
+	return nil
+}
+
+// Stop 在 ctx 到期前等待进行中的 HTTP 请求与 gRPC 调用完成（到期后强制关闭），再停止定时同步、
+// 数据源监听与统计的定期保存，并等待统计数据最后保存一次；停止后不能再次 Start，也不能再创建新的 Server
+func (s *Server) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started || s.stopped {
+		return nil
+	}
+	s.stopped = true
+	var errs []error
+	for _, hs := range s.servers {
+		errs = append(errs, hs.Shutdown(ctx))
+	}
+	s.servers = nil
+	if gs := s.grpcServer; gs != nil {
+		done := make(chan struct{})
+		go func() {
+			gs.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-ctx.Done():
+			gs.Stop()
+			errs = append(errs, ctx.Err())
+		}
+		s.grpcServer = nil
+	}
+	// 请求处理完毕后再停止后台任务，统计数据的最后一次保存包含这些请求
+	s.cancel()
+	errs = append(errs, waitStatsSaved(ctx))
+	return errors.Join(errs...)
+}
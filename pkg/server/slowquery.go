@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// --- 慢查询日志 ---
+
+var (
+	// 命令行参数（见 registerSearchFlags）
+	slowQueryThreshold = new(time.Duration) // 为 0 时不记录
+
+	slowQueryCount atomic.Int64 // 启动以来的慢查询次数，见 /api/status
+)
+
+// scanStats 为一次扫描的统计
+type scanStats struct {
+	Shards  int   // 分片数
+	Scanned int64 // 实际检查过的条目数
+	Matched int   // 命中的条目数（合并前）
+
+	PlatformScanned map[string]int64 // 各平台实际检查过的条目数
+	PlatformMatched map[string]int   // 各平台命中的条目数
+}
+
+// platformSummary 按平台列出检查与命中的条目数，形如 "ncm=1200/3 qq=800/0"，用于调试日志
+func (s scanStats) platformSummary() string {
+	names := make([]string, 0, len(s.PlatformScanned))
+	for p := range s.PlatformScanned {
+		names = append(names, p)
+	}
+	slices.Sort(names)
+	parts := make([]string, len(names))
+	for i, p := range names {
+		parts[i] = fmt.Sprintf("%s=%d/%d", p, s.PlatformScanned[p], s.PlatformMatched[p])
+	}
+	return strings.Join(parts, " ")
+}
+
+// logSlowQuery 在搜索耗时达到 -slow-query 阈值时记录日志并计数；timedOut 表示搜索因超时被中止
+func logSlowQuery(key string, opts searchOptions, stats scanStats, merged int, elapsed time.Duration, timedOut bool) {
+	if *slowQueryThreshold <= 0 || elapsed < *slowQueryThreshold {
+		return
+	}
+	slowQueryCount.Add(1)
+	outcome := "ok"
+	if timedOut {
+		outcome = "timeout"
+	}
+	logWarnf("Slow query (%s, %s): query=%q platforms=%s shards=%d scanned=%d matched=%d merged=%d limit=%d",
+		elapsed.Round(time.Millisecond), outcome, key, strings.Join(opts.Platforms, ","),
+		stats.Shards, stats.Scanned, stats.Matched, merged, opts.Limit)
+}
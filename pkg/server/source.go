@@ -0,0 +1,259 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// --- 歌词数据源 ---
+
+// Source 为一个可提供索引条目与歌词文件的数据源，每个数据源在搜索结果中表现为一个平台
+type Source interface {
+	// Name 返回数据源对应的平台名
+	Name() string
+	// List 读取数据源的全部索引条目（尚未预处理，由 loadMetadata 统一解析元数据并构建 SearchBlob）
+	List() ([]IndexEntry, error)
+	// Get 返回指定歌曲某种格式歌词文件的本地路径，不存在时返回 errLyricNotFound
+	Get(id, format string) (string, error)
+	// Watch 在数据变化时调用 changed，直到 ctx 被取消
+	Watch(ctx context.Context, changed func())
+}
+
+// sourcePollInterval 为本地目录数据源检查 index 文件变化的间隔
+var sourcePollInterval = 30 * time.Second
+
+// dirSource 为本地目录中的数据源：一个 index.jsonl 文件，歌词文件以 <id>.<格式> 存放在同一目录
+type dirSource struct {
+	name      string
+	indexPath string
+}
+
+func newDirSource(name, indexPath string) *dirSource {
+	return &dirSource{name: name, indexPath: indexPath}
+}
+
+func (s *dirSource) Name() string { return s.name }
+
+// Dir 返回歌词文件所在目录
+func (s *dirSource) Dir() string { return filepath.Dir(s.indexPath) }
+
+func (s *dirSource) List() ([]IndexEntry, error) {
+	file, err := os.Open(s.indexPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readIndexEntries(file, s.indexPath)
+}
+
+// readIndexEntries 逐行解析 index.jsonl，跳过格式错误的行；label 用于日志中标识索引文件
+func readIndexEntries(r io.Reader, label string) ([]IndexEntry, error) {
+	// 优化：预分配容量以减少扩容
+	var entries []IndexEntry
+	scanner := bufio.NewScanner(r)
+
+	// 优化：增大缓冲区以提高读取性能
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 1024*1024)
+
+	malformed := 0
+	for scanner.Scan() {
+		var entry IndexEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			if len(strings.TrimSpace(scanner.Text())) > 0 {
+				malformed++
+			}
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if malformed > 0 {
+		logWarnf("Warning: skipped %d malformed line(s) in %s (run 'validate' for details)", malformed, label)
+	}
+	return entries, scanner.Err()
+}
+
+func (s *dirSource) Get(id, format string) (string, error) {
+	filePath, ok := lyricFilePath(s.Dir(), id, format)
+	if !ok {
+		return "", errLyricNotFound
+	}
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return "", errLyricNotFound
+	}
+	return filePath, nil
+}
+
+// Watch 定期检查 index 文件的修改时间
+func (s *dirSource) Watch(ctx context.Context, changed func()) {
+	modTime := func() time.Time {
+		info, err := os.Stat(s.indexPath)
+		if err != nil {
+			return time.Time{}
+		}
+		return info.ModTime()
+	}
+	last := modTime()
+	ticker := time.NewTicker(sourcePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if t := modTime(); !t.Equal(last) {
+				last = t
+				changed()
+			}
+		}
+	}
+}
+
+// gitSource 为一个独立的 Git 仓库数据源，克隆到 -sources-dir/<name>，index.jsonl 位于仓库根目录，按 -interval 定期拉取
+type gitSource struct {
+	name string
+	url  string
+}
+
+func (s *gitSource) Name() string { return s.name }
+
+// Dir 返回仓库的本地克隆目录
+func (s *gitSource) Dir() string { return filepath.Join(*sourcesDir, s.name) }
+
+func (s *gitSource) local() *dirSource {
+	return newDirSource(s.name, filepath.Join(s.Dir(), "index.jsonl"))
+}
+
+// List 在本地尚未克隆时先克隆仓库
+func (s *gitSource) List() ([]IndexEntry, error) {
+	if _, err := os.Stat(filepath.Join(s.Dir(), ".git")); os.IsNotExist(err) {
+		logInfof("Cloning source %s from %s...", s.name, s.url)
+		out, err := exec.Command("git", "clone", "--depth", "1", s.url, s.Dir()).CombinedOutput()
+		logCommandOutput("git clone "+s.name, out)
+		if err != nil {
+			return nil, fmt.Errorf("git clone failed: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+	}
+	return s.local().List()
+}
+
+func (s *gitSource) Get(id, format string) (string, error) {
+	return s.local().Get(id, format)
+}
+
+func (s *gitSource) Watch(ctx context.Context, changed func()) {
+	if *noSync || *syncInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(*syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			before := currentCommit(s.Dir())
+			out, err := exec.Command("git", "-C", s.Dir(), "pull").CombinedOutput()
+			logCommandOutput("git pull "+s.name, out)
+			if err != nil {
+				logErrorf("Source %s: git pull failed: %v: %s", s.name, err, strings.TrimSpace(string(out)))
+				continue
+			}
+			if currentCommit(s.Dir()) != before {
+				changed()
+			}
+		}
+	}
+}
+
+// lyricFilePath 返回歌词文件在 dir 中的路径，各数据源（含远程数据源的本地缓存）共用；
+// ID 与格式来自请求并会成为文件名，包含路径分隔符或 .. 时返回 false，防止访问 dir 之外的文件
+func lyricFilePath(dir, id, format string) (string, bool) {
+	for _, part := range []string{id, format} {
+		if part == "" || strings.ContainsAny(part, `/\`) || strings.Contains(part, "..") {
+			return "", false
+		}
+	}
+	return filepath.Join(dir, id+"."+format), true
+}
+
+// writeCachedFile 先写入临时文件再重命名，避免并发请求读到写了一半的缓存
+func writeCachedFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".download-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// --- 数据源注册 ---
+
+var (
+	// 命令行参数（见 registerDataFlags）
+	extraSources sourceList
+	sourcesDir   = new(string)
+)
+
+// sourceList 为 -source 的取值：name=目录 注册本地目录，name=仓库地址 注册 Git 仓库，name=s3://bucket/prefix 注册对象存储
+type sourceList []Source
+
+func (l *sourceList) String() string {
+	names := make([]string, 0, len(*l))
+	for _, src := range *l {
+		names = append(names, src.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func (l *sourceList) Set(v string) error {
+	name, location, ok := strings.Cut(v, "=")
+	name, location = strings.ToLower(strings.TrimSpace(name)), strings.TrimSpace(location)
+	if !ok || name == "" || location == "" {
+		return fmt.Errorf("invalid source %q, expected name=dir or name=git-url", v)
+	}
+	if isS3URL(location) {
+		bucket, prefix := parseS3URL(location)
+		*l = append(*l, newS3Source(name, bucket, path.Join(prefix, "index.jsonl")))
+	} else if isGitURL(location) {
+		*l = append(*l, &gitSource{name: name, url: location})
+	} else {
+		*l = append(*l, newDirSource(name, filepath.Join(location, "index.jsonl")))
+	}
+	return nil
+}
+
+func isGitURL(s string) bool {
+	return strings.Contains(s, "://") || strings.HasPrefix(s, "git@") || strings.HasSuffix(s, ".git")
+}
+
+// watchSources 监听附加数据源，任一数据源变化时重新加载索引并清除缓存
+func watchSources(ctx context.Context) {
+	for _, src := range extraSources {
+		go src.Watch(ctx, func() {
+			logInfof("Source %s changed, reloading index", src.Name())
+			loadMetadata()
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// --- Server-Sent Events ---
+
+// sseHeartbeatInterval 为 SSE 心跳间隔，防止中间代理因空闲断开连接
+const sseHeartbeatInterval = 30 * time.Second
+
+// eventsHandler 以 SSE 形式推送 sync-started、sync-finished、reload、cache-cleared 等服务器事件
+func eventsHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, r, http.StatusInternalServerError, codeStreamingUnsupported, "Streaming unsupported")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // 关闭 Nginx 缓冲
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	events := subscribeEvents()
+	defer unsubscribeEvents(events)
+
+	heartbeat := time.NewTicker(sseHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case ev := <-events:
+			data, _ := json.Marshal(ev)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
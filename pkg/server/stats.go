@@ -0,0 +1,169 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// --- 数据库统计 ---
+
+// lyricFormats 为可下载的歌词格式
+var lyricFormats = []string{"ttml", "lrc", "yrc", "qrc", "lys"}
+
+type artistCount struct {
+	Artist string `json:"artist"`
+	Count  int    `json:"count"`
+}
+
+type growthStats struct {
+	Since  string         `json:"since"`
+	Before map[string]int `json:"before"`
+	Delta  map[string]int `json:"delta"`
+}
+
+// dbStats 汇总数据库覆盖情况，供维护者追踪
+type dbStats struct {
+	Root             string                    `json:"root"`
+	Commit           string                    `json:"commit,omitempty"`
+	Entries          map[string]int            `json:"entries"`
+	UniqueSongs      int                       `json:"unique_songs"`
+	CrossPlatform    int                       `json:"cross_platform_songs"`
+	TopArtists       []artistCount             `json:"top_artists"`
+	FormatCoverage   map[string]map[string]int `json:"format_coverage"`
+	MissingTTML      map[string]int            `json:"missing_ttml"`
+	OnlyOtherFormats map[string]int            `json:"only_other_formats"`
+	Growth           *growthStats              `json:"growth,omitempty"`
+}
+
+// collectStats 基于已加载的索引统计数据库；topN 控制歌手排行长度，since 非空时计算相对该 commit 的增长
+func collectStats(topN int, since string) (*dbStats, error) {
+	st := &dbStats{
+		Root:             actualDataDir,
+		Commit:           currentCommit(actualDataDir),
+		Entries:          platformCounts(),
+		FormatCoverage:   make(map[string]map[string]int),
+		MissingTTML:      make(map[string]int),
+		OnlyOtherFormats: make(map[string]int),
+	}
+
+	rows := collectExportRows()
+	st.UniqueSongs = len(rows)
+	artists := make(map[string]int)
+	for _, r := range rows {
+		if len(r.Platforms) > 1 {
+			st.CrossPlatform++
+		}
+		for _, a := range r.Artists {
+			artists[a]++
+		}
+	}
+	for a, n := range artists {
+		st.TopArtists = append(st.TopArtists, artistCount{Artist: a, Count: n})
+	}
+	sort.Slice(st.TopArtists, func(i, j int) bool {
+		if st.TopArtists[i].Count != st.TopArtists[j].Count {
+			return st.TopArtists[i].Count > st.TopArtists[j].Count
+		}
+		return st.TopArtists[i].Artist < st.TopArtists[j].Artist
+	})
+	if len(st.TopArtists) > topN {
+		st.TopArtists = st.TopArtists[:topN]
+	}
+
+	// 检查各平台目录中每个条目实际存在的格式（raw 平台不按 ID 存放文件，跳过）
+	mu.RLock()
+	for platform, entries := range dataStore {
+		dir, ok := platformPaths[platform]
+		if !ok || platform == "raw" {
+			continue
+		}
+		coverage := make(map[string]int)
+		for _, entry := range entries {
+			hasTTML, hasOther := false, false
+			for _, f := range lyricFormats {
+				if _, err := os.Stat(filepath.Join(dir, entry.ID+"."+f)); err == nil {
+					coverage[f]++
+					if f == "ttml" {
+						hasTTML = true
+					} else {
+						hasOther = true
+					}
+				}
+			}
+			if !hasTTML {
+				st.MissingTTML[platform]++
+				if hasOther {
+					st.OnlyOtherFormats[platform]++
+				}
+			}
+		}
+		st.FormatCoverage[platform] = coverage
+	}
+	mu.RUnlock()
+
+	if since != "" {
+		before, err := countEntriesAt(actualDataDir, since)
+		if err != nil {
+			return nil, err
+		}
+		growth := &growthStats{Since: since, Before: before, Delta: make(map[string]int)}
+		for p, n := range st.Entries {
+			growth.Delta[p] = n - before[p]
+		}
+		st.Growth = growth
+	}
+	return st, nil
+}
+
+// resolveCommit 将 commit（提交哈希、标签或分支）解析为本地存在的提交；数据仓库默认以 --depth 1 克隆，
+// 本地没有时先从 origin 按需抓取该提交，仍失败时返回说明原因的错误
+func resolveCommit(root, commit string) (string, error) {
+	verify := func(rev string) (string, error) {
+		out, err := exec.Command("git", "-C", root, "rev-parse", "--verify", "--quiet", rev+"^{commit}").Output()
+		return strings.TrimSpace(string(out)), err
+	}
+	if sha, err := verify(commit); err == nil {
+		return sha, nil
+	}
+	if out, err := exec.Command("git", "-C", root, "fetch", "--quiet", "--depth=1", "origin", commit).CombinedOutput(); err != nil {
+		return "", fmt.Errorf("commit %q is not in the local clone and could not be fetched from origin: %s", commit, strings.TrimSpace(string(out)))
+	}
+	if sha, err := verify("FETCH_HEAD"); err == nil {
+		return sha, nil
+	}
+	return "", fmt.Errorf("commit %q is not in the local clone and could not be fetched from origin", commit)
+}
+
+// countEntriesAt 统计指定 commit 时各平台 index 文件中可被加载的条目数（与加载器一样跳过无效行）
+func countEntriesAt(root, commit string) (map[string]int, error) {
+	sha, err := resolveCommit(root, commit)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int)
+	for platform, path := range indexFiles(root) {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			continue
+		}
+		out, err := exec.Command("git", "-C", root, "show", sha+":"+filepath.ToSlash(rel)).Output()
+		if err != nil {
+			// 该 commit 时此平台可能尚不存在
+			continue
+		}
+		n := 0
+		for _, line := range bytes.Split(out, []byte("\n")) {
+			if len(bytes.TrimSpace(line)) > 0 && json.Valid(line) {
+				n++
+			}
+		}
+		counts[platform] = n
+	}
+	return counts, nil
+}
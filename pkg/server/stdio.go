@@ -0,0 +1,252 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strings"
+)
+
+// --- JSON-RPC over stdio ---
+
+// 使用 JSON-RPC 2.0 协议，每行一条请求/响应，便于桌面播放器以子进程方式嵌入而无需监听端口
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// JSON-RPC 2.0 标准错误码
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+// serveStdio 从 in 逐行读取请求并将响应写入 out，直到输入结束
+func serveStdio(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	enc := json.NewEncoder(out)
+	enc.SetEscapeHTML(false)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var req rpcRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			enc.Encode(rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: rpcParseError, Message: err.Error()}})
+			continue
+		}
+		resp := handleRPC(req)
+		// 不带 id 的请求为通知，不返回响应
+		if len(req.ID) == 0 {
+			continue
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func handleRPC(req rpcRequest) rpcResponse {
+	resp := rpcResponse{JSONRPC: "2.0", ID: req.ID}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		resp.Error = &rpcError{Code: rpcInvalidRequest, Message: "invalid request"}
+		return resp
+	}
+
+	var result interface{}
+	var err error
+	switch req.Method {
+	case "search":
+		result, err = rpcSearch(req.Params)
+	case "get":
+		result, err = rpcGet(req.Params)
+	case "convert":
+		result, err = rpcConvert(req.Params)
+	default:
+		resp.Error = &rpcError{Code: rpcMethodNotFound, Message: "method not found: " + req.Method}
+		return resp
+	}
+
+	var perr *rpcError
+	switch {
+	case errors.As(err, &perr):
+		resp.Error = perr
+	case err != nil:
+		resp.Error = &rpcError{Code: rpcInternalError, Message: err.Error()}
+	default:
+		resp.Result = result
+	}
+	return resp
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+func invalidParams(err error) error {
+	return &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+}
+
+func rpcSearch(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		Query          string   `json:"query"`
+		Platforms      []string `json:"platforms"`
+		Limit          int      `json:"limit"`
+		MinScore       float64  `json:"minScore"`
+		Lang           string   `json:"lang"`
+		HasTranslation *bool    `json:"hasTranslation"`
+		HasRoman       *bool    `json:"hasRoman"`
+		WordTimed      *bool    `json:"wordTimed"`
+		Phonetic       bool     `json:"phonetic"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, invalidParams(err)
+	}
+
+	query := strings.ToLower(strings.TrimSpace(p.Query))
+	if query == "" {
+		return map[string]interface{}{"count": 0, "results": []SearchResult{}}, nil
+	}
+	if err := validateQuery(query, fieldQuery{}); err != nil {
+		return nil, invalidParams(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *searchTimeout)
+	defer cancel()
+	outcome, err := runSearch(ctx, query, searchOptions{
+		Platforms:      p.Platforms,
+		Limit:          p.Limit,
+		MinScore:       p.MinScore,
+		Lang:           p.Lang,
+		HasTranslation: p.HasTranslation,
+		HasRoman:       p.HasRoman,
+		WordTimed:      p.WordTimed,
+		Phonetic:       p.Phonetic,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"count":     len(outcome.Results),
+		"results":   withParsedFields(outcome.Results),
+		"truncated": outcome.Truncated,
+	}, nil
+}
+
+func rpcGet(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		Platform string `json:"platform"`
+		MusicID  string `json:"musicId"`
+		Format   string `json:"format"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, invalidParams(err)
+	}
+	if !validDownloadFormat(p.Format) {
+		return nil, invalidParams(errors.New("format must be one of auto, " + strings.Join(lyricFileFormats, ", ")))
+	}
+
+	resolve := func(format string) (string, error) { return resolveLyricFile(p.Platform, p.MusicID, format) }
+	var filePath, detected string
+	var err error
+	if p.Format == "auto" {
+		filePath, detected, err = resolveAutoLyricFile(resolve)
+	} else {
+		filePath, err = resolve(p.Format)
+	}
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if detected != "" {
+		return map[string]interface{}{"content": string(data), "format": detected}, nil
+	}
+	return map[string]interface{}{"content": string(data)}, nil
+}
+
+// rpcConvert 转换歌词格式，输入可以是直接给出的 content，也可以是按 platform/musicId 引用的已存储文件
+func rpcConvert(params json.RawMessage) (interface{}, error) {
+	var p struct {
+		Content  string `json:"content"`
+		Platform string `json:"platform"`
+		MusicID  string `json:"musicId"`
+		From     string `json:"from"`
+		To       string `json:"to"`
+		convertOptions
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, invalidParams(err)
+	}
+	if err := p.convertOptions.validate(); err != nil {
+		return nil, invalidParams(err)
+	}
+	if p.From == "" {
+		p.From = "ttml"
+	}
+
+	data := []byte(p.Content)
+	if p.Content == "" {
+		if !validDownloadFormat(p.From) {
+			return nil, invalidParams(errors.New("from must be one of auto, " + strings.Join(lyricFileFormats, ", ")))
+		}
+		resolve := func(format string) (string, error) { return resolveLyricFile(p.Platform, p.MusicID, format) }
+		var filePath string
+		var err error
+		if p.From == "auto" {
+			filePath, _, err = resolveAutoLyricFile(resolve)
+		} else {
+			filePath, err = resolve(p.From)
+		}
+		if err != nil {
+			return nil, invalidParams(err)
+		}
+		if data, err = os.ReadFile(filePath); err != nil {
+			return nil, err
+		}
+	}
+
+	out, err := convertLyric(data, p.From, p.To, p.convertOptions)
+	if err != nil {
+		return nil, invalidParams(err)
+	}
+	if p.To == "json" || p.To == "words" {
+		return json.RawMessage(out), nil
+	}
+	return map[string]interface{}{"content": string(out)}, nil
+}
+
+// runStdioMode 在标准输入输出上提供 JSON-RPC 服务，日志仍输出到标准错误
+func runStdioMode() {
+	logInfof("Serving JSON-RPC on stdio")
+	if err := serveStdio(os.Stdin, os.Stdout); err != nil {
+		log.Fatalf("Stdio mode failed: %v", err)
+	}
+}
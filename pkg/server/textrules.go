@@ -0,0 +1,109 @@
+package server
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// --- 同义词与停用词 ---
+
+// textRules 在构建 SearchBlob 与规范化查询时统一改写文本：
+// 同义词替换为所在组的第一个词，停用词直接丢弃。均按空白分隔的单词匹配（不区分大小写）
+type textRules struct {
+	synonyms  map[string]string
+	stopwords map[string]bool
+}
+
+// activeTextRules 为当前生效的规则，由 mu 保护；为 nil 时不做任何改写
+var activeTextRules *textRules
+
+// readRuleLines 读取规则文件中的有效行（跳过空行与 # 注释）
+func readRuleLines(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// loadTextRules 读取同义词文件（每行一组，以 "|" 分隔，第一个词为规范形式）
+// 与停用词文件（每行一个词），路径为空的文件跳过；两者都未指定时返回 nil
+func loadTextRules(synonymsPath, stopwordsPath string) (*textRules, error) {
+	if synonymsPath == "" && stopwordsPath == "" {
+		return nil, nil
+	}
+	rules := &textRules{synonyms: make(map[string]string), stopwords: make(map[string]bool)}
+
+	if synonymsPath != "" {
+		lines, err := readRuleLines(synonymsPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			words := strings.Split(line, "|")
+			canonical := strings.ToLower(strings.TrimSpace(words[0]))
+			for _, w := range words[1:] {
+				if w = strings.ToLower(strings.TrimSpace(w)); w != "" {
+					rules.synonyms[w] = canonical
+				}
+			}
+		}
+	}
+
+	if stopwordsPath != "" {
+		lines, err := readRuleLines(stopwordsPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			rules.stopwords[strings.ToLower(line)] = true
+		}
+	}
+	return rules, nil
+}
+
+// ruleKey 去掉单词两侧的括号等包裹符号，使 "(Live)" 与 "live" 对应同一条规则
+func ruleKey(word string) string {
+	return strings.Trim(word, "()[]{}<>（）【】「」\"',;:!?")
+}
+
+// apply 改写小写文本；rules 为 nil 或文本全部由停用词组成时原样返回
+func (rules *textRules) apply(s string) string {
+	if rules == nil {
+		return s
+	}
+	words := strings.Fields(s)
+	out := make([]string, 0, len(words))
+	for _, w := range words {
+		key := ruleKey(w)
+		if rules.stopwords[key] {
+			continue
+		}
+		if canonical, ok := rules.synonyms[key]; ok {
+			w = canonical
+		}
+		out = append(out, w)
+	}
+	if len(out) == 0 {
+		return s
+	}
+	return strings.Join(out, " ")
+}
+
+// currentTextRules 返回当前生效的规则
+func currentTextRules() *textRules {
+	mu.RLock()
+	defer mu.RUnlock()
+	return activeTextRules
+}
@@ -0,0 +1,127 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"strconv"
+)
+
+// --- 时间轴变换 ---
+
+// timingTransform 描述转换或下载时对歌词时间轴的调整，零值表示不调整；
+// 依次执行缩放、偏移、负值截断与最小行间隔
+type timingTransform struct {
+	Offset int64   `json:"offset,omitempty"` // 毫秒，正值使歌词延后显示
+	Scale  float64 `json:"scale,omitempty"`  // 时间乘以该系数，如 1.25 倍速版本取 0.8；0 视为 1
+	Clamp  bool    `json:"clamp,omitempty"`  // 将变换后为负的时间截断为 0
+	MinGap int64   `json:"minGap,omitempty"` // 毫秒，相邻两行之间至少留出的间隔，不足时提前前一行的结束时间
+}
+
+// isZero 报告变换是否不会改变任何时间
+func (t timingTransform) isZero() bool {
+	return t.Offset == 0 && (t.Scale == 0 || t.Scale == 1) && !t.Clamp && t.MinGap == 0
+}
+
+// validate 检查参数范围
+func (t timingTransform) validate() error {
+	if t.Scale < 0 || math.IsNaN(t.Scale) || math.IsInf(t.Scale, 0) {
+		return errors.New("scale must be a positive number")
+	}
+	if t.MinGap < 0 {
+		return errors.New("minGap must not be negative")
+	}
+	return nil
+}
+
+// parseTimingQuery 从查询参数 offset、scale、clamp、minGap 读取时间轴变换
+func parseTimingQuery(q url.Values) (timingTransform, error) {
+	var t timingTransform
+	var err error
+	if v := q.Get("offset"); v != "" {
+		if t.Offset, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return t, errors.New("offset must be an integer number of milliseconds")
+		}
+	}
+	if v := q.Get("scale"); v != "" {
+		if t.Scale, err = strconv.ParseFloat(v, 64); err != nil {
+			return t, errors.New("scale must be a positive number")
+		}
+	}
+	if v := q.Get("clamp"); v != "" {
+		if t.Clamp, err = strconv.ParseBool(v); err != nil {
+			return t, errors.New("clamp must be true or false")
+		}
+	}
+	if v := q.Get("minGap"); v != "" {
+		if t.MinGap, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return t, errors.New("minGap must be an integer number of milliseconds")
+		}
+	}
+	return t, t.validate()
+}
+
+// encode 将非零的变换参数写入查询参数，用于把下载请求转发给对等实例
+func (t timingTransform) encode(q url.Values) {
+	if t.Offset != 0 {
+		q.Set("offset", strconv.FormatInt(t.Offset, 10))
+	}
+	if t.Scale != 0 && t.Scale != 1 {
+		q.Set("scale", strconv.FormatFloat(t.Scale, 'f', -1, 64))
+	}
+	if t.Clamp {
+		q.Set("clamp", "true")
+	}
+	if t.MinGap != 0 {
+		q.Set("minGap", strconv.FormatInt(t.MinGap, 10))
+	}
+}
+
+// apply 就地变换歌词中所有行与词的时间
+func (t timingTransform) apply(lyric *Lyric) {
+	if t.isZero() {
+		return
+	}
+	at := func(ms int64) int64 {
+		if t.Scale != 0 && t.Scale != 1 {
+			ms = int64(math.Round(float64(ms) * t.Scale))
+		}
+		ms += t.Offset
+		if t.Clamp && ms < 0 {
+			ms = 0
+		}
+		return ms
+	}
+	for i := range lyric.Lines {
+		l := &lyric.Lines[i]
+		l.Start, l.End = at(l.Start), at(l.End)
+		for j := range l.Words {
+			l.Words[j].Start, l.Words[j].End = at(l.Words[j].Start), at(l.Words[j].End)
+		}
+	}
+	if t.MinGap > 0 {
+		snapLineGaps(lyric.Lines, t.MinGap)
+	}
+}
+
+// snapLineGaps 使每个主歌词行在下一主歌词行开始前至少 gap 毫秒结束；
+// 背景人声行本就与主歌词重叠，不参与调整
+func snapLineGaps(lines []LyricLine, gap int64) {
+	prev := -1
+	for i := range lines {
+		if lines[i].IsBackground {
+			continue
+		}
+		if prev >= 0 {
+			l := &lines[prev]
+			if limit := lines[i].Start - gap; l.End > limit {
+				l.End = max(limit, l.Start)
+				for j := range l.Words {
+					l.Words[j].End = min(l.Words[j].End, l.End)
+					l.Words[j].Start = min(l.Words[j].Start, l.Words[j].End)
+				}
+			}
+		}
+		prev = i
+	}
+}
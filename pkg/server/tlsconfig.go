@@ -0,0 +1,106 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+// --- TLS 与客户端证书 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	tlsCertFile         = new(string) // 与 -tls-key 同时指定时公开端口与管理端口改用 HTTPS
+	tlsKeyFile          = new(string)
+	clientCAFile        = new(string) // 签发客户端证书的 CA（PEM），指定后按 -client-cert-listeners 要求客户端证书
+	clientCertListeners = new(string) // 逗号分隔的 main、admin、grpc
+
+	serverCert         tls.Certificate
+	clientCAs          *x509.CertPool
+	clientCertRequired []string // 解析后的 -client-cert-listeners
+)
+
+// tlsListeners 为 -client-cert-listeners 可选的端口：main 为 -port，admin 为 -admin-addr，grpc 为 -grpc-port
+var tlsListeners = []string{"main", "admin", "grpc"}
+
+// loadTLSConfig 检查 TLS 参数并读取证书与客户端 CA
+func loadTLSConfig() error {
+	if (*tlsCertFile == "") != (*tlsKeyFile == "") {
+		return errors.New("-tls-cert and -tls-key must be given together")
+	}
+	clientCertRequired = nil
+	for _, l := range strings.Split(*clientCertListeners, ",") {
+		if l = strings.TrimSpace(l); l == "" {
+			continue
+		}
+		if !slices.Contains(tlsListeners, l) {
+			return fmt.Errorf("invalid -client-cert-listeners %q, expected one or more of %s", l, strings.Join(tlsListeners, ", "))
+		}
+		clientCertRequired = append(clientCertRequired, l)
+	}
+	if *tlsCertFile == "" {
+		if *clientCAFile != "" {
+			return errors.New("-client-ca requires -tls-cert and -tls-key")
+		}
+		return nil
+	}
+	cert, err := tls.LoadX509KeyPair(*tlsCertFile, *tlsKeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %v", err)
+	}
+	serverCert = cert
+	if *clientCAFile != "" {
+		pem, err := os.ReadFile(*clientCAFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in -client-ca %s", *clientCAFile)
+		}
+		clientCAs = pool
+	}
+	return nil
+}
+
+// serverTLSConfig 返回 name 端口的 TLS 配置（未启用 TLS 时为 nil）：name 出现在 -client-cert-listeners 中时
+// 要求并校验客户端证书，否则客户端可选择出示证书（校验通过即可代替 API 密钥）
+func serverTLSConfig(name string) *tls.Config {
+	if *tlsCertFile == "" {
+		return nil
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAs != nil {
+		cfg.ClientCAs = clientCAs
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+		if slices.Contains(clientCertRequired, name) {
+			cfg.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return cfg
+}
+
+// listen 在 addr 上监听，启用 TLS 时按 serverTLSConfig 包装为 TLS 监听器
+func listen(addr, name string) (net.Listener, error) {
+	lis, err := net.Listen("tcp", addr)
+	cfg := serverTLSConfig(name)
+	if err != nil || cfg == nil {
+		return lis, err
+	}
+	cfg.NextProtos = []string{"h2", "http/1.1"}
+	return tls.NewListener(lis, cfg), nil
+}
+
+// verifiedClientCert 报告请求是否出示了经 -client-ca 校验的客户端证书
+func verifiedClientCert(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+}
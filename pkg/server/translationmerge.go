@@ -0,0 +1,57 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+)
+
+// --- 外部翻译合并 ---
+
+// translationAlignTolerance 为翻译行与原文行开始时间允许的最大偏差（毫秒）
+const translationAlignTolerance = 500
+
+// mergeTranslation 将外部翻译歌词（通常为 LRC）按开始时间逐行并入原文：
+// 每个主歌词行取开始时间最接近且偏差不超过 translationAlignTolerance 的翻译行，
+// 已有的翻译被覆盖。返回对上的行数，一行都没有对上时返回错误
+func mergeTranslation(lyric *Lyric, data []byte) (int, error) {
+	trans, err := parseLyric(data, "auto")
+	if err != nil {
+		return 0, fmt.Errorf("invalid translation: %w", err)
+	}
+	lines := trans.Lines
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].Start < lines[j].Start })
+
+	matched := 0
+	for i := range lyric.Lines {
+		l := &lyric.Lines[i]
+		if l.IsBackground {
+			continue
+		}
+		// 找到开始时间不早于该行的第一个翻译行，再与其前一行比较取最接近者
+		k := sort.Search(len(lines), func(k int) bool { return lines[k].Start >= l.Start })
+		best, diff := -1, int64(translationAlignTolerance)+1
+		for _, c := range []int{k - 1, k} {
+			if c < 0 || c >= len(lines) {
+				continue
+			}
+			if d := abs64(lines[c].Start - l.Start); d < diff {
+				best, diff = c, d
+			}
+		}
+		if best >= 0 {
+			l.Translation = lines[best].Text
+			matched++
+		}
+	}
+	if matched == 0 {
+		return 0, fmt.Errorf("no translation line aligns with the lyric (tolerance %dms)", translationAlignTolerance)
+	}
+	return matched, nil
+}
+
+func abs64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
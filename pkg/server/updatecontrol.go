@@ -0,0 +1,92 @@
+package server
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// --- 手动更新的冷却与合并 ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	updateCooldown = new(time.Duration) // 两次手动触发的同步之间的最短间隔
+
+	updateMu         sync.Mutex
+	updateInFlight   *updateCall // 正在进行的同步，期间到达的触发共享其结果
+	lastManualUpdate time.Time
+	lastDryRun       time.Time // 上次 dryRun 预览的时间，与真正的同步分开计算冷却
+)
+
+// updateCall 为一次正在进行或已完成的同步
+type updateCall struct {
+	done    chan struct{}
+	updated bool
+}
+
+// updateResult 为一次手动触发的处理结果
+type updateResult struct {
+	Updated    bool
+	Coalesced  bool          // 并入了已在进行的同步
+	RetryAfter time.Duration // 大于 0 表示处于冷却期，本次触发被拒绝
+}
+
+// startUpdate 在持有 updateMu 时登记一次新的同步，并在锁外执行
+func startUpdate() *updateCall {
+	c := &updateCall{done: make(chan struct{})}
+	updateInFlight = c
+	updateMu.Unlock()
+
+	c.updated = runUpdate()
+
+	updateMu.Lock()
+	updateInFlight = nil
+	updateMu.Unlock()
+	close(c.done)
+	return c
+}
+
+// runCoalescedUpdate 执行一次同步；已有同步在进行时等待其完成并返回同一结果（定时同步使用，不受冷却限制）
+func runCoalescedUpdate() bool {
+	updateMu.Lock()
+	if c := updateInFlight; c != nil {
+		updateMu.Unlock()
+		<-c.done
+		return c.updated
+	}
+	return startUpdate().updated
+}
+
+// triggerManualUpdate 处理 /api/update 与 gRPC Update 的触发：
+// 已有同步在进行时并入其中；距上次手动同步不足 -update-cooldown 时拒绝并返回剩余等待时间
+func triggerManualUpdate() updateResult {
+	updateMu.Lock()
+	if c := updateInFlight; c != nil {
+		updateMu.Unlock()
+		<-c.done
+		return updateResult{Updated: c.updated, Coalesced: true}
+	}
+	if remaining := *updateCooldown - time.Since(lastManualUpdate); !lastManualUpdate.IsZero() && remaining > 0 {
+		updateMu.Unlock()
+		return updateResult{RetryAfter: remaining}
+	}
+	lastManualUpdate = time.Now()
+	return updateResult{Updated: startUpdate().updated}
+}
+
+// reserveDryRun 为 dryRun 预览施加 -update-cooldown：预览同样会向远端发起 fetch，
+// 距上次预览不足冷却时间时返回剩余等待时间，否则登记本次预览并返回 0
+func reserveDryRun() time.Duration {
+	updateMu.Lock()
+	defer updateMu.Unlock()
+	if remaining := *updateCooldown - time.Since(lastDryRun); !lastDryRun.IsZero() && remaining > 0 {
+		return remaining
+	}
+	lastDryRun = time.Now()
+	return 0
+}
+
+// retryAfterSeconds 将剩余等待时间向上取整为秒，用于 Retry-After 头
+func retryAfterSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// --- 索引校验 ---
+
+// 校验问题类型
+const (
+	issueMalformedJSON     = "malformed_json"
+	issueEmptyEntry        = "empty_entry"
+	issueMalformedMetadata = "malformed_metadata"
+	issueInvalidMetadata   = "invalid_metadata"
+	issueMissingRawFile    = "missing_raw_file"
+	issueMissingLyricFile  = "missing_lyric_file"
+)
+
+type validationIssue struct {
+	Platform string `json:"platform"`
+	Line     int    `json:"line"`
+	ID       string `json:"id,omitempty"`
+	Kind     string `json:"kind"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+type platformValidation struct {
+	IndexFile string         `json:"index_file"`
+	Lines     int            `json:"lines"`
+	Valid     int            `json:"valid"`
+	Issues    map[string]int `json:"issues"`
+}
+
+// validationReport 为机器可读的校验报告
+type validationReport struct {
+	Root      string                         `json:"root"`
+	OK        bool                           `json:"ok"`
+	Platforms map[string]*platformValidation `json:"platforms"`
+	Issues    []validationIssue              `json:"issues"`
+}
+
+// validateIndex 逐行解析 root 下所有 index 文件，检查格式、元数据结构以及引用的歌词文件是否存在
+func validateIndex(root string) *validationReport {
+	report := &validationReport{
+		Root:      root,
+		Platforms: make(map[string]*platformValidation),
+		Issues:    []validationIssue{},
+	}
+
+	rawDir := filepath.Join(root, "raw-lyrics")
+	platformNames := make([]string, 0)
+	files := indexFiles(root)
+	for name := range files {
+		platformNames = append(platformNames, name)
+	}
+	sort.Strings(platformNames)
+
+	for _, platform := range platformNames {
+		path := files[platform]
+		file, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		pv := &platformValidation{IndexFile: path, Issues: make(map[string]int)}
+		report.Platforms[platform] = pv
+		dir := filepath.Dir(path)
+
+		addIssue := func(line int, id, kind, detail string) {
+			pv.Issues[kind]++
+			report.Issues = append(report.Issues, validationIssue{
+				Platform: platform, Line: line, ID: id, Kind: kind, Detail: detail,
+			})
+		}
+
+		scanner := bufio.NewScanner(file)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		lineNo := 0
+		for scanner.Scan() {
+			lineNo++
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			pv.Lines++
+
+			var entry IndexEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				addIssue(lineNo, "", issueMalformedJSON, err.Error())
+				continue
+			}
+
+			valid := true
+			if entry.ID == "" || entry.RawLyricFile == "" {
+				addIssue(lineNo, entry.ID, issueEmptyEntry, "missing id or rawLyricFile")
+				valid = false
+			}
+			if len(entry.MetadataRaw) == 0 {
+				addIssue(lineNo, entry.ID, issueMalformedMetadata, "metadata is empty")
+				valid = false
+			} else if md, err := parseMetadata(entry.MetadataRaw); err != nil {
+				addIssue(lineNo, entry.ID, issueMalformedMetadata, err.Error())
+				valid = false
+			} else if err := md.validate(); err != nil {
+				addIssue(lineNo, entry.ID, issueInvalidMetadata, err.Error())
+				valid = false
+			}
+			if entry.RawLyricFile != "" {
+				if _, err := os.Stat(filepath.Join(rawDir, entry.RawLyricFile)); err != nil {
+					addIssue(lineNo, entry.ID, issueMissingRawFile, entry.RawLyricFile)
+					valid = false
+				}
+			}
+			// raw 平台的条目只引用 raw-lyrics 中的文件，其余平台还需存在 <id>.ttml
+			if platform != "raw" && entry.ID != "" {
+				if _, err := os.Stat(filepath.Join(dir, entry.ID+".ttml")); err != nil {
+					addIssue(lineNo, entry.ID, issueMissingLyricFile, entry.ID+".ttml")
+					valid = false
+				}
+			}
+			if valid {
+				pv.Valid++
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			addIssue(lineNo+1, "", issueMalformedJSON, err.Error())
+		}
+		file.Close()
+	}
+
+	report.OK = len(report.Issues) == 0
+	return report
+}
+
+func validateHandler(w http.ResponseWriter, r *http.Request) {
+	if actualDataDir == "" {
+		writeError(w, r, http.StatusServiceUnavailable, codeDataUnavailable, "No valid data directory found")
+		return
+	}
+	writeJSON(w, r, validateIndex(actualDataDir))
+}
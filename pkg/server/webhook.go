@@ -0,0 +1,96 @@
+package server
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// --- 出站 Webhook ---
+
+var (
+	// 命令行参数（见 registerServeFlags）
+	webhookURLs   stringList
+	webhookSecret = new(string)
+
+	webhookClient = &http.Client{Timeout: 10 * time.Second}
+)
+
+// webhookEvents 为投递给 -webhook 的事件：主数据仓库同步后的 index-updated 与 -repo 仓库更新后的 repo-updated
+var webhookEvents = []string{"index-updated", "repo-updated"}
+
+// webhookAttempts 为单次投递的最大尝试次数，失败后按 1s、2s… 递增间隔重试
+const webhookAttempts = 3
+
+// signWebhook 返回请求体的 HMAC-SHA256 签名（十六进制），未配置密钥时返回空
+func signWebhook(body []byte) string {
+	if *webhookSecret == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, []byte(*webhookSecret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliverWebhook 向单个地址投递事件，非 2xx 响应视为失败并重试
+func deliverWebhook(url string, ev serverEvent, body []byte) {
+	for attempt := 1; attempt <= webhookAttempts; attempt++ {
+		err := func() error {
+			req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("User-Agent", "amlldb-search-webhook")
+			req.Header.Set("X-AMLL-Event", ev.Type)
+			if sig := signWebhook(body); sig != "" {
+				req.Header.Set("X-AMLL-Signature-256", "sha256="+sig)
+			}
+			resp, err := webhookClient.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				return fmt.Errorf("unexpected status %s", resp.Status)
+			}
+			return nil
+		}()
+		if err == nil {
+			return
+		}
+		logErrorf("Webhook %s delivery failed (attempt %d/%d): %v", url, attempt, webhookAttempts, err)
+		if attempt < webhookAttempts {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+}
+
+// startWebhooks 订阅服务器事件，索引因同步（含 -repo 仓库的拉取）发生变化时向所有 -webhook 地址并行投递
+func startWebhooks() {
+	if len(webhookURLs) == 0 {
+		return
+	}
+	events := subscribeEvents()
+	go func() {
+		for ev := range events {
+			if !slices.Contains(webhookEvents, ev.Type) {
+				continue
+			}
+			body, err := json.Marshal(ev)
+			if err != nil {
+				logErrorf("Webhook payload encoding failed: %v", err)
+				continue
+			}
+			for _, url := range webhookURLs {
+				go deliverWebhook(url, ev, body)
+			}
+		}
+	}()
+}
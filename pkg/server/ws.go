@@ -0,0 +1,39 @@
+package server
+
+import (
+	"golang.org/x/net/websocket"
+)
+
+// --- WebSocket 推送 ---
+
+// wsHandler 将服务器事件（如索引更新）实时推送给 WebSocket 客户端；
+// 桌面播放器等非浏览器客户端通常不带 Origin，因此不做来源校验
+var wsHandler = websocket.Server{Handler: wsStream}
+
+func wsStream(conn *websocket.Conn) {
+	defer conn.Close()
+
+	events := subscribeEvents()
+	defer unsubscribeEvents(events)
+
+	// 客户端无需发送数据，读取仅用于感知连接关闭
+	closed := make(chan struct{})
+	go func() {
+		var discard []byte
+		for websocket.Message.Receive(conn, &discard) == nil {
+		}
+		close(closed)
+	}()
+
+	for {
+		select {
+		case ev := <-events:
+			if err := websocket.JSON.Send(conn, ev); err != nil {
+				logErrorf("WebSocket send failed: %v", err)
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// --- YRC（网易云逐字歌词）解析与输出 ---
+
+var (
+	// timedLineHeaderPattern 匹配 YRC/QRC 的行头 [开始毫秒,持续毫秒]
+	timedLineHeaderPattern = regexp.MustCompile(`^\[(\d+),(\d+)\]`)
+	// yrcWordPattern 匹配 YRC 词前的时间 (开始,持续,0)
+	yrcWordPattern = regexp.MustCompile(`\((\d+),(\d+),-?\d+\)`)
+)
+
+// parseTimedLineHeader 解析 YRC/QRC 的行头，返回行的开始、结束时间与行头之后的内容
+func parseTimedLineHeader(line string) (start, end int64, rest string, ok bool) {
+	m := timedLineHeaderPattern.FindStringSubmatch(line)
+	if m == nil {
+		return 0, 0, "", false
+	}
+	start, _ = strconv.ParseInt(m[1], 10, 64)
+	dur, _ := strconv.ParseInt(m[2], 10, 64)
+	return start, start + dur, line[len(m[0]):], true
+}
+
+// scanTimedLines 逐行解析 YRC/QRC 内容，行头之外的 [ti:] 等标签写入元数据，其余无法识别的行被忽略
+func scanTimedLines(data []byte, parseWords func(text string) []LyricWord) (*Lyric, error) {
+	lyric := &Lyric{Metadata: make(map[string][]string)}
+	scanner := bufio.NewScanner(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		start, end, rest, ok := parseTimedLineHeader(line)
+		if !ok {
+			if m := lrcTagPattern.FindStringSubmatch(line); m != nil {
+				if key, value, ok := strings.Cut(m[1], ":"); ok && strings.TrimSpace(value) != "" {
+					key = strings.ToLower(strings.TrimSpace(key))
+					if k, ok := lrcMetadataKeys[key]; ok {
+						key = k
+					}
+					lyric.Metadata[key] = append(lyric.Metadata[key], strings.TrimSpace(value))
+				}
+			}
+			continue
+		}
+		l := LyricLine{Start: start, End: end, Words: parseWords(rest)}
+		if l.Words == nil {
+			l.Text = rest
+		}
+		lyric.Lines = append(lyric.Lines, l)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	finishLines(lyric)
+	lyric.Lines = dropEmptyLines(lyric.Lines)
+	if len(lyric.Lines) == 0 {
+		return nil, errEmptyLyric
+	}
+	return lyric, nil
+}
+
+// parseYRC 解析 YRC 歌词：每行形如 [开始,持续](词开始,词持续,0)词(词开始,词持续,0)词，
+// 以 { 开头的 JSON 行（作词、作曲等信息）被忽略
+func parseYRC(data []byte) (*Lyric, error) {
+	lyric, err := scanTimedLines(data, func(text string) []LyricWord {
+		locs := yrcWordPattern.FindAllStringSubmatchIndex(text, -1)
+		if len(locs) == 0 {
+			return nil
+		}
+		words := make([]LyricWord, 0, len(locs))
+		for i, loc := range locs {
+			start, _ := strconv.ParseInt(text[loc[2]:loc[3]], 10, 64)
+			dur, _ := strconv.ParseInt(text[loc[4]:loc[5]], 10, 64)
+			end := len(text)
+			if i+1 < len(locs) {
+				end = locs[i+1][0]
+			}
+			words = append(words, LyricWord{Start: start, End: start + dur, Text: text[loc[1]:end]})
+		}
+		return words
+	})
+	if err != nil && !errors.Is(err, errEmptyLyric) {
+		return nil, fmt.Errorf("invalid YRC: %w", err)
+	}
+	return lyric, err
+}
+
+// renderYRC 输出 YRC，没有逐字信息的行作为一个整词输出，背景人声行不单独输出
+func renderYRC(lyric *Lyric) string {
+	var sb strings.Builder
+	for _, line := range lyric.Lines {
+		if line.IsBackground {
+			continue
+		}
+		fmt.Fprintf(&sb, "[%d,%d]", line.Start, max(line.End-line.Start, 0))
+		for _, w := range lineWords(line) {
+			fmt.Fprintf(&sb, "(%d,%d,0)%s", w.Start, max(w.End-w.Start, 0), w.Text)
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// lineWords 返回行的逐字信息，没有时把整行视为一个词
+func lineWords(line LyricLine) []LyricWord {
+	if len(line.Words) > 0 {
+		return line.Words
+	}
+	return []LyricWord{{Start: line.Start, End: line.End, Text: line.Text}}
+}
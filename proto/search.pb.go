@@ -0,0 +1,959 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: search.proto
+
+package searchpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type MetadataField struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Values        []string               `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetadataField) Reset() {
+	*x = MetadataField{}
+	mi := &file_search_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetadataField) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetadataField) ProtoMessage() {}
+
+func (x *MetadataField) ProtoReflect() protoreflect.Message {
+	mi := &file_search_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetadataField.ProtoReflect.Descriptor instead.
+func (*MetadataField) Descriptor() ([]byte, []int) {
+	return file_search_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MetadataField) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *MetadataField) GetValues() []string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type SearchResult struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	RawLyricFile   string                 `protobuf:"bytes,2,opt,name=raw_lyric_file,json=rawLyricFile,proto3" json:"raw_lyric_file,omitempty"`
+	Metadata       []*MetadataField       `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty"`
+	Platforms      []string               `protobuf:"bytes,4,rep,name=platforms,proto3" json:"platforms,omitempty"`
+	Score          float64                `protobuf:"fixed64,5,opt,name=score,proto3" json:"score,omitempty"`
+	Updated        string                 `protobuf:"bytes,6,opt,name=updated,proto3" json:"updated,omitempty"`
+	Lang           string                 `protobuf:"bytes,7,opt,name=lang,proto3" json:"lang,omitempty"`
+	HasTranslation bool                   `protobuf:"varint,8,opt,name=has_translation,json=hasTranslation,proto3" json:"has_translation,omitempty"`
+	HasRoman       bool                   `protobuf:"varint,9,opt,name=has_roman,json=hasRoman,proto3" json:"has_roman,omitempty"`
+	WordTimed      bool                   `protobuf:"varint,10,opt,name=word_timed,json=wordTimed,proto3" json:"word_timed,omitempty"`
+	Source         string                 `protobuf:"bytes,11,opt,name=source,proto3" json:"source,omitempty"`
+	FileSize       *int64                 `protobuf:"varint,12,opt,name=file_size,json=fileSize,proto3,oneof" json:"file_size,omitempty"`
+	FileMtime      string                 `protobuf:"bytes,13,opt,name=file_mtime,json=fileMtime,proto3" json:"file_mtime,omitempty"`
+	PlatformIds    map[string]string      `protobuf:"bytes,14,rep,name=platform_ids,json=platformIds,proto3" json:"platform_ids,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SearchResult) Reset() {
+	*x = SearchResult{}
+	mi := &file_search_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResult) ProtoMessage() {}
+
+func (x *SearchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_search_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResult.ProtoReflect.Descriptor instead.
+func (*SearchResult) Descriptor() ([]byte, []int) {
+	return file_search_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *SearchResult) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SearchResult) GetRawLyricFile() string {
+	if x != nil {
+		return x.RawLyricFile
+	}
+	return ""
+}
+
+func (x *SearchResult) GetMetadata() []*MetadataField {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *SearchResult) GetPlatforms() []string {
+	if x != nil {
+		return x.Platforms
+	}
+	return nil
+}
+
+func (x *SearchResult) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *SearchResult) GetUpdated() string {
+	if x != nil {
+		return x.Updated
+	}
+	return ""
+}
+
+func (x *SearchResult) GetLang() string {
+	if x != nil {
+		return x.Lang
+	}
+	return ""
+}
+
+func (x *SearchResult) GetHasTranslation() bool {
+	if x != nil {
+		return x.HasTranslation
+	}
+	return false
+}
+
+func (x *SearchResult) GetHasRoman() bool {
+	if x != nil {
+		return x.HasRoman
+	}
+	return false
+}
+
+func (x *SearchResult) GetWordTimed() bool {
+	if x != nil {
+		return x.WordTimed
+	}
+	return false
+}
+
+func (x *SearchResult) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *SearchResult) GetFileSize() int64 {
+	if x != nil && x.FileSize != nil {
+		return *x.FileSize
+	}
+	return 0
+}
+
+func (x *SearchResult) GetFileMtime() string {
+	if x != nil {
+		return x.FileMtime
+	}
+	return ""
+}
+
+func (x *SearchResult) GetPlatformIds() map[string]string {
+	if x != nil {
+		return x.PlatformIds
+	}
+	return nil
+}
+
+type SearchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	Platforms     []string               `protobuf:"bytes,2,rep,name=platforms,proto3" json:"platforms,omitempty"`
+	Limit         int32                  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+	MinScore      float64                `protobuf:"fixed64,4,opt,name=min_score,json=minScore,proto3" json:"min_score,omitempty"`
+	Sources       []string               `protobuf:"bytes,5,rep,name=sources,proto3" json:"sources,omitempty"`
+	Phonetic      bool                   `protobuf:"varint,6,opt,name=phonetic,proto3" json:"phonetic,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_search_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_search_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_search_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SearchRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetPlatforms() []string {
+	if x != nil {
+		return x.Platforms
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetMinScore() float64 {
+	if x != nil {
+		return x.MinScore
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+func (x *SearchRequest) GetPhonetic() bool {
+	if x != nil {
+		return x.Phonetic
+	}
+	return false
+}
+
+type SearchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*SearchResult        `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	Truncated     bool                   `protobuf:"varint,2,opt,name=truncated,proto3" json:"truncated,omitempty"`
+	Cached        bool                   `protobuf:"varint,3,opt,name=cached,proto3" json:"cached,omitempty"`
+	Total         int32                  `protobuf:"varint,4,opt,name=total,proto3" json:"total,omitempty"`
+	NextCursor    string                 `protobuf:"bytes,5,opt,name=next_cursor,json=nextCursor,proto3" json:"next_cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_search_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_search_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_search_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SearchResponse) GetResults() []*SearchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *SearchResponse) GetTruncated() bool {
+	if x != nil {
+		return x.Truncated
+	}
+	return false
+}
+
+func (x *SearchResponse) GetCached() bool {
+	if x != nil {
+		return x.Cached
+	}
+	return false
+}
+
+func (x *SearchResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *SearchResponse) GetNextCursor() string {
+	if x != nil {
+		return x.NextCursor
+	}
+	return ""
+}
+
+type LookupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Platform      string                 `protobuf:"bytes,1,opt,name=platform,proto3" json:"platform,omitempty"`
+	MusicId       string                 `protobuf:"bytes,2,opt,name=music_id,json=musicId,proto3" json:"music_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupRequest) Reset() {
+	*x = LookupRequest{}
+	mi := &file_search_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupRequest) ProtoMessage() {}
+
+func (x *LookupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_search_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupRequest.ProtoReflect.Descriptor instead.
+func (*LookupRequest) Descriptor() ([]byte, []int) {
+	return file_search_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *LookupRequest) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *LookupRequest) GetMusicId() string {
+	if x != nil {
+		return x.MusicId
+	}
+	return ""
+}
+
+type DownloadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Platform      string                 `protobuf:"bytes,1,opt,name=platform,proto3" json:"platform,omitempty"`
+	MusicId       string                 `protobuf:"bytes,2,opt,name=music_id,json=musicId,proto3" json:"music_id,omitempty"`
+	Format        string                 `protobuf:"bytes,3,opt,name=format,proto3" json:"format,omitempty"`
+	Source        string                 `protobuf:"bytes,4,opt,name=source,proto3" json:"source,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadRequest) Reset() {
+	*x = DownloadRequest{}
+	mi := &file_search_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadRequest) ProtoMessage() {}
+
+func (x *DownloadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_search_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadRequest.ProtoReflect.Descriptor instead.
+func (*DownloadRequest) Descriptor() ([]byte, []int) {
+	return file_search_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *DownloadRequest) GetPlatform() string {
+	if x != nil {
+		return x.Platform
+	}
+	return ""
+}
+
+func (x *DownloadRequest) GetMusicId() string {
+	if x != nil {
+		return x.MusicId
+	}
+	return ""
+}
+
+func (x *DownloadRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *DownloadRequest) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+type DownloadChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Data          []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadChunk) Reset() {
+	*x = DownloadChunk{}
+	mi := &file_search_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadChunk) ProtoMessage() {}
+
+func (x *DownloadChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_search_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadChunk.ProtoReflect.Descriptor instead.
+func (*DownloadChunk) Descriptor() ([]byte, []int) {
+	return file_search_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DownloadChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_search_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_search_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_search_proto_rawDescGZIP(), []int{7}
+}
+
+type StatusResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Status         string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	LastUpdateTime string                 `protobuf:"bytes,2,opt,name=last_update_time,json=lastUpdateTime,proto3" json:"last_update_time,omitempty"`
+	TotalEntries   int64                  `protobuf:"varint,3,opt,name=total_entries,json=totalEntries,proto3" json:"total_entries,omitempty"`
+	PlatformStats  map[string]int64       `protobuf:"bytes,4,rep,name=platform_stats,json=platformStats,proto3" json:"platform_stats,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	RepoUrl        string                 `protobuf:"bytes,5,opt,name=repo_url,json=repoUrl,proto3" json:"repo_url,omitempty"`
+	CacheSize      int64                  `protobuf:"varint,6,opt,name=cache_size,json=cacheSize,proto3" json:"cache_size,omitempty"`
+	DataCommit     string                 `protobuf:"bytes,7,opt,name=data_commit,json=dataCommit,proto3" json:"data_commit,omitempty"`
+	DataCommitTime string                 `protobuf:"bytes,8,opt,name=data_commit_time,json=dataCommitTime,proto3" json:"data_commit_time,omitempty"`
+	DataBranch     string                 `protobuf:"bytes,9,opt,name=data_branch,json=dataBranch,proto3" json:"data_branch,omitempty"`
+	StartedAt      string                 `protobuf:"bytes,10,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	UptimeSeconds  int64                  `protobuf:"varint,11,opt,name=uptime_seconds,json=uptimeSeconds,proto3" json:"uptime_seconds,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_search_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_search_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_search_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *StatusResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetLastUpdateTime() string {
+	if x != nil {
+		return x.LastUpdateTime
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetTotalEntries() int64 {
+	if x != nil {
+		return x.TotalEntries
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetPlatformStats() map[string]int64 {
+	if x != nil {
+		return x.PlatformStats
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetRepoUrl() string {
+	if x != nil {
+		return x.RepoUrl
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetCacheSize() int64 {
+	if x != nil {
+		return x.CacheSize
+	}
+	return 0
+}
+
+func (x *StatusResponse) GetDataCommit() string {
+	if x != nil {
+		return x.DataCommit
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetDataCommitTime() string {
+	if x != nil {
+		return x.DataCommitTime
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetDataBranch() string {
+	if x != nil {
+		return x.DataBranch
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetStartedAt() string {
+	if x != nil {
+		return x.StartedAt
+	}
+	return ""
+}
+
+func (x *StatusResponse) GetUptimeSeconds() int64 {
+	if x != nil {
+		return x.UptimeSeconds
+	}
+	return 0
+}
+
+type UpdateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateRequest) Reset() {
+	*x = UpdateRequest{}
+	mi := &file_search_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateRequest) ProtoMessage() {}
+
+func (x *UpdateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_search_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateRequest.ProtoReflect.Descriptor instead.
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return file_search_proto_rawDescGZIP(), []int{9}
+}
+
+type UpdateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Updated       bool                   `protobuf:"varint,1,opt,name=updated,proto3" json:"updated,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateResponse) Reset() {
+	*x = UpdateResponse{}
+	mi := &file_search_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateResponse) ProtoMessage() {}
+
+func (x *UpdateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_search_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateResponse.ProtoReflect.Descriptor instead.
+func (*UpdateResponse) Descriptor() ([]byte, []int) {
+	return file_search_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UpdateResponse) GetUpdated() bool {
+	if x != nil {
+		return x.Updated
+	}
+	return false
+}
+
+func (x *UpdateResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+var File_search_proto protoreflect.FileDescriptor
+
+const file_search_proto_rawDesc = "" +
+	"\n" +
+	"\fsearch.proto\x12\ramllsearch.v1\"9\n" +
+	"\rMetadataField\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
+	"\x06values\x18\x02 \x03(\tR\x06values\"\xbd\x04\n" +
+	"\fSearchResult\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12$\n" +
+	"\x0eraw_lyric_file\x18\x02 \x01(\tR\frawLyricFile\x128\n" +
+	"\bmetadata\x18\x03 \x03(\v2\x1c.amllsearch.v1.MetadataFieldR\bmetadata\x12\x1c\n" +
+	"\tplatforms\x18\x04 \x03(\tR\tplatforms\x12\x14\n" +
+	"\x05score\x18\x05 \x01(\x01R\x05score\x12\x18\n" +
+	"\aupdated\x18\x06 \x01(\tR\aupdated\x12\x12\n" +
+	"\x04lang\x18\a \x01(\tR\x04lang\x12'\n" +
+	"\x0fhas_translation\x18\b \x01(\bR\x0ehasTranslation\x12\x1b\n" +
+	"\thas_roman\x18\t \x01(\bR\bhasRoman\x12\x1d\n" +
+	"\n" +
+	"word_timed\x18\n" +
+	" \x01(\bR\twordTimed\x12\x16\n" +
+	"\x06source\x18\v \x01(\tR\x06source\x12 \n" +
+	"\tfile_size\x18\f \x01(\x03H\x00R\bfileSize\x88\x01\x01\x12\x1d\n" +
+	"\n" +
+	"file_mtime\x18\r \x01(\tR\tfileMtime\x12O\n" +
+	"\fplatform_ids\x18\x0e \x03(\v2,.amllsearch.v1.SearchResult.PlatformIdsEntryR\vplatformIds\x1a>\n" +
+	"\x10PlatformIdsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01B\f\n" +
+	"\n" +
+	"_file_size\"\xac\x01\n" +
+	"\rSearchRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x1c\n" +
+	"\tplatforms\x18\x02 \x03(\tR\tplatforms\x12\x14\n" +
+	"\x05limit\x18\x03 \x01(\x05R\x05limit\x12\x1b\n" +
+	"\tmin_score\x18\x04 \x01(\x01R\bminScore\x12\x18\n" +
+	"\asources\x18\x05 \x03(\tR\asources\x12\x1a\n" +
+	"\bphonetic\x18\x06 \x01(\bR\bphonetic\"\xb4\x01\n" +
+	"\x0eSearchResponse\x125\n" +
+	"\aresults\x18\x01 \x03(\v2\x1b.amllsearch.v1.SearchResultR\aresults\x12\x1c\n" +
+	"\ttruncated\x18\x02 \x01(\bR\ttruncated\x12\x16\n" +
+	"\x06cached\x18\x03 \x01(\bR\x06cached\x12\x14\n" +
+	"\x05total\x18\x04 \x01(\x05R\x05total\x12\x1f\n" +
+	"\vnext_cursor\x18\x05 \x01(\tR\n" +
+	"nextCursor\"F\n" +
+	"\rLookupRequest\x12\x1a\n" +
+	"\bplatform\x18\x01 \x01(\tR\bplatform\x12\x19\n" +
+	"\bmusic_id\x18\x02 \x01(\tR\amusicId\"x\n" +
+	"\x0fDownloadRequest\x12\x1a\n" +
+	"\bplatform\x18\x01 \x01(\tR\bplatform\x12\x19\n" +
+	"\bmusic_id\x18\x02 \x01(\tR\amusicId\x12\x16\n" +
+	"\x06format\x18\x03 \x01(\tR\x06format\x12\x16\n" +
+	"\x06source\x18\x04 \x01(\tR\x06source\"#\n" +
+	"\rDownloadChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\"\x0f\n" +
+	"\rStatusRequest\"\xfe\x03\n" +
+	"\x0eStatusResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12(\n" +
+	"\x10last_update_time\x18\x02 \x01(\tR\x0elastUpdateTime\x12#\n" +
+	"\rtotal_entries\x18\x03 \x01(\x03R\ftotalEntries\x12W\n" +
+	"\x0eplatform_stats\x18\x04 \x03(\v20.amllsearch.v1.StatusResponse.PlatformStatsEntryR\rplatformStats\x12\x19\n" +
+	"\brepo_url\x18\x05 \x01(\tR\arepoUrl\x12\x1d\n" +
+	"\n" +
+	"cache_size\x18\x06 \x01(\x03R\tcacheSize\x12\x1f\n" +
+	"\vdata_commit\x18\a \x01(\tR\n" +
+	"dataCommit\x12(\n" +
+	"\x10data_commit_time\x18\b \x01(\tR\x0edataCommitTime\x12\x1f\n" +
+	"\vdata_branch\x18\t \x01(\tR\n" +
+	"dataBranch\x12\x1d\n" +
+	"\n" +
+	"started_at\x18\n" +
+	" \x01(\tR\tstartedAt\x12%\n" +
+	"\x0euptime_seconds\x18\v \x01(\x03R\ruptimeSeconds\x1a@\n" +
+	"\x12PlatformStatsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x03R\x05value:\x028\x01\"\x0f\n" +
+	"\rUpdateRequest\"D\n" +
+	"\x0eUpdateResponse\x12\x18\n" +
+	"\aupdated\x18\x01 \x01(\bR\aupdated\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage2\xc0\x03\n" +
+	"\vLyricSearch\x12E\n" +
+	"\x06Search\x12\x1c.amllsearch.v1.SearchRequest\x1a\x1d.amllsearch.v1.SearchResponse\x12K\n" +
+	"\fSearchStream\x12\x1c.amllsearch.v1.SearchRequest\x1a\x1b.amllsearch.v1.SearchResult0\x01\x12C\n" +
+	"\x06Lookup\x12\x1c.amllsearch.v1.LookupRequest\x1a\x1b.amllsearch.v1.SearchResult\x12J\n" +
+	"\bDownload\x12\x1e.amllsearch.v1.DownloadRequest\x1a\x1c.amllsearch.v1.DownloadChunk0\x01\x12E\n" +
+	"\x06Status\x12\x1c.amllsearch.v1.StatusRequest\x1a\x1d.amllsearch.v1.StatusResponse\x12E\n" +
+	"\x06Update\x12\x1c.amllsearch.v1.UpdateRequest\x1a\x1d.amllsearch.v1.UpdateResponseB\x1eZ\x1camlldb-search/proto;searchpbb\x06proto3"
+
+var (
+	file_search_proto_rawDescOnce sync.Once
+	file_search_proto_rawDescData []byte
+)
+
+func file_search_proto_rawDescGZIP() []byte {
+	file_search_proto_rawDescOnce.Do(func() {
+		file_search_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_search_proto_rawDesc), len(file_search_proto_rawDesc)))
+	})
+	return file_search_proto_rawDescData
+}
+
+var file_search_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_search_proto_goTypes = []any{
+	(*MetadataField)(nil),   // 0: amllsearch.v1.MetadataField
+	(*SearchResult)(nil),    // 1: amllsearch.v1.SearchResult
+	(*SearchRequest)(nil),   // 2: amllsearch.v1.SearchRequest
+	(*SearchResponse)(nil),  // 3: amllsearch.v1.SearchResponse
+	(*LookupRequest)(nil),   // 4: amllsearch.v1.LookupRequest
+	(*DownloadRequest)(nil), // 5: amllsearch.v1.DownloadRequest
+	(*DownloadChunk)(nil),   // 6: amllsearch.v1.DownloadChunk
+	(*StatusRequest)(nil),   // 7: amllsearch.v1.StatusRequest
+	(*StatusResponse)(nil),  // 8: amllsearch.v1.StatusResponse
+	(*UpdateRequest)(nil),   // 9: amllsearch.v1.UpdateRequest
+	(*UpdateResponse)(nil),  // 10: amllsearch.v1.UpdateResponse
+	nil,                     // 11: amllsearch.v1.SearchResult.PlatformIdsEntry
+	nil,                     // 12: amllsearch.v1.StatusResponse.PlatformStatsEntry
+}
+var file_search_proto_depIdxs = []int32{
+	0,  // 0: amllsearch.v1.SearchResult.metadata:type_name -> amllsearch.v1.MetadataField
+	11, // 1: amllsearch.v1.SearchResult.platform_ids:type_name -> amllsearch.v1.SearchResult.PlatformIdsEntry
+	1,  // 2: amllsearch.v1.SearchResponse.results:type_name -> amllsearch.v1.SearchResult
+	12, // 3: amllsearch.v1.StatusResponse.platform_stats:type_name -> amllsearch.v1.StatusResponse.PlatformStatsEntry
+	2,  // 4: amllsearch.v1.LyricSearch.Search:input_type -> amllsearch.v1.SearchRequest
+	2,  // 5: amllsearch.v1.LyricSearch.SearchStream:input_type -> amllsearch.v1.SearchRequest
+	4,  // 6: amllsearch.v1.LyricSearch.Lookup:input_type -> amllsearch.v1.LookupRequest
+	5,  // 7: amllsearch.v1.LyricSearch.Download:input_type -> amllsearch.v1.DownloadRequest
+	7,  // 8: amllsearch.v1.LyricSearch.Status:input_type -> amllsearch.v1.StatusRequest
+	9,  // 9: amllsearch.v1.LyricSearch.Update:input_type -> amllsearch.v1.UpdateRequest
+	3,  // 10: amllsearch.v1.LyricSearch.Search:output_type -> amllsearch.v1.SearchResponse
+	1,  // 11: amllsearch.v1.LyricSearch.SearchStream:output_type -> amllsearch.v1.SearchResult
+	1,  // 12: amllsearch.v1.LyricSearch.Lookup:output_type -> amllsearch.v1.SearchResult
+	6,  // 13: amllsearch.v1.LyricSearch.Download:output_type -> amllsearch.v1.DownloadChunk
+	8,  // 14: amllsearch.v1.LyricSearch.Status:output_type -> amllsearch.v1.StatusResponse
+	10, // 15: amllsearch.v1.LyricSearch.Update:output_type -> amllsearch.v1.UpdateResponse
+	10, // [10:16] is the sub-list for method output_type
+	4,  // [4:10] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_search_proto_init() }
+func file_search_proto_init() {
+	if File_search_proto != nil {
+		return
+	}
+	file_search_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_search_proto_rawDesc), len(file_search_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_search_proto_goTypes,
+		DependencyIndexes: file_search_proto_depIdxs,
+		MessageInfos:      file_search_proto_msgTypes,
+	}.Build()
+	File_search_proto = out.File
+	file_search_proto_goTypes = nil
+	file_search_proto_depIdxs = nil
+}